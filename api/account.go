@@ -44,13 +44,58 @@ func (s *AccountHandler) HandleUniqueAccount(w http.ResponseWriter, r *http.Requ
 	}
 }
 
+/*
+HandleAccountStatus routes the request to the appropriate handler for /account/{id}/status endpoint.
+*/
+func (s *AccountHandler) HandleAccountStatus(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return s.getAccountStatusHistory(w, r)
+	case "POST":
+		return s.changeAccountStatus(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleAccountOverdraft routes the request to the appropriate handler for /account/{id}/overdraft endpoint.
+*/
+func (s *AccountHandler) HandleAccountOverdraft(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "PUT":
+		return s.setAccountOverdraft(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleAccountLimits routes the request to the appropriate handler for /account/{id}/limits endpoint.
+*/
+func (s *AccountHandler) HandleAccountLimits(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return s.getAccountLimits(w, r)
+	case "PUT":
+		return s.setAccountLimits(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
 /* ------------------------------- Controller ------------------------------- */
 
 /*
 getAccounts is the controller method that handles the GET /account endpoint.
 */
 func (s *AccountHandler) getAccounts(w http.ResponseWriter, r *http.Request) error {
-	accounts, err := s.service.Account.GetAll()
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	accounts, err := s.service.Account.GetAll(p)
 	if err != nil {
 		return err
 	}
@@ -62,6 +107,11 @@ func (s *AccountHandler) getAccounts(w http.ResponseWriter, r *http.Request) err
 createAccount is the controller method that handles the POST /account endpoint.
 */
 func (s *AccountHandler) createAccount(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
 	data := new(dto.CreateAccountDTO)
 
 	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
@@ -69,21 +119,32 @@ func (s *AccountHandler) createAccount(w http.ResponseWriter, r *http.Request) e
 	}
 	defer r.Body.Close()
 
-	err := s.service.Account.Create(data)
+	account, err := s.service.Account.Create(p, data)
 	if err != nil {
 		if err.Error() == "user_not_found" {
 			return NewApiError(http.StatusBadRequest, "user_not_found")
+		} else if err.Error() == "forbidden" {
+			return NewApiError(http.StatusForbidden, "forbidden")
+		} else if err.Error() == "user_not_verified" {
+			return NewApiError(http.StatusForbidden, "user_not_verified")
+		} else if err.Error() == "invalid_account_type" || err.Error() == "invalid_maturity_date" || err.Error() == "invalid_currency" {
+			return NewApiError(http.StatusBadRequest, err.Error())
 		}
 		return err
 	}
 
-	return WriteJSON(w, http.StatusCreated, NewApiResponse(http.StatusCreated, data, r))
+	return WriteJSON(w, http.StatusCreated, NewApiResponse(http.StatusCreated, account, r))
 }
 
 /*
 getAccount is the controller method that handles the GET /account/{id} endpoint.
 */
 func (s *AccountHandler) getAccount(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
 	id, err := GetIntParameter(r, "id")
 	if err != nil {
 		return NewApiError(http.StatusBadRequest, "missing_account_id")
@@ -92,7 +153,7 @@ func (s *AccountHandler) getAccount(w http.ResponseWriter, r *http.Request) erro
 	param := r.URL.Query()
 	_, exist := param["user"]
 
-	a, err := s.service.Account.Get(id, exist)
+	a, err := s.service.Account.Get(p, id, exist)
 
 	if err != nil {
 		if err.Error() == "account_not_found" {
@@ -105,3 +166,153 @@ func (s *AccountHandler) getAccount(w http.ResponseWriter, r *http.Request) erro
 
 	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, a, r))
 }
+
+/*
+getAccountStatusHistory is the controller method that handles the GET /account/{id}/status endpoint.
+*/
+func (s *AccountHandler) getAccountStatusHistory(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	history, err := s.service.Account.GetStatusHistory(p, id)
+	if err != nil {
+		return accountError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, history, r))
+}
+
+/*
+changeAccountStatus is the controller method that handles the POST /account/{id}/status endpoint.
+*/
+func (s *AccountHandler) changeAccountStatus(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	data := new(dto.ChangeAccountStatusDTO)
+
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		return NewApiError(http.StatusBadRequest, "invalid_request_body")
+	}
+	defer r.Body.Close()
+
+	change, err := s.service.Account.ChangeStatus(p, id, data)
+	if err != nil {
+		return accountError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, change, r))
+}
+
+/*
+setAccountOverdraft is the controller method that handles the PUT /account/{id}/overdraft endpoint.
+*/
+func (s *AccountHandler) setAccountOverdraft(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	data := new(dto.SetOverdraftDTO)
+
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		return NewApiError(http.StatusBadRequest, "invalid_request_body")
+	}
+	defer r.Body.Close()
+
+	a, err := s.service.Account.SetOverdraft(p, id, data)
+	if err != nil {
+		return accountError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, a, r))
+}
+
+/*
+getAccountLimits is the controller method that handles the GET /account/{id}/limits endpoint.
+*/
+func (s *AccountHandler) getAccountLimits(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	limits, err := s.service.Account.GetLimits(p, id)
+	if err != nil {
+		return accountError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, limits, r))
+}
+
+/*
+setAccountLimits is the controller method that handles the PUT /account/{id}/limits endpoint.
+*/
+func (s *AccountHandler) setAccountLimits(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	data := new(dto.SetTransferLimitsDTO)
+
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		return NewApiError(http.StatusBadRequest, "invalid_request_body")
+	}
+	defer r.Body.Close()
+
+	limits, err := s.service.Account.SetLimits(p, id, data)
+	if err != nil {
+		return accountError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, limits, r))
+}
+
+/*
+accountError maps the account service errors to the appropriate API error.
+*/
+func accountError(err error) error {
+	switch err.Error() {
+	case "invalid_account_id", "invalid_status", "empty_reason", "invalid_overdraft_limit", "overdraft_not_allowed", "invalid_limit":
+		return NewApiError(http.StatusBadRequest, err.Error())
+	case "account_not_found":
+		return NewApiError(http.StatusNotFound, err.Error())
+	case "invalid_status_transition", "non_zero_balance", "active_holds", "accrued_interest_outstanding", "active_standing_orders",
+		"overdraft_below_balance":
+		return NewApiError(http.StatusConflict, err.Error())
+	case "forbidden":
+		return NewApiError(http.StatusForbidden, err.Error())
+	default:
+		return err
+	}
+}
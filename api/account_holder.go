@@ -0,0 +1,271 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/services"
+	"github.com/farischt/gobank/pkg/types"
+)
+
+type AccountHolderHandler struct {
+	service *services.Service
+}
+
+func NewAccountHolderHandler(service *services.Service) *AccountHolderHandler {
+	return &AccountHolderHandler{
+		service: service,
+	}
+}
+
+/*
+HandleAccountHolders routes the request to the appropriate handler for /account/{id}/holders endpoint.
+*/
+func (s *AccountHolderHandler) HandleAccountHolders(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return s.getAccountHolders(w, r)
+	case "POST":
+		return s.addAccountHolder(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleUniqueAccountHolder routes the request to the appropriate handler for /account/{id}/holders/{userId} endpoint.
+*/
+func (s *AccountHolderHandler) HandleUniqueAccountHolder(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "DELETE":
+		return s.removeAccountHolder(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleAccountHolderChanges routes the request to the appropriate handler for /account/{id}/holder-changes endpoint.
+*/
+func (s *AccountHolderHandler) HandleAccountHolderChanges(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return s.getAccountHolderChanges(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleAccountHolderChangeApprove routes the request to the appropriate handler for /account/{id}/holder-changes/{changeId}/approve endpoint.
+*/
+func (s *AccountHolderHandler) HandleAccountHolderChangeApprove(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "POST":
+		return s.approveAccountHolderChange(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleAccountHolderChangeReject routes the request to the appropriate handler for /account/{id}/holder-changes/{changeId}/reject endpoint.
+*/
+func (s *AccountHolderHandler) HandleAccountHolderChangeReject(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "POST":
+		return s.rejectAccountHolderChange(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/* ------------------------------- Controller ------------------------------- */
+
+/*
+getAccountHolders is the controller that handles the GET /account/{id}/holders endpoint.
+*/
+func (s *AccountHolderHandler) getAccountHolders(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	holders, err := s.service.AccountHolder.GetAll(p, id)
+	if err != nil {
+		return accountHolderError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, holders, r))
+}
+
+/*
+addAccountHolder is the controller that handles the POST /account/{id}/holders endpoint.
+The change is answered with 202 while it awaits the consent of the primary holder.
+*/
+func (s *AccountHolderHandler) addAccountHolder(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	data := new(dto.AddAccountHolderDTO)
+
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		return NewApiError(http.StatusBadRequest, "invalid_request_body")
+	}
+	defer r.Body.Close()
+
+	change, err := s.service.AccountHolder.Add(p, id, data)
+	if err != nil {
+		return accountHolderError(err)
+	}
+
+	status := holderChangeStatus(change, http.StatusCreated)
+	return WriteJSON(w, status, NewApiResponse(status, change, r))
+}
+
+/*
+removeAccountHolder is the controller that handles the DELETE /account/{id}/holders/{userId} endpoint.
+The change is answered with 202 while it awaits the consent of the primary holder.
+*/
+func (s *AccountHolderHandler) removeAccountHolder(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	userId, err := GetIntParameter(r, "userId")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_user_id")
+	}
+
+	change, err := s.service.AccountHolder.Remove(p, id, userId)
+	if err != nil {
+		return accountHolderError(err)
+	}
+
+	status := holderChangeStatus(change, http.StatusOK)
+	return WriteJSON(w, status, NewApiResponse(status, change, r))
+}
+
+/*
+getAccountHolderChanges is the controller that handles the GET /account/{id}/holder-changes endpoint.
+*/
+func (s *AccountHolderHandler) getAccountHolderChanges(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	changes, err := s.service.AccountHolder.GetChanges(p, id)
+	if err != nil {
+		return accountHolderError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, changes, r))
+}
+
+/*
+approveAccountHolderChange is the controller that handles the POST /account/{id}/holder-changes/{changeId}/approve endpoint.
+*/
+func (s *AccountHolderHandler) approveAccountHolderChange(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	changeId, err := GetIntParameter(r, "changeId")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_holder_change_id")
+	}
+
+	change, err := s.service.AccountHolder.Approve(p, id, changeId)
+	if err != nil {
+		return accountHolderError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, change, r))
+}
+
+/*
+rejectAccountHolderChange is the controller that handles the POST /account/{id}/holder-changes/{changeId}/reject endpoint.
+*/
+func (s *AccountHolderHandler) rejectAccountHolderChange(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	changeId, err := GetIntParameter(r, "changeId")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_holder_change_id")
+	}
+
+	change, err := s.service.AccountHolder.Reject(p, id, changeId)
+	if err != nil {
+		return accountHolderError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, change, r))
+}
+
+/*
+holderChangeStatus returns the status answering a change of holders, 202 while it is pending.
+*/
+func holderChangeStatus(change *types.SerializedAccountHolderChange, applied int) int {
+	if change.Status == types.HolderChangePending {
+		return http.StatusAccepted
+	}
+	return applied
+}
+
+/*
+accountHolderError maps the account holder service errors to the appropriate API error.
+*/
+func accountHolderError(err error) error {
+	switch err.Error() {
+	case "invalid_account_id", "invalid_user_id", "invalid_account_holder_change_id", "invalid_holder_role", "invalid_email",
+		"cannot_remove_primary_holder":
+		return NewApiError(http.StatusBadRequest, err.Error())
+	case "account_not_found", "user_not_found", "account_holder_not_found", "account_holder_change_not_found":
+		return NewApiError(http.StatusNotFound, err.Error())
+	case "account_holder_already_exist", "account_holder_change_already_exist", "account_holder_change_not_pending":
+		return NewApiError(http.StatusConflict, err.Error())
+	case "user_not_verified", "account_closed", "forbidden":
+		return NewApiError(http.StatusForbidden, err.Error())
+	default:
+		return err
+	}
+}
@@ -0,0 +1,234 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/services"
+	"github.com/farischt/gobank/pkg/types"
+)
+
+type AdminHandler struct {
+	service *services.Service
+}
+
+func NewAdminHandler(service *services.Service) *AdminHandler {
+	return &AdminHandler{
+		service: service,
+	}
+}
+
+/*
+HandleUserRoles routes the request to the appropriate handler for /admin/user/{id}/roles endpoint.
+*/
+func (h *AdminHandler) HandleUserRoles(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return h.getUserRoles(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleUniqueUserRole routes the request to the appropriate handler for /admin/user/{id}/roles/{role} endpoint.
+*/
+func (h *AdminHandler) HandleUniqueUserRole(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "PUT":
+		return h.grantRole(w, r)
+	case "DELETE":
+		return h.revokeRole(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleAudit routes the request to the appropriate handler for /admin/audit endpoint.
+*/
+func (h *AdminHandler) HandleAudit(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return h.getAuditLog(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleFxRates routes the request to the appropriate handler for /admin/fx-rates endpoint.
+*/
+func (h *AdminHandler) HandleFxRates(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return h.getFxRates(w, r)
+	case "PUT":
+		return h.setFxRate(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/* ------------------------------- Controller ------------------------------- */
+
+/*
+getUserRoles is the controller method that handles the GET /admin/user/{id}/roles endpoint.
+*/
+func (h *AdminHandler) getUserRoles(w http.ResponseWriter, r *http.Request) error {
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_user_id")
+	}
+
+	roles, err := h.service.Role.GetRoles(id)
+	if err != nil {
+		return roleError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, roles, r))
+}
+
+/*
+grantRole is the controller method that handles the PUT /admin/user/{id}/roles/{role} endpoint.
+*/
+func (h *AdminHandler) grantRole(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_user_id")
+	}
+
+	role, err := GetStringParameter(r, "role")
+	if err != nil {
+		return err
+	}
+
+	err = h.service.Role.Grant(p, id, types.Role(role))
+	if err != nil {
+		return roleError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, nil, r))
+}
+
+/*
+revokeRole is the controller method that handles the DELETE /admin/user/{id}/roles/{role} endpoint.
+*/
+func (h *AdminHandler) revokeRole(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_user_id")
+	}
+
+	role, err := GetStringParameter(r, "role")
+	if err != nil {
+		return err
+	}
+
+	err = h.service.Role.Revoke(p, id, types.Role(role))
+	if err != nil {
+		return roleError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, nil, r))
+}
+
+/*
+getAuditLog is the controller method that handles the GET /admin/audit endpoint.
+*/
+func (h *AdminHandler) getAuditLog(w http.ResponseWriter, r *http.Request) error {
+	offset, err := GetIntQuery(r, "offset", 0)
+	if err != nil {
+		return err
+	}
+
+	limit, err := GetIntQuery(r, "limit", 50)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.service.Audit.GetAll(offset, limit)
+	if err != nil {
+		return err
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, entries, r))
+}
+
+/*
+roleError maps the role service errors to the appropriate API error.
+*/
+func roleError(err error) error {
+	switch err.Error() {
+	case "invalid_user_id", "invalid_role", "cannot_revoke_own_admin_role":
+		return NewApiError(http.StatusBadRequest, err.Error())
+	case "user_not_found", "role_not_granted":
+		return NewApiError(http.StatusNotFound, err.Error())
+	case "forbidden":
+		return NewApiError(http.StatusForbidden, err.Error())
+	default:
+		return err
+	}
+}
+
+/*
+getFxRates is the controller method that handles the GET /admin/fx-rates endpoint.
+It returns the latest rate of every currency pair.
+*/
+func (h *AdminHandler) getFxRates(w http.ResponseWriter, r *http.Request) error {
+	rates, err := h.service.Fx.GetRates()
+	if err != nil {
+		return err
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, rates, r))
+}
+
+/*
+setFxRate is the controller method that handles the PUT /admin/fx-rates endpoint.
+*/
+func (h *AdminHandler) setFxRate(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	data := new(dto.SetFxRateDTO)
+
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		return NewApiError(http.StatusBadRequest, "invalid_request_body")
+	}
+	defer r.Body.Close()
+
+	rate, err := h.service.Fx.SetRate(p, data)
+	if err != nil {
+		return fxError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, rate, r))
+}
+
+/*
+fxError maps the fx service errors to the appropriate API error.
+*/
+func fxError(err error) error {
+	switch err.Error() {
+	case "invalid_currency", "invalid_rate", "invalid_as_of":
+		return NewApiError(http.StatusBadRequest, err.Error())
+	case "forbidden":
+		return NewApiError(http.StatusForbidden, err.Error())
+	default:
+		return err
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/services"
+)
+
+type ApiKeyHandler struct {
+	service *services.Service
+}
+
+func NewApiKeyHandler(service *services.Service) *ApiKeyHandler {
+	return &ApiKeyHandler{
+		service: service,
+	}
+}
+
+/*
+HandleApiKey routes the request to the appropriate handler for /api-keys endpoint.
+*/
+func (h *ApiKeyHandler) HandleApiKey(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return h.getApiKeys(w, r)
+	case "POST":
+		return h.createApiKey(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleUniqueApiKey routes the request to the appropriate handler for /api-keys/{id} endpoint.
+*/
+func (h *ApiKeyHandler) HandleUniqueApiKey(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "DELETE":
+		return h.revokeApiKey(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/* ------------------------------- Controller ------------------------------- */
+
+/*
+getApiKeys is the controller method that handles the GET /api-keys endpoint.
+*/
+func (h *ApiKeyHandler) getApiKeys(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	keys, err := h.service.ApiKey.GetAll(p)
+	if err != nil {
+		return apiKeyError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, keys, r))
+}
+
+/*
+createApiKey is the controller method that handles the POST /api-keys endpoint.
+*/
+func (h *ApiKeyHandler) createApiKey(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	data := new(dto.CreateApiKeyDTO)
+
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		return NewApiError(http.StatusBadRequest, "invalid_request_body")
+	}
+	defer r.Body.Close()
+
+	key, err := h.service.ApiKey.Create(p, data)
+	if err != nil {
+		return apiKeyError(err)
+	}
+
+	return WriteJSON(w, http.StatusCreated, NewApiResponse(http.StatusCreated, key, r))
+}
+
+/*
+revokeApiKey is the controller method that handles the DELETE /api-keys/{id} endpoint.
+*/
+func (h *ApiKeyHandler) revokeApiKey(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_api_key_id")
+	}
+
+	err = h.service.ApiKey.Revoke(p, id)
+	if err != nil {
+		return apiKeyError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, nil, r))
+}
+
+/*
+apiKeyError maps the API key service errors to the appropriate API error.
+*/
+func apiKeyError(err error) error {
+	switch err.Error() {
+	case "empty_name", "empty_scopes", "invalid_scope", "invalid_expires_at", "invalid_allowed_ip", "invalid_api_key_id", "account_not_selected":
+		return NewApiError(http.StatusBadRequest, err.Error())
+	case "api_key_not_found":
+		return NewApiError(http.StatusNotFound, err.Error())
+	case "forbidden":
+		return NewApiError(http.StatusForbidden, err.Error())
+	default:
+		return err
+	}
+}
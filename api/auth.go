@@ -41,7 +41,8 @@ func (h *AuthenticationHandler) HandleLogout(w http.ResponseWriter, r *http.Requ
 
 /*
 login is the controller that handles the POST /auth/login endpoint.
-It creates a new token for the user.
+It creates a new token for the user, logged in with its email or, for compatibility,
+with the number of one of its accounts.
 */
 func (h *AuthenticationHandler) login(w http.ResponseWriter, r *http.Request) error {
 	data := new(dto.LoginDTO)
@@ -51,10 +52,17 @@ func (h *AuthenticationHandler) login(w http.ResponseWriter, r *http.Request) er
 	}
 	defer r.Body.Close()
 
-	token, err := h.service.Session.Create(data.AccountNumber, data.Password)
+	token, err := h.service.Session.Create(data)
 
 	if err != nil {
-		return err
+		switch err.Error() {
+		case "missing_email", "invalid_iban":
+			return NewApiError(http.StatusBadRequest, err.Error())
+		case "invalid_credentials":
+			return NewApiError(http.StatusUnauthorized, err.Error())
+		default:
+			return err
+		}
 	}
 
 	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, token, r))
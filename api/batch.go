@@ -0,0 +1,259 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/importer"
+	"github.com/farischt/gobank/pkg/services"
+	"github.com/farischt/gobank/pkg/types"
+)
+
+// Maximum size of an uploaded batch file
+const maxBatchFileSize = 5 << 20
+
+type BatchHandler struct {
+	service *services.Service
+}
+
+func NewBatchHandler(service *services.Service) *BatchHandler {
+	return &BatchHandler{
+		service: service,
+	}
+}
+
+/*
+HandleBatches routes the request to the appropriate handler for /account/{id}/batches endpoint.
+*/
+func (s *BatchHandler) HandleBatches(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return s.getBatches(w, r)
+	case "POST":
+		return s.createBatch(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleUniqueBatch routes the request to the appropriate handler for /account/{id}/batches/{batchId} endpoint.
+*/
+func (s *BatchHandler) HandleUniqueBatch(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return s.getBatch(w, r)
+	case "DELETE":
+		return s.cancelBatch(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleBatchConfirm routes the request to the appropriate handler for /account/{id}/batches/{batchId}/confirm endpoint.
+*/
+func (s *BatchHandler) HandleBatchConfirm(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "POST":
+		return s.confirmBatch(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/* ------------------------------- Controller ------------------------------- */
+
+/*
+getBatches is the controller that handles the GET /account/{id}/batches endpoint.
+*/
+func (s *BatchHandler) getBatches(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	batches, err := s.service.Batch.GetAll(p, id)
+	if err != nil {
+		return batchError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, batches, r))
+}
+
+/*
+createBatch is the controller that handles the POST /account/{id}/batches endpoint.
+The body is a pain.001 or CSV file, given by the format query parameter or else by the content type.
+The mode query parameter chooses between all_or_nothing, the default, and best_effort execution.
+The control_sum query parameter gives the control sum of a CSV file, a pain.001 one declaring its own.
+*/
+func (s *BatchHandler) createBatch(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	query := r.URL.Query()
+
+	format := types.BatchFormat(query.Get("format"))
+	if format == "" {
+		contentType := r.Header.Get("Content-Type")
+		if strings.Contains(contentType, "xml") {
+			format = types.BatchPain001
+		} else if strings.Contains(contentType, "csv") {
+			format = types.BatchCSV
+		} else {
+			return NewApiError(http.StatusBadRequest, "missing_format")
+		}
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBatchFileSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return NewApiError(http.StatusRequestEntityTooLarge, "file_too_large")
+		}
+		return NewApiError(http.StatusBadRequest, "invalid_request_body")
+	}
+	defer r.Body.Close()
+
+	var data *dto.CreateBatchDTO
+	switch format {
+	case types.BatchPain001:
+		data, err = importer.Pain001(bytes.NewReader(body))
+	case types.BatchCSV:
+		data, err = importer.CSV(bytes.NewReader(body))
+		if err == nil && query.Has("control_sum") {
+			sum := query.Get("control_sum")
+			data.ControlSum = &sum
+		}
+	default:
+		err = errors.New("invalid_format")
+	}
+	if err != nil {
+		return batchError(err)
+	}
+
+	data.Format = string(format)
+	data.Mode = query.Get("mode")
+
+	batch, err := s.service.Batch.Create(p, id, data)
+	if err != nil {
+		return batchError(err)
+	}
+
+	return WriteJSON(w, http.StatusCreated, NewApiResponse(http.StatusCreated, batch, r))
+}
+
+/*
+getBatch is the controller that handles the GET /account/{id}/batches/{batchId} endpoint.
+It reports the outcome of every line of the batch.
+*/
+func (s *BatchHandler) getBatch(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	batchId, err := GetIntParameter(r, "batchId")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_batch_id")
+	}
+
+	batch, err := s.service.Batch.Get(p, id, batchId)
+	if err != nil {
+		return batchError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, batch, r))
+}
+
+/*
+cancelBatch is the controller that handles the DELETE /account/{id}/batches/{batchId} endpoint.
+*/
+func (s *BatchHandler) cancelBatch(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	batchId, err := GetIntParameter(r, "batchId")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_batch_id")
+	}
+
+	batch, err := s.service.Batch.Cancel(p, id, batchId)
+	if err != nil {
+		return batchError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, batch, r))
+}
+
+/*
+confirmBatch is the controller that handles the POST /account/{id}/batches/{batchId}/confirm endpoint.
+*/
+func (s *BatchHandler) confirmBatch(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	batchId, err := GetIntParameter(r, "batchId")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_batch_id")
+	}
+
+	batch, err := s.service.Batch.Confirm(p, id, batchId)
+	if err != nil {
+		return batchError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, batch, r))
+}
+
+/*
+batchError maps the batch service errors to the appropriate API error.
+*/
+func batchError(err error) error {
+	switch err.Error() {
+	case "invalid_batch_id", "invalid_format", "invalid_mode", "invalid_file", "empty_batch", "too_many_lines",
+		"transaction_count_mismatch", "invalid_control_sum", "control_sum_mismatch", "invalid_message_id", "debtor_account_mismatch":
+		return NewApiError(http.StatusBadRequest, err.Error())
+	case "batch_not_found":
+		return NewApiError(http.StatusNotFound, err.Error())
+	case "batch_already_exist", "batch_not_pending", "batch_invalid":
+		return NewApiError(http.StatusConflict, err.Error())
+	default:
+		return transactionError(err)
+	}
+}
@@ -0,0 +1,248 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/services"
+)
+
+type BeneficiaryHandler struct {
+	service *services.Service
+}
+
+func NewBeneficiaryHandler(service *services.Service) *BeneficiaryHandler {
+	return &BeneficiaryHandler{
+		service: service,
+	}
+}
+
+/*
+HandleBeneficiaries routes the request to the appropriate handler for /user/{id}/beneficiaries endpoint.
+*/
+func (b *BeneficiaryHandler) HandleBeneficiaries(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return b.getBeneficiaries(w, r)
+	case "POST":
+		return b.createBeneficiary(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleUniqueBeneficiary routes the request to the appropriate handler for /user/{id}/beneficiaries/{beneficiaryId} endpoint.
+*/
+func (b *BeneficiaryHandler) HandleUniqueBeneficiary(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return b.getBeneficiary(w, r)
+	case "PATCH":
+		return b.updateBeneficiary(w, r)
+	case "DELETE":
+		return b.deleteBeneficiary(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleBeneficiaryConfirm routes the request to the appropriate handler for /user/{id}/beneficiaries/{beneficiaryId}/confirm endpoint.
+*/
+func (b *BeneficiaryHandler) HandleBeneficiaryConfirm(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "POST":
+		return b.confirmBeneficiary(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/* ------------------------------- Controller ------------------------------- */
+
+/*
+getBeneficiaries is the controller that handles the GET /user/{id}/beneficiaries endpoint.
+*/
+func (b *BeneficiaryHandler) getBeneficiaries(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_user_id")
+	}
+
+	beneficiaries, err := b.service.Beneficiary.GetAll(p, id)
+	if err != nil {
+		return beneficiaryError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, beneficiaries, r))
+}
+
+/*
+createBeneficiary is the controller that handles the POST /user/{id}/beneficiaries endpoint.
+The beneficiary is returned with the masked name of the recipient, to be confirmed before its first use.
+*/
+func (b *BeneficiaryHandler) createBeneficiary(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_user_id")
+	}
+
+	data := new(dto.CreateBeneficiaryDTO)
+
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		return NewApiError(http.StatusBadRequest, "invalid_request_body")
+	}
+	defer r.Body.Close()
+
+	beneficiary, err := b.service.Beneficiary.Create(p, id, data)
+	if err != nil {
+		return beneficiaryError(err)
+	}
+
+	return WriteJSON(w, http.StatusCreated, NewApiResponse(http.StatusCreated, beneficiary, r))
+}
+
+/*
+getBeneficiary is the controller that handles the GET /user/{id}/beneficiaries/{beneficiaryId} endpoint.
+*/
+func (b *BeneficiaryHandler) getBeneficiary(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_user_id")
+	}
+
+	beneficiaryId, err := GetIntParameter(r, "beneficiaryId")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_beneficiary_id")
+	}
+
+	beneficiary, err := b.service.Beneficiary.Get(p, id, beneficiaryId)
+	if err != nil {
+		return beneficiaryError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, beneficiary, r))
+}
+
+/*
+updateBeneficiary is the controller that handles the PATCH /user/{id}/beneficiaries/{beneficiaryId} endpoint.
+*/
+func (b *BeneficiaryHandler) updateBeneficiary(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_user_id")
+	}
+
+	beneficiaryId, err := GetIntParameter(r, "beneficiaryId")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_beneficiary_id")
+	}
+
+	data := new(dto.UpdateBeneficiaryDTO)
+
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		return NewApiError(http.StatusBadRequest, "invalid_request_body")
+	}
+	defer r.Body.Close()
+
+	beneficiary, err := b.service.Beneficiary.Update(p, id, beneficiaryId, data)
+	if err != nil {
+		return beneficiaryError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, beneficiary, r))
+}
+
+/*
+deleteBeneficiary is the controller that handles the DELETE /user/{id}/beneficiaries/{beneficiaryId} endpoint.
+*/
+func (b *BeneficiaryHandler) deleteBeneficiary(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_user_id")
+	}
+
+	beneficiaryId, err := GetIntParameter(r, "beneficiaryId")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_beneficiary_id")
+	}
+
+	err = b.service.Beneficiary.Delete(p, id, beneficiaryId)
+	if err != nil {
+		return beneficiaryError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, nil, r))
+}
+
+/*
+confirmBeneficiary is the controller that handles the POST /user/{id}/beneficiaries/{beneficiaryId}/confirm endpoint.
+*/
+func (b *BeneficiaryHandler) confirmBeneficiary(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_user_id")
+	}
+
+	beneficiaryId, err := GetIntParameter(r, "beneficiaryId")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_beneficiary_id")
+	}
+
+	beneficiary, err := b.service.Beneficiary.Confirm(p, id, beneficiaryId)
+	if err != nil {
+		return beneficiaryError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, beneficiary, r))
+}
+
+/*
+beneficiaryError maps the beneficiary service errors to the appropriate API error.
+*/
+func beneficiaryError(err error) error {
+	switch err.Error() {
+	case "invalid_user_id", "invalid_beneficiary_id", "empty_nickname", "invalid_iban":
+		return NewApiError(http.StatusBadRequest, err.Error())
+	case "user_not_found", "account_not_found", "beneficiary_not_found":
+		return NewApiError(http.StatusNotFound, err.Error())
+	case "beneficiary_already_exist":
+		return NewApiError(http.StatusConflict, err.Error())
+	case "forbidden":
+		return NewApiError(http.StatusForbidden, err.Error())
+	default:
+		return err
+	}
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/farischt/gobank/pkg/types"
+)
+
+type contextKey string
+
+const principalContextKey contextKey = "principal"
+
+/*
+withPrincipal returns a shallow copy of the request carrying the given principal in its context.
+*/
+func withPrincipal(r *http.Request, p *types.Principal) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), principalContextKey, p))
+}
+
+/*
+GetPrincipal is a helper function to get the authenticated principal from the request context.
+It returns an error if the request went through no authentication middleware.
+*/
+func GetPrincipal(r *http.Request) (*types.Principal, error) {
+	p, ok := r.Context().Value(principalContextKey).(*types.Principal)
+	if !ok || p == nil {
+		return nil, NewApiError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	return p, nil
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/services"
+)
+
+type HoldHandler struct {
+	service *services.Service
+}
+
+func NewHoldHandler(service *services.Service) *HoldHandler {
+	return &HoldHandler{
+		service: service,
+	}
+}
+
+/*
+HandleHolds routes the request to the appropriate handler for /account/{id}/holds endpoint.
+*/
+func (s *HoldHandler) HandleHolds(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return s.getHolds(w, r)
+	case "POST":
+		return s.createHold(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleUniqueHold routes the request to the appropriate handler for /account/{id}/holds/{holdId} endpoint.
+*/
+func (s *HoldHandler) HandleUniqueHold(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return s.getHold(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleHoldCapture routes the request to the appropriate handler for /account/{id}/holds/{holdId}/capture endpoint.
+*/
+func (s *HoldHandler) HandleHoldCapture(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "POST":
+		return s.captureHold(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleHoldRelease routes the request to the appropriate handler for /account/{id}/holds/{holdId}/release endpoint.
+*/
+func (s *HoldHandler) HandleHoldRelease(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "POST":
+		return s.releaseHold(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/* ------------------------------- Controller ------------------------------- */
+
+/*
+getHolds is the controller that handles the GET /account/{id}/holds endpoint.
+*/
+func (s *HoldHandler) getHolds(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	holds, err := s.service.Hold.GetAll(p, id)
+	if err != nil {
+		return holdError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, holds, r))
+}
+
+/*
+createHold is the controller that handles the POST /account/{id}/holds endpoint.
+*/
+func (s *HoldHandler) createHold(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	data := new(dto.CreateHoldDTO)
+
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		return NewApiError(http.StatusBadRequest, "invalid_request_body")
+	}
+	defer r.Body.Close()
+
+	hold, err := s.service.Hold.Create(p, id, data)
+	if err != nil {
+		return holdError(err)
+	}
+
+	return WriteJSON(w, http.StatusCreated, NewApiResponse(http.StatusCreated, hold, r))
+}
+
+/*
+getHold is the controller that handles the GET /account/{id}/holds/{holdId} endpoint.
+*/
+func (s *HoldHandler) getHold(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	holdId, err := GetIntParameter(r, "holdId")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_hold_id")
+	}
+
+	hold, err := s.service.Hold.Get(p, id, holdId)
+	if err != nil {
+		return holdError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, hold, r))
+}
+
+/*
+captureHold is the controller that handles the POST /account/{id}/holds/{holdId}/capture endpoint.
+*/
+func (s *HoldHandler) captureHold(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	holdId, err := GetIntParameter(r, "holdId")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_hold_id")
+	}
+
+	data := new(dto.CaptureHoldDTO)
+
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		return NewApiError(http.StatusBadRequest, "invalid_request_body")
+	}
+	defer r.Body.Close()
+
+	hold, err := s.service.Hold.Capture(p, id, holdId, data)
+	if err != nil {
+		return holdError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, hold, r))
+}
+
+/*
+releaseHold is the controller that handles the POST /account/{id}/holds/{holdId}/release endpoint.
+*/
+func (s *HoldHandler) releaseHold(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	holdId, err := GetIntParameter(r, "holdId")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_hold_id")
+	}
+
+	hold, err := s.service.Hold.Release(p, id, holdId)
+	if err != nil {
+		return holdError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, hold, r))
+}
+
+/*
+holdError maps the hold service errors to the appropriate API error.
+A capture failing the transfer checks is reported as a transfer error.
+*/
+func holdError(err error) error {
+	switch err.Error() {
+	case "invalid_account_id", "invalid_hold_id", "capture_exceeds_hold":
+		return NewApiError(http.StatusBadRequest, err.Error())
+	case "hold_not_found":
+		return NewApiError(http.StatusNotFound, err.Error())
+	case "hold_not_active", "hold_expired":
+		return NewApiError(http.StatusConflict, err.Error())
+	default:
+		return transactionError(err)
+	}
+}
@@ -6,23 +6,41 @@ import (
 
 	"github.com/farischt/gobank/config"
 	"github.com/farischt/gobank/pkg/services"
-	"github.com/farischt/gobank/pkg/store"
+	"github.com/farischt/gobank/pkg/types"
 	"github.com/gorilla/mux"
 )
 
 type Handlers struct {
 	User           *UserHandler
 	Account        *AccountHandler
+	AccountHolder  *AccountHolderHandler
 	Transaction    *TransactionHandler
 	Authentication *AuthenticationHandler
+	Admin          *AdminHandler
+	ApiKey         *ApiKeyHandler
+	StandingOrder  *StandingOrderHandler
+	Hold           *HoldHandler
+	Batch          *BatchHandler
+	PaymentRequest *PaymentRequestHandler
+	Beneficiary    *BeneficiaryHandler
+	Statement      *StatementHandler
 }
 
 func NewHandlers(service *services.Service) *Handlers {
 	return &Handlers{
 		User:           NewUserHandler(service),
 		Account:        NewAccountHandler(service),
+		AccountHolder:  NewAccountHolderHandler(service),
 		Transaction:    NewTransactionHandler(service),
 		Authentication: NewAuthenticationHandler(service),
+		Admin:          NewAdminHandler(service),
+		ApiKey:         NewApiKeyHandler(service),
+		StandingOrder:  NewStandingOrderHandler(service),
+		Hold:           NewHoldHandler(service),
+		Batch:          NewBatchHandler(service),
+		PaymentRequest: NewPaymentRequestHandler(service),
+		Beneficiary:    NewBeneficiaryHandler(service),
+		Statement:      NewStatementHandler(service),
 	}
 }
 
@@ -30,21 +48,21 @@ func NewHandlers(service *services.Service) *Handlers {
 ApiServer is the API server.
 */
 type ApiServer struct {
-	listenAddr string
-	service    *services.Service
-	handlers   *Handlers
+	listenAddr  string
+	service     *services.Service
+	handlers    *Handlers
+	rateLimiter RateLimitStore
 }
 
 /*
 NewApiServer creates a new instance of API server.
 */
-func New(l string, s store.Store) *ApiServer {
-	services := services.New(s)
-
+func New(l string, service *services.Service) *ApiServer {
 	return &ApiServer{
-		listenAddr: l,
-		service:    services,
-		handlers:   NewHandlers(services),
+		listenAddr:  l,
+		service:     service,
+		handlers:    NewHandlers(service),
+		rateLimiter: NewMemoryRateLimitStore(),
 	}
 }
 
@@ -54,13 +72,65 @@ Start starts the API server.
 func (s *ApiServer) Start() {
 	router := mux.NewRouter()
 
-	router.HandleFunc("/user", makeHTTPFunc(s.handlers.User.HandleUser))
-	router.HandleFunc("/user/{id}", makeHTTPFunc(s.handlers.User.HandleUniqueUser))
-	router.HandleFunc("/auth/login", s.WithoutAuth(makeHTTPFunc(s.handlers.Authentication.HandleLogin)))
+	router.HandleFunc("/user", s.WithRateLimit("user", makeHTTPFunc(s.handlers.User.HandleUser)))
+	router.HandleFunc("/user/verify", s.WithRateLimit("user_verify", makeHTTPFunc(s.handlers.User.HandleVerifyEmail)))
+	router.HandleFunc("/user/verify/resend", s.WithRateLimit("user_verify", makeHTTPFunc(s.handlers.User.HandleResendVerification)))
+	router.HandleFunc("/user/{id}", s.WithAuth(s.WithRateLimit("user", s.RequireScope(types.ScopeUserRead, makeHTTPFunc(s.handlers.User.HandleUniqueUser)))))
+	router.HandleFunc("/user/{id}/export", s.WithAuth(s.WithRateLimit("user", s.RequireScope(types.ScopeUserRead, makeHTTPFunc(s.handlers.User.HandleUserExport)))))
+	router.HandleFunc("/user/{id}/password", s.WithAuth(s.WithRateLimit("login", makeHTTPFunc(s.handlers.User.HandleUserPassword))))
+	router.HandleFunc("/user/{id}/beneficiaries", s.WithAuth(s.WithRateLimit("user", makeHTTPFunc(s.handlers.Beneficiary.HandleBeneficiaries))))
+	router.HandleFunc("/user/{id}/beneficiaries/{beneficiaryId}", s.WithAuth(s.WithRateLimit("user", makeHTTPFunc(s.handlers.Beneficiary.HandleUniqueBeneficiary))))
+	router.HandleFunc("/user/{id}/beneficiaries/{beneficiaryId}/confirm", s.WithAuth(s.WithRateLimit("user", makeHTTPFunc(s.handlers.Beneficiary.HandleBeneficiaryConfirm))))
+	router.HandleFunc("/auth/login", s.WithoutAuth(s.WithRateLimit("login", makeHTTPFunc(s.handlers.Authentication.HandleLogin))))
 	router.HandleFunc("/auth/logout", s.WithAuth(makeHTTPFunc(s.handlers.Authentication.HandleLogout)))
-	router.HandleFunc("/account", makeHTTPFunc(s.handlers.Account.HandleAccount))
-	router.HandleFunc("/account/{id}", makeHTTPFunc(s.handlers.Account.HandleUniqueAccount))
-	router.HandleFunc("/transfer", s.WithAuth(makeHTTPFunc(s.handlers.Transaction.HandleTransfer)))
+	router.HandleFunc("/api-keys", s.WithAuth(s.WithRateLimit("api_keys", makeHTTPFunc(s.handlers.ApiKey.HandleApiKey))))
+	router.HandleFunc("/api-keys/{id}", s.WithAuth(s.WithRateLimit("api_keys", makeHTTPFunc(s.handlers.ApiKey.HandleUniqueApiKey))))
+	router.HandleFunc("/account", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeAccountRead, makeHTTPFunc(s.handlers.Account.HandleAccount))))).Methods("GET")
+	router.HandleFunc("/account", s.WithAuth(s.WithRateLimit("account", makeHTTPFunc(s.handlers.Account.HandleAccount))))
+	router.HandleFunc("/account/{id}", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeAccountRead, makeHTTPFunc(s.handlers.Account.HandleUniqueAccount)))))
+	router.HandleFunc("/account/{id}/status", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeAdmin, s.RequirePermission(types.PermAccountFreeze, makeHTTPFunc(s.handlers.Account.HandleAccountStatus)))))).Methods("POST")
+	router.HandleFunc("/account/{id}/status", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeAccountRead, makeHTTPFunc(s.handlers.Account.HandleAccountStatus)))))
+	router.HandleFunc("/account/{id}/overdraft", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeAdmin, s.RequirePermission(types.PermAccountOverdraft, makeHTTPFunc(s.handlers.Account.HandleAccountOverdraft))))))
+	router.HandleFunc("/account/{id}/limits", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeAdmin, s.RequirePermission(types.PermAccountLimits, makeHTTPFunc(s.handlers.Account.HandleAccountLimits)))))).Methods("PUT")
+	router.HandleFunc("/account/{id}/limits", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeAccountRead, makeHTTPFunc(s.handlers.Account.HandleAccountLimits)))))
+	router.HandleFunc("/account/{id}/holders", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeTransferWrite, makeHTTPFunc(s.handlers.AccountHolder.HandleAccountHolders))))).Methods("POST")
+	router.HandleFunc("/account/{id}/holders", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeAccountRead, makeHTTPFunc(s.handlers.AccountHolder.HandleAccountHolders)))))
+	router.HandleFunc("/account/{id}/holders/{userId}", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeTransferWrite, makeHTTPFunc(s.handlers.AccountHolder.HandleUniqueAccountHolder)))))
+	router.HandleFunc("/account/{id}/holder-changes", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeAccountRead, makeHTTPFunc(s.handlers.AccountHolder.HandleAccountHolderChanges)))))
+	router.HandleFunc("/account/{id}/holder-changes/{changeId}/approve", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeTransferWrite, makeHTTPFunc(s.handlers.AccountHolder.HandleAccountHolderChangeApprove)))))
+	router.HandleFunc("/account/{id}/holder-changes/{changeId}/reject", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeTransferWrite, makeHTTPFunc(s.handlers.AccountHolder.HandleAccountHolderChangeReject)))))
+	router.HandleFunc("/account/{id}/transactions", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeAccountRead, makeHTTPFunc(s.handlers.Transaction.HandleAccountTransactions)))))
+	router.HandleFunc("/account/{id}/statement", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeAccountRead, makeHTTPFunc(s.handlers.Statement.HandleStatementExport)))))
+	router.HandleFunc("/account/{id}/statements", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeAccountRead, makeHTTPFunc(s.handlers.Statement.HandleStatements)))))
+	router.HandleFunc("/account/{id}/statements/{statementId}", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeAccountRead, makeHTTPFunc(s.handlers.Statement.HandleUniqueStatement)))))
+	router.HandleFunc("/account/{id}/standing-orders", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeTransferWrite, makeHTTPFunc(s.handlers.StandingOrder.HandleStandingOrders))))).Methods("POST")
+	router.HandleFunc("/account/{id}/standing-orders", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeAccountRead, makeHTTPFunc(s.handlers.StandingOrder.HandleStandingOrders)))))
+	router.HandleFunc("/account/{id}/standing-orders/{orderId}", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeTransferWrite, makeHTTPFunc(s.handlers.StandingOrder.HandleUniqueStandingOrder))))).Methods("PATCH", "DELETE")
+	router.HandleFunc("/account/{id}/standing-orders/{orderId}", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeAccountRead, makeHTTPFunc(s.handlers.StandingOrder.HandleUniqueStandingOrder)))))
+	router.HandleFunc("/account/{id}/holds", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeTransferWrite, makeHTTPFunc(s.handlers.Hold.HandleHolds))))).Methods("POST")
+	router.HandleFunc("/account/{id}/holds", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeAccountRead, makeHTTPFunc(s.handlers.Hold.HandleHolds)))))
+	router.HandleFunc("/account/{id}/holds/{holdId}", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeAccountRead, makeHTTPFunc(s.handlers.Hold.HandleUniqueHold)))))
+	router.HandleFunc("/account/{id}/holds/{holdId}/capture", s.WithAuth(s.WithRateLimit("transfer", s.RequireScope(types.ScopeTransferWrite, makeHTTPFunc(s.handlers.Hold.HandleHoldCapture)))))
+	router.HandleFunc("/account/{id}/holds/{holdId}/release", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeTransferWrite, makeHTTPFunc(s.handlers.Hold.HandleHoldRelease)))))
+	router.HandleFunc("/account/{id}/batches", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeTransferWrite, makeHTTPFunc(s.handlers.Batch.HandleBatches))))).Methods("POST")
+	router.HandleFunc("/account/{id}/batches", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeAccountRead, makeHTTPFunc(s.handlers.Batch.HandleBatches)))))
+	router.HandleFunc("/account/{id}/batches/{batchId}", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeTransferWrite, makeHTTPFunc(s.handlers.Batch.HandleUniqueBatch))))).Methods("DELETE")
+	router.HandleFunc("/account/{id}/batches/{batchId}", s.WithAuth(s.WithRateLimit("account", s.RequireScope(types.ScopeAccountRead, makeHTTPFunc(s.handlers.Batch.HandleUniqueBatch)))))
+	router.HandleFunc("/account/{id}/batches/{batchId}/confirm", s.WithAuth(s.WithRateLimit("transfer", s.RequireScope(types.ScopeTransferWrite, makeHTTPFunc(s.handlers.Batch.HandleBatchConfirm)))))
+	router.HandleFunc("/transfer", s.WithAuth(s.WithRateLimit("transfer", s.RequireScope(types.ScopeTransferWrite, makeHTTPFunc(s.handlers.Transaction.HandleTransfer)))))
+	router.HandleFunc("/transfer/quote", s.WithAuth(s.WithRateLimit("transfer", s.RequireScope(types.ScopeTransferWrite, makeHTTPFunc(s.handlers.Transaction.HandleTransferQuote)))))
+	router.HandleFunc("/payment-requests", s.WithAuth(s.WithRateLimit("payment_requests", s.RequireScope(types.ScopeTransferWrite, makeHTTPFunc(s.handlers.PaymentRequest.HandlePaymentRequests))))).Methods("POST")
+	router.HandleFunc("/payment-requests", s.WithAuth(s.WithRateLimit("payment_requests", s.RequireScope(types.ScopeAccountRead, makeHTTPFunc(s.handlers.PaymentRequest.HandlePaymentRequests)))))
+	router.HandleFunc("/payment-requests/inbox", s.WithAuth(s.WithRateLimit("payment_requests", s.RequireScope(types.ScopeAccountRead, makeHTTPFunc(s.handlers.PaymentRequest.HandlePaymentRequestInbox)))))
+	router.HandleFunc("/payment-requests/{id}", s.WithAuth(s.WithRateLimit("payment_requests", s.RequireScope(types.ScopeAccountRead, makeHTTPFunc(s.handlers.PaymentRequest.HandleUniquePaymentRequest)))))
+	router.HandleFunc("/payment-requests/{id}/accept", s.WithAuth(s.WithRateLimit("transfer", s.RequireScope(types.ScopeTransferWrite, makeHTTPFunc(s.handlers.PaymentRequest.HandlePaymentRequestAccept)))))
+	router.HandleFunc("/payment-requests/{id}/decline", s.WithAuth(s.WithRateLimit("payment_requests", s.RequireScope(types.ScopeTransferWrite, makeHTTPFunc(s.handlers.PaymentRequest.HandlePaymentRequestDecline)))))
+	router.HandleFunc("/payment-requests/{id}/cancel", s.WithAuth(s.WithRateLimit("payment_requests", s.RequireScope(types.ScopeTransferWrite, makeHTTPFunc(s.handlers.PaymentRequest.HandlePaymentRequestCancel)))))
+	router.HandleFunc("/transaction/{id}/reverse", s.WithAuth(s.WithRateLimit("admin", s.RequireScope(types.ScopeAdmin, s.RequirePermission(types.PermTransactionReverse, makeHTTPFunc(s.handlers.Transaction.HandleTransactionReverse))))))
+	router.HandleFunc("/admin/user/{id}/roles", s.WithAuth(s.WithRateLimit("admin", s.RequireScope(types.ScopeAdmin, s.RequirePermission(types.PermRoleManage, makeHTTPFunc(s.handlers.Admin.HandleUserRoles))))))
+	router.HandleFunc("/admin/user/{id}/roles/{role}", s.WithAuth(s.WithRateLimit("admin", s.RequireScope(types.ScopeAdmin, s.RequirePermission(types.PermRoleManage, makeHTTPFunc(s.handlers.Admin.HandleUniqueUserRole))))))
+	router.HandleFunc("/admin/fx-rates", s.WithAuth(s.WithRateLimit("admin", s.RequireScope(types.ScopeAdmin, s.RequirePermission(types.PermFxManage, makeHTTPFunc(s.handlers.Admin.HandleFxRates))))))
+	router.HandleFunc("/admin/audit", s.WithAuth(s.WithRateLimit("admin", s.RequireScope(types.ScopeAdmin, s.RequirePermission(types.PermAuditRead, makeHTTPFunc(s.handlers.Admin.HandleAudit))))))
 
 	log.Println("Server up and running on port", s.listenAddr[1:])
 	err := http.ListenAndServe(s.listenAddr, router)
@@ -72,29 +142,97 @@ func (s *ApiServer) Start() {
 
 /*
 withAuth is a middleware to protect routes that require authentication.
+It accepts either a session token or an API key.
 */
 func (s *ApiServer) WithAuth(handlerFunc http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Println("authentication protected route")
 
+		var principal *types.Principal
+		var err error
+
 		token := r.Header.Get(config.GetConfig().GetString(config.TOKEN_NAME))
-		if len(token) == 0 {
+		apiKey := r.Header.Get(config.GetConfig().GetString(config.API_KEY_NAME))
+
+		if len(token) > 0 {
+			principal, err = s.service.Session.GetPrincipal(token)
+			if err != nil {
+				_ = WriteJSON(w, http.StatusUnauthorized, NewApiError(http.StatusUnauthorized, "invalid_token"))
+				return
+			}
+		} else if len(apiKey) > 0 {
+			principal, err = s.service.ApiKey.Authenticate(apiKey, GetClientIP(r))
+			if err != nil {
+				_ = WriteJSON(w, http.StatusUnauthorized, NewApiError(http.StatusUnauthorized, err.Error()))
+				return
+			}
+		} else {
 			_ = WriteJSON(w, http.StatusUnauthorized, NewApiError(http.StatusUnauthorized, "missing_token"))
 			return
 		}
 
-		_, validToken := s.service.Session.IsValidSessionToken(token)
+		// Equivalent to next() in express
+		handlerFunc(w, withPrincipal(r, principal))
+	}
+}
 
-		if !validToken {
-			_ = WriteJSON(w, http.StatusUnauthorized, NewApiError(http.StatusUnauthorized, "invalid_token"))
+/*
+RequireScope is a middleware to restrict the routes an API key may call.
+It must be chained after WithAuth. Session principals are not restricted.
+*/
+func (s *ApiServer) RequireScope(scope string, handlerFunc http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := GetPrincipal(r)
+		if err != nil {
+			_ = WriteJSON(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		if !p.HasScope(scope) {
+			_ = WriteJSON(w, http.StatusForbidden, NewApiError(http.StatusForbidden, "insufficient_scope"))
 			return
 		}
 
-		// Equivalent to next() in express
 		handlerFunc(w, r)
 	}
 }
 
+/*
+RequirePermission is a middleware to protect routes that require a privileged permission.
+It must be chained after WithAuth. Every request going through it, allowed or not,
+is recorded in the audit log.
+*/
+func (s *ApiServer) RequirePermission(perm types.Permission, handlerFunc http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := GetPrincipal(r)
+		if err != nil {
+			_ = WriteJSON(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		if !p.Can(perm) {
+			s.audit(p, perm, r, http.StatusForbidden)
+			_ = WriteJSON(w, http.StatusForbidden, NewApiError(http.StatusForbidden, "forbidden"))
+			return
+		}
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		handlerFunc(sw, r)
+		s.audit(p, perm, r, sw.status)
+	}
+}
+
+/*
+audit records a privileged request in the audit log.
+A failure to write the entry is logged but does not fail the request.
+*/
+func (s *ApiServer) audit(p *types.Principal, perm types.Permission, r *http.Request, status int) {
+	err := s.service.Audit.Record(p, perm, r.Method, r.URL.Path, status)
+	if err != nil {
+		log.Println("unable to write audit entry:", err)
+	}
+}
+
 /*
 withoutAuth is a middleware to protect routes that must not be authenticated.
 */
@@ -102,9 +240,10 @@ func (s *ApiServer) WithoutAuth(handlerFunc http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Println("without authentication protected route")
 
-		// Check if the token is already set
+		// Check if the token or the API key is already set
 		token := r.Header.Get(config.GetConfig().GetString(config.TOKEN_NAME))
-		if len(token) > 0 {
+		apiKey := r.Header.Get(config.GetConfig().GetString(config.API_KEY_NAME))
+		if len(token) > 0 || len(apiKey) > 0 {
 			_ = WriteJSON(w, http.StatusForbidden, NewApiError(http.StatusForbidden, "already_authenticated"))
 			return
 		}
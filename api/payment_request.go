@@ -0,0 +1,263 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/services"
+)
+
+type PaymentRequestHandler struct {
+	service *services.Service
+}
+
+func NewPaymentRequestHandler(service *services.Service) *PaymentRequestHandler {
+	return &PaymentRequestHandler{
+		service: service,
+	}
+}
+
+/*
+HandlePaymentRequests routes the request to the appropriate handler for /payment-requests endpoint.
+*/
+func (s *PaymentRequestHandler) HandlePaymentRequests(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return s.getSentPaymentRequests(w, r)
+	case "POST":
+		return s.createPaymentRequest(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandlePaymentRequestInbox routes the request to the appropriate handler for /payment-requests/inbox endpoint.
+*/
+func (s *PaymentRequestHandler) HandlePaymentRequestInbox(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return s.getPaymentRequestInbox(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleUniquePaymentRequest routes the request to the appropriate handler for /payment-requests/{id} endpoint.
+*/
+func (s *PaymentRequestHandler) HandleUniquePaymentRequest(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return s.getPaymentRequest(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandlePaymentRequestAccept routes the request to the appropriate handler for /payment-requests/{id}/accept endpoint.
+*/
+func (s *PaymentRequestHandler) HandlePaymentRequestAccept(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "POST":
+		return s.acceptPaymentRequest(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandlePaymentRequestDecline routes the request to the appropriate handler for /payment-requests/{id}/decline endpoint.
+*/
+func (s *PaymentRequestHandler) HandlePaymentRequestDecline(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "POST":
+		return s.declinePaymentRequest(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandlePaymentRequestCancel routes the request to the appropriate handler for /payment-requests/{id}/cancel endpoint.
+*/
+func (s *PaymentRequestHandler) HandlePaymentRequestCancel(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "POST":
+		return s.cancelPaymentRequest(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/* ------------------------------- Controller ------------------------------- */
+
+/*
+createPaymentRequest is the controller that handles the POST /payment-requests endpoint.
+A client retrying a request can send the same Idempotency-Key header so that it's only made once.
+*/
+func (s *PaymentRequestHandler) createPaymentRequest(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	data := new(dto.CreatePaymentRequestDTO)
+
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		return NewApiError(http.StatusBadRequest, "invalid_request_body")
+	}
+	defer r.Body.Close()
+
+	data.IdempotencyKey = r.Header.Get("Idempotency-Key")
+
+	request, err := s.service.PaymentRequest.Create(p, data)
+	if err != nil {
+		return paymentRequestError(err)
+	}
+
+	return WriteJSON(w, http.StatusCreated, NewApiResponse(http.StatusCreated, request, r))
+}
+
+/*
+getSentPaymentRequests is the controller that handles the GET /payment-requests endpoint.
+*/
+func (s *PaymentRequestHandler) getSentPaymentRequests(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	requests, err := s.service.PaymentRequest.GetSent(p)
+	if err != nil {
+		return paymentRequestError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, requests, r))
+}
+
+/*
+getPaymentRequestInbox is the controller that handles the GET /payment-requests/inbox endpoint.
+*/
+func (s *PaymentRequestHandler) getPaymentRequestInbox(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	requests, err := s.service.PaymentRequest.GetInbox(p)
+	if err != nil {
+		return paymentRequestError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, requests, r))
+}
+
+/*
+getPaymentRequest is the controller that handles the GET /payment-requests/{id} endpoint.
+*/
+func (s *PaymentRequestHandler) getPaymentRequest(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_payment_request_id")
+	}
+
+	request, err := s.service.PaymentRequest.Get(p, id)
+	if err != nil {
+		return paymentRequestError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, request, r))
+}
+
+/*
+acceptPaymentRequest is the controller that handles the POST /payment-requests/{id}/accept endpoint.
+*/
+func (s *PaymentRequestHandler) acceptPaymentRequest(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_payment_request_id")
+	}
+
+	request, err := s.service.PaymentRequest.Accept(p, id)
+	if err != nil {
+		return paymentRequestError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, request, r))
+}
+
+/*
+declinePaymentRequest is the controller that handles the POST /payment-requests/{id}/decline endpoint.
+*/
+func (s *PaymentRequestHandler) declinePaymentRequest(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_payment_request_id")
+	}
+
+	request, err := s.service.PaymentRequest.Decline(p, id)
+	if err != nil {
+		return paymentRequestError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, request, r))
+}
+
+/*
+cancelPaymentRequest is the controller that handles the POST /payment-requests/{id}/cancel endpoint.
+*/
+func (s *PaymentRequestHandler) cancelPaymentRequest(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_payment_request_id")
+	}
+
+	request, err := s.service.PaymentRequest.Cancel(p, id)
+	if err != nil {
+		return paymentRequestError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, request, r))
+}
+
+/*
+paymentRequestError maps the payment request service errors to the appropriate API error.
+Accepting a request failing the transfer checks is reported as a transfer error.
+*/
+func paymentRequestError(err error) error {
+	switch err.Error() {
+	case "invalid_payment_request_id", "invalid_reason", "invalid_expires_at", "cannot_request_from_yourself", "invalid_payer":
+		return NewApiError(http.StatusBadRequest, err.Error())
+	case "payment_request_not_found":
+		return NewApiError(http.StatusNotFound, err.Error())
+	case "payment_request_not_pending", "payment_request_expired":
+		return NewApiError(http.StatusConflict, err.Error())
+	case "payer_account_frozen", "payer_account_dormant", "payer_account_closed":
+		return NewApiError(http.StatusForbidden, err.Error())
+	default:
+		return transactionError(err)
+	}
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/farischt/gobank/config"
+)
+
+const defaultRateLimit = "60/1m"
+
+/*
+RateLimit is a token bucket configuration: the bucket holds at most Limit
+tokens and is refilled with Limit tokens every Period.
+*/
+type RateLimit struct {
+	Limit  int
+	Period time.Duration
+}
+
+/*
+ParseRateLimit parses a rate limit written as "<limit>/<period>", e.g. "5/1m".
+*/
+func ParseRateLimit(s string) (RateLimit, error) {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	if len(parts) != 2 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q", s)
+	}
+
+	limit, err := strconv.Atoi(parts[0])
+	if err != nil || limit <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q", s)
+	}
+
+	period, err := time.ParseDuration(parts[1])
+	if err != nil || period <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q", s)
+	}
+
+	return RateLimit{Limit: limit, Period: period}, nil
+}
+
+/*
+RateLimitResult is the outcome of taking a token from a bucket.
+*/
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until a token is available, only set when not allowed
+	RetryAfter time.Duration
+}
+
+/*
+RateLimitStore holds the token buckets.
+The in-process store is used by default, a shared backend can implement this
+interface so that several instances of the server share their limits.
+*/
+type RateLimitStore interface {
+	Take(key string, limit RateLimit, now time.Time) RateLimitResult
+}
+
+type bucket struct {
+	tokens   float64
+	updateAt time.Time
+	period   time.Duration
+}
+
+type memoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	sweepAt time.Time
+}
+
+/*
+NewMemoryRateLimitStore creates an in-process rate limit store.
+*/
+func NewMemoryRateLimitStore() RateLimitStore {
+	return &memoryRateLimitStore{
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (m *memoryRateLimitStore) Take(key string, limit RateLimit, now time.Time) RateLimitResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+
+	capacity := float64(limit.Limit)
+	rate := capacity / limit.Period.Seconds()
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updateAt: now}
+		m.buckets[key] = b
+	}
+	b.period = limit.Period
+
+	// Refill the bucket with the tokens earned since the last update
+	elapsed := now.Sub(b.updateAt).Seconds()
+	b.tokens = math.Min(capacity, b.tokens+elapsed*rate)
+	b.updateAt = now
+
+	result := RateLimitResult{Limit: limit.Limit}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+
+	result.Remaining = int(b.tokens)
+	result.Reset = time.Duration((capacity - b.tokens) / rate * float64(time.Second))
+
+	return result
+}
+
+/*
+sweep drops the buckets that have been refilled since their last use,
+since they are equivalent to a new bucket.
+*/
+func (m *memoryRateLimitStore) sweep(now time.Time) {
+	if now.Before(m.sweepAt) {
+		return
+	}
+
+	for key, b := range m.buckets {
+		if now.Sub(b.updateAt) > b.period {
+			delete(m.buckets, key)
+		}
+	}
+
+	m.sweepAt = now.Add(time.Minute)
+}
+
+/*
+rateLimitFor reads the limit of a route from the configuration.
+The limit of a route is set by RATE_LIMIT_<ROUTE>, falling back to RATE_LIMIT_DEFAULT.
+*/
+func rateLimitFor(route string) RateLimit {
+	c := config.GetConfig()
+
+	value := c.GetString(fmt.Sprintf("%s_%s", config.RATE_LIMIT, strings.ToUpper(route)))
+	if value == "" {
+		value = c.GetString(config.RATE_LIMIT_DEFAULT)
+	}
+	if value == "" {
+		value = defaultRateLimit
+	}
+
+	limit, err := ParseRateLimit(value)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return limit
+}
+
+/*
+rateLimitKey identifies the client of a request: the API key or the user of the
+session when authenticated, the IP address otherwise.
+*/
+func rateLimitKey(route string, r *http.Request) string {
+	p, err := GetPrincipal(r)
+	switch {
+	case err != nil:
+		return fmt.Sprintf("%s:ip:%s", route, GetClientIP(r))
+	case p.IsApiKey():
+		return fmt.Sprintf("%s:key:%s", route, p.ApiKeyPrefix)
+	default:
+		return fmt.Sprintf("%s:user:%d", route, p.UserID)
+	}
+}
+
+/*
+WithRateLimit is a middleware to limit the rate at which a client can call a route.
+When chained after WithAuth, clients are identified by their API key or user,
+and by their IP address otherwise.
+*/
+func (s *ApiServer) WithRateLimit(route string, handlerFunc http.HandlerFunc) http.HandlerFunc {
+	limit := rateLimitFor(route)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		result := s.rateLimiter.Take(rateLimitKey(route, r), limit, time.Now())
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.Reset.Seconds()))))
+
+		if !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+			_ = WriteJSON(w, http.StatusTooManyRequests, NewApiError(http.StatusTooManyRequests, "rate_limit_exceeded"))
+			return
+		}
+
+		handlerFunc(w, r)
+	}
+}
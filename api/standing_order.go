@@ -0,0 +1,210 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/services"
+)
+
+type StandingOrderHandler struct {
+	service *services.Service
+}
+
+func NewStandingOrderHandler(service *services.Service) *StandingOrderHandler {
+	return &StandingOrderHandler{
+		service: service,
+	}
+}
+
+/*
+HandleStandingOrders routes the request to the appropriate handler for /account/{id}/standing-orders endpoint.
+*/
+func (s *StandingOrderHandler) HandleStandingOrders(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return s.getStandingOrders(w, r)
+	case "POST":
+		return s.createStandingOrder(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleUniqueStandingOrder routes the request to the appropriate handler for /account/{id}/standing-orders/{orderId} endpoint.
+*/
+func (s *StandingOrderHandler) HandleUniqueStandingOrder(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return s.getStandingOrder(w, r)
+	case "PATCH":
+		return s.updateStandingOrder(w, r)
+	case "DELETE":
+		return s.cancelStandingOrder(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/* ------------------------------- Controller ------------------------------- */
+
+/*
+getStandingOrders is the controller that handles the GET /account/{id}/standing-orders endpoint.
+*/
+func (s *StandingOrderHandler) getStandingOrders(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	orders, err := s.service.StandingOrder.GetAll(p, id)
+	if err != nil {
+		return standingOrderError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, orders, r))
+}
+
+/*
+createStandingOrder is the controller that handles the POST /account/{id}/standing-orders endpoint.
+*/
+func (s *StandingOrderHandler) createStandingOrder(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	data := new(dto.CreateStandingOrderDTO)
+
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		return NewApiError(http.StatusBadRequest, "invalid_request_body")
+	}
+	defer r.Body.Close()
+
+	order, err := s.service.StandingOrder.Create(p, id, data)
+	if err != nil {
+		return standingOrderError(err)
+	}
+
+	return WriteJSON(w, http.StatusCreated, NewApiResponse(http.StatusCreated, order, r))
+}
+
+/*
+getStandingOrder is the controller that handles the GET /account/{id}/standing-orders/{orderId} endpoint.
+*/
+func (s *StandingOrderHandler) getStandingOrder(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	orderId, err := GetIntParameter(r, "orderId")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_standing_order_id")
+	}
+
+	order, err := s.service.StandingOrder.Get(p, id, orderId)
+	if err != nil {
+		return standingOrderError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, order, r))
+}
+
+/*
+updateStandingOrder is the controller that handles the PATCH /account/{id}/standing-orders/{orderId} endpoint.
+*/
+func (s *StandingOrderHandler) updateStandingOrder(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	orderId, err := GetIntParameter(r, "orderId")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_standing_order_id")
+	}
+
+	data := new(dto.UpdateStandingOrderDTO)
+
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		return NewApiError(http.StatusBadRequest, "invalid_request_body")
+	}
+	defer r.Body.Close()
+
+	order, err := s.service.StandingOrder.Update(p, id, orderId, data)
+	if err != nil {
+		return standingOrderError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, order, r))
+}
+
+/*
+cancelStandingOrder is the controller that handles the DELETE /account/{id}/standing-orders/{orderId} endpoint.
+The order is cancelled and kept with its runs.
+*/
+func (s *StandingOrderHandler) cancelStandingOrder(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	orderId, err := GetIntParameter(r, "orderId")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_standing_order_id")
+	}
+
+	order, err := s.service.StandingOrder.Cancel(p, id, orderId)
+	if err != nil {
+		return standingOrderError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, order, r))
+}
+
+/*
+standingOrderError maps the standing order service errors to the appropriate API error.
+*/
+func standingOrderError(err error) error {
+	switch err.Error() {
+	case "invalid_account_id", "invalid_amount", "invalid_iban", "cannot_transfer_to_yourself",
+		"invalid_frequency", "invalid_day_of_month", "invalid_start_date", "invalid_end_date":
+		return NewApiError(http.StatusBadRequest, err.Error())
+	case "account_not_found", "standing_order_not_found":
+		return NewApiError(http.StatusNotFound, err.Error())
+	case "standing_order_not_active":
+		return NewApiError(http.StatusConflict, err.Error())
+	case "forbidden":
+		return NewApiError(http.StatusForbidden, err.Error())
+	default:
+		return err
+	}
+}
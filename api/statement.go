@@ -0,0 +1,200 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/services"
+	"github.com/farischt/gobank/pkg/types"
+)
+
+type StatementHandler struct {
+	service *services.Service
+}
+
+func NewStatementHandler(service *services.Service) *StatementHandler {
+	return &StatementHandler{
+		service: service,
+	}
+}
+
+/*
+HandleStatements routes the request to the appropriate handler for /account/{id}/statements endpoint.
+*/
+func (s *StatementHandler) HandleStatements(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return s.getStatements(w, r)
+	case "POST":
+		return s.createStatement(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleUniqueStatement routes the request to the appropriate handler for /account/{id}/statements/{statementId} endpoint.
+*/
+func (s *StatementHandler) HandleUniqueStatement(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return s.getStatement(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleStatementExport routes the request to the appropriate handler for /account/{id}/statement endpoint.
+*/
+func (s *StatementHandler) HandleStatementExport(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return s.exportStatement(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/* ------------------------------- Controller ------------------------------- */
+
+/*
+getStatements is the controller that handles the GET /account/{id}/statements endpoint.
+*/
+func (s *StatementHandler) getStatements(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	statements, err := s.service.Statement.GetAll(p, id)
+	if err != nil {
+		return statementError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, statements, r))
+}
+
+/*
+createStatement is the controller that handles the POST /account/{id}/statements endpoint.
+It generates the statement of a period that has ended.
+*/
+func (s *StatementHandler) createStatement(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	data := new(dto.CreateStatementDTO)
+
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		return NewApiError(http.StatusBadRequest, "invalid_request_body")
+	}
+	defer r.Body.Close()
+
+	statement, err := s.service.Statement.Generate(p, id, data)
+	if err != nil {
+		return statementError(err)
+	}
+
+	return WriteJSON(w, http.StatusCreated, NewApiResponse(http.StatusCreated, statement, r))
+}
+
+/*
+getStatement is the controller that handles the GET /account/{id}/statements/{statementId} endpoint.
+The statement is rendered in the format given by the format query parameter, json by default.
+*/
+func (s *StatementHandler) getStatement(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	statementId, err := GetIntParameter(r, "statementId")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_statement_id")
+	}
+
+	format := types.StatementFormat(r.URL.Query().Get("format"))
+	if format == "" {
+		format = types.StatementJSON
+	}
+
+	file, err := s.service.Statement.Render(p, id, statementId, format)
+	if err != nil {
+		return statementError(err)
+	}
+
+	return WriteFile(w, file)
+}
+
+/*
+exportStatement is the controller that handles the GET /account/{id}/statement endpoint.
+The transactions from the from query parameter to the to one, both included, are rendered in the
+bank format given by the format query parameter, camt053 or mt940.
+*/
+func (s *StatementHandler) exportStatement(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	format := types.StatementFormat(r.URL.Query().Get("format"))
+	if format == "" {
+		return NewApiError(http.StatusBadRequest, "missing_format")
+	}
+
+	from, err := GetTimeQuery(r, "from")
+	if err != nil {
+		return err
+	}
+
+	to, err := GetTimeQuery(r, "to")
+	if err != nil {
+		return err
+	}
+
+	file, err := s.service.Statement.Export(p, id, format, from, to)
+	if err != nil {
+		return statementError(err)
+	}
+
+	return WriteFile(w, file)
+}
+
+/*
+statementError maps the statement service errors to the appropriate API error.
+*/
+func statementError(err error) error {
+	switch err.Error() {
+	case "invalid_account_id", "invalid_period", "period_not_ended", "invalid_format":
+		return NewApiError(http.StatusBadRequest, err.Error())
+	case "account_not_found", "statement_not_found":
+		return NewApiError(http.StatusNotFound, err.Error())
+	case "forbidden":
+		return NewApiError(http.StatusForbidden, err.Error())
+	default:
+		return err
+	}
+}
@@ -2,10 +2,12 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/farischt/gobank/pkg/dto"
 	"github.com/farischt/gobank/pkg/services"
+	"github.com/farischt/gobank/pkg/types"
 )
 
 type TransactionHandler struct {
@@ -30,10 +32,47 @@ func (s *TransactionHandler) HandleTransfer(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+/*
+HandleTransferQuote routes the request to the appropriate handler for /transfer/quote endpoint.
+*/
+func (s *TransactionHandler) HandleTransferQuote(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "POST":
+		return s.quoteTransaction(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleTransactionReverse routes the request to the appropriate handler for /transaction/{id}/reverse endpoint.
+*/
+func (s *TransactionHandler) HandleTransactionReverse(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "POST":
+		return s.reverseTransaction(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleAccountTransactions routes the request to the appropriate handler for /account/{id}/transactions endpoint.
+*/
+func (s *TransactionHandler) HandleAccountTransactions(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return s.searchTransactions(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
 /* ------------------------------- Controller ------------------------------- */
 
 /*
 handleTransfer is the controller that handles the POST /transfer endpoint.
+A client retrying a transfer can send the same Idempotency-Key header so that it's only made once.
 */
 func (s *TransactionHandler) createTransaction(w http.ResponseWriter, r *http.Request) error {
 	data := new(dto.CreateTransactionDTO)
@@ -43,22 +82,155 @@ func (s *TransactionHandler) createTransaction(w http.ResponseWriter, r *http.Re
 	}
 	defer r.Body.Close()
 
-	tokenId, err := GetTokenFromHeader(r)
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	data.IdempotencyKey = r.Header.Get("Idempotency-Key")
+
+	txn, err := s.service.Transaction.Transfer(p, data)
+	if err != nil {
+		return transactionError(err)
+	}
+
+	return WriteJSON(w, http.StatusCreated, NewApiResponse(http.StatusCreated, types.SerializeSentTransaction(*txn), r))
+}
+
+/*
+quoteTransaction is the controller that handles the POST /transfer/quote endpoint.
+It previews the fee of a transfer without making it.
+*/
+func (s *TransactionHandler) quoteTransaction(w http.ResponseWriter, r *http.Request) error {
+	data := new(dto.CreateTransactionDTO)
+
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		return NewApiError(http.StatusBadRequest, "invalid_request_body")
+	}
+	defer r.Body.Close()
+
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	quote, err := s.service.Transaction.Quote(p, data)
+	if err != nil {
+		return transactionError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, quote, r))
+}
+
+/*
+reverseTransaction is the controller that handles the POST /transaction/{id}/reverse endpoint.
+*/
+func (s *TransactionHandler) reverseTransaction(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_transaction_id")
+	}
+
+	data := new(dto.ReverseTransactionDTO)
+
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		return NewApiError(http.StatusBadRequest, "invalid_request_body")
+	}
+	defer r.Body.Close()
+
+	reversal, err := s.service.Transaction.Reverse(p, id, data)
+	if err != nil {
+		return transactionError(err)
+	}
+
+	return WriteJSON(w, http.StatusCreated, NewApiResponse(http.StatusCreated, reversal, r))
+}
+
+/*
+searchTransactions is the controller that handles the GET /account/{id}/transactions endpoint.
+The history can be filtered with the q, creditor_reference, since and until query parameters.
+*/
+func (s *TransactionHandler) searchTransactions(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_account_id")
+	}
+
+	filter := &dto.SearchTransactionsDTO{
+		Query:             r.URL.Query().Get("q"),
+		CreditorReference: r.URL.Query().Get("creditor_reference"),
+	}
+
+	filter.Since, err = GetTimeQuery(r, "since")
 	if err != nil {
 		return err
 	}
 
-	// TODO: Useles check, since the token is already checked in the middleware
-	token, err := s.service.Session.Get(tokenId)
+	filter.Until, err = GetTimeQuery(r, "until")
 	if err != nil {
-		return NewApiError(http.StatusUnauthorized, "unauthorized")
+		return err
+	}
+
+	filter.Offset, err = GetIntQuery(r, "offset", 0)
+	if err != nil {
+		return err
 	}
 
-	err = s.service.Transaction.Transfer(token.AccountId, data)
+	filter.Limit, err = GetIntQuery(r, "limit", 50)
 	if err != nil {
-		// TODO: Handle error to return appropriate error code
 		return err
 	}
 
-	return WriteJSON(w, http.StatusCreated, NewApiResponse(http.StatusCreated, data, r))
+	txns, err := s.service.Transaction.Search(p, id, filter)
+	if err != nil {
+		return transactionError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, txns, r))
+}
+
+/*
+transactionError maps the transaction service errors to the appropriate API error.
+*/
+func transactionError(err error) error {
+	var limitErr *services.LimitExceededError
+	if errors.As(err, &limitErr) {
+		return NewApiErrorWithDetails(http.StatusForbidden, limitErr.Error(), limitErr)
+	}
+
+	var coolingOffErr *services.CoolingOffError
+	if errors.As(err, &coolingOffErr) {
+		return NewApiErrorWithDetails(http.StatusForbidden, coolingOffErr.Error(), coolingOffErr)
+	}
+
+	switch err.Error() {
+	case "invalid_amount", "invalid_iban", "cannot_transfer_to_yourself", "insufficient_balance", "invalid_idempotency_key",
+		"invalid_transaction_id", "reversal_exceeds_original", "to_and_beneficiary_id",
+		"invalid_reference", "invalid_creditor_reference", "invalid_memo", "invalid_account_id", "invalid_limit", "invalid_period",
+		"missing_source_account", "overdraft_not_allowed":
+		return NewApiError(http.StatusBadRequest, err.Error())
+	case "idempotency_key_reused", "transaction_not_reversible", "transaction_already_reversed":
+		return NewApiError(http.StatusConflict, err.Error())
+	case "fx_rate_not_found", "fx_rate_stale":
+		return NewApiErrorWithDetails(http.StatusUnprocessableEntity, err.Error(), "no usable exchange rate between the currencies of the accounts")
+	case "account_not_found", "transaction_not_found", "beneficiary_not_found":
+		return NewApiError(http.StatusNotFound, err.Error())
+	case "account_frozen", "account_dormant", "account_closed",
+		"recipient_account_closed", "savings_transfer_to_other_owner",
+		"savings_monthly_transfer_limit_reached", "term_deposit_not_matured",
+		"sender_account_closed", "beneficiary_not_confirmed", "forbidden":
+		return NewApiError(http.StatusForbidden, err.Error())
+	default:
+		return err
+	}
 }
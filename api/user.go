@@ -37,6 +37,58 @@ func (u *UserHandler) HandleUniqueUser(w http.ResponseWriter, r *http.Request) e
 	switch r.Method {
 	case "GET":
 		return u.getUserById(w, r)
+	case "PATCH":
+		return u.updateUser(w, r)
+	case "DELETE":
+		return u.deleteUser(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleUserExport routes the request to the appropriate handler for /user/{id}/export endpoint.
+*/
+func (u *UserHandler) HandleUserExport(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "GET":
+		return u.exportUser(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleUserPassword routes the request to the appropriate handler for /user/{id}/password endpoint.
+*/
+func (u *UserHandler) HandleUserPassword(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "PUT":
+		return u.setUserPassword(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleVerifyEmail routes the request to the appropriate handler for /user/verify endpoint.
+*/
+func (u *UserHandler) HandleVerifyEmail(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "POST":
+		return u.verifyEmail(w, r)
+	default:
+		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+/*
+HandleResendVerification routes the request to the appropriate handler for /user/verify/resend endpoint.
+*/
+func (u *UserHandler) HandleResendVerification(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case "POST":
+		return u.resendVerification(w, r)
 	default:
 		return NewApiError(http.StatusMethodNotAllowed, "method_not_allowed")
 	}
@@ -63,13 +115,18 @@ func (u *UserHandler) createUser(w http.ResponseWriter, r *http.Request) error {
 			return NewApiError(http.StatusBadRequest, err.Error())
 		case "empty_email":
 			return NewApiError(http.StatusBadRequest, err.Error())
-		case "user_already_exists":
+		case "invalid_email":
+			return NewApiError(http.StatusBadRequest, err.Error())
+		case "user_already_exist", "password_too_short":
 			return NewApiError(http.StatusBadRequest, err.Error())
 		default:
 			return err
 		}
 	}
 
+	// The password is never sent back
+	data.Password = ""
+
 	return WriteJSON(w, http.StatusCreated, NewApiResponse(http.StatusCreated, data, r))
 }
 
@@ -77,17 +134,24 @@ func (u *UserHandler) createUser(w http.ResponseWriter, r *http.Request) error {
 getUserById is the controller method that handles the GET /user/{id} endpoint.
 */
 func (u *UserHandler) getUserById(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
 	id, err := GetIntParameter(r, "id")
 
 	if err != nil {
 		return NewApiError(http.StatusBadRequest, "missing_user_id")
 	}
 
-	user, err := u.service.User.Get(id)
+	user, err := u.service.User.Get(p, id)
 	if err != nil {
 		switch err.Error() {
 		case "invalid_user_id":
 			return NewApiError(http.StatusBadRequest, err.Error())
+		case "user_not_found":
+			return NewApiError(http.StatusNotFound, err.Error())
 		default:
 			return err
 		}
@@ -95,3 +159,162 @@ func (u *UserHandler) getUserById(w http.ResponseWriter, r *http.Request) error
 
 	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, user, r))
 }
+
+/*
+updateUser is the controller method that handles the PATCH /user/{id} endpoint.
+*/
+func (u *UserHandler) updateUser(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_user_id")
+	}
+
+	data := new(dto.UpdateUserDTO)
+
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		return NewApiError(http.StatusBadRequest, "invalid_request_body")
+	}
+	defer r.Body.Close()
+
+	user, err := u.service.User.Update(p, id, data)
+	if err != nil {
+		return userError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, user, r))
+}
+
+/*
+deleteUser is the controller method that handles the DELETE /user/{id} endpoint.
+*/
+func (u *UserHandler) deleteUser(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_user_id")
+	}
+
+	err = u.service.User.Delete(p, id)
+	if err != nil {
+		return userError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, nil, r))
+}
+
+/*
+exportUser is the controller method that handles the GET /user/{id}/export endpoint.
+*/
+func (u *UserHandler) exportUser(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_user_id")
+	}
+
+	export, err := u.service.User.Export(p, id)
+	if err != nil {
+		return userError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, export, r))
+}
+
+/*
+setUserPassword is the controller method that handles the PUT /user/{id}/password endpoint.
+*/
+func (u *UserHandler) setUserPassword(w http.ResponseWriter, r *http.Request) error {
+	p, err := GetPrincipal(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParameter(r, "id")
+	if err != nil {
+		return NewApiError(http.StatusBadRequest, "missing_user_id")
+	}
+
+	data := new(dto.SetPasswordDTO)
+
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		return NewApiError(http.StatusBadRequest, "invalid_request_body")
+	}
+	defer r.Body.Close()
+
+	err = u.service.User.SetPassword(p, id, data)
+	if err != nil {
+		return userError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, nil, r))
+}
+
+/*
+verifyEmail is the controller method that handles the POST /user/verify endpoint.
+*/
+func (u *UserHandler) verifyEmail(w http.ResponseWriter, r *http.Request) error {
+	data := new(dto.VerifyEmailDTO)
+
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		return NewApiError(http.StatusBadRequest, "invalid_request_body")
+	}
+	defer r.Body.Close()
+
+	err := u.service.User.VerifyEmail(data.Token)
+	if err != nil {
+		return userError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, nil, r))
+}
+
+/*
+resendVerification is the controller method that handles the POST /user/verify/resend endpoint.
+*/
+func (u *UserHandler) resendVerification(w http.ResponseWriter, r *http.Request) error {
+	data := new(dto.ResendVerificationDTO)
+
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		return NewApiError(http.StatusBadRequest, "invalid_request_body")
+	}
+	defer r.Body.Close()
+
+	err := u.service.User.ResendVerification(data.Email)
+	if err != nil {
+		return userError(err)
+	}
+
+	return WriteJSON(w, http.StatusOK, NewApiResponse(http.StatusOK, nil, r))
+}
+
+/*
+userError maps the user service errors to the appropriate API error.
+*/
+func userError(err error) error {
+	switch err.Error() {
+	case "invalid_user_id", "empty_first_name", "empty_last_name", "empty_email", "invalid_email", "user_already_exist", "invalid_verification_token",
+		"password_too_short":
+		return NewApiError(http.StatusBadRequest, err.Error())
+	case "non_zero_balance", "accrued_interest_outstanding", "active_holds", "active_standing_orders", "primary_holder_of_joint_account":
+		return NewApiError(http.StatusConflict, err.Error())
+	case "user_not_found":
+		return NewApiError(http.StatusNotFound, err.Error())
+	case "invalid_current_password", "forbidden":
+		return NewApiError(http.StatusForbidden, err.Error())
+	default:
+		return err
+	}
+}
@@ -3,19 +3,22 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/farischt/gobank/config"
+	"github.com/farischt/gobank/pkg/types"
 	"github.com/gorilla/mux"
 )
 
 /* API ERROR */
 type ApiError struct {
-	Status    int       `json:"status"`
-	Err       string    `json:"error"`
-	Timestamp time.Time `json:"timestamp"`
+	Status    int         `json:"status"`
+	Err       string      `json:"error"`
+	Details   interface{} `json:"details,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
 }
 
 func NewApiError(s int, e string) ApiError {
@@ -26,6 +29,12 @@ func NewApiError(s int, e string) ApiError {
 	}
 }
 
+func NewApiErrorWithDetails(s int, e string, d interface{}) ApiError {
+	err := NewApiError(s, e)
+	err.Details = d
+	return err
+}
+
 func (a ApiError) Error() string {
 	return a.Err
 }
@@ -72,6 +81,19 @@ func makeHTTPFunc(f apiFunc) http.HandlerFunc {
 	}
 }
 
+/*
+statusWriter is an http.ResponseWriter that remembers the status code written by a handler.
+*/
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
 /*
 WriteJSON is a helper function to write JSON response.
 It will set the content-type to application/json and write the status code.
@@ -83,6 +105,22 @@ func WriteJSON(w http.ResponseWriter, status int, v any) error {
 	return json.NewEncoder(w).Encode(v)
 }
 
+/*
+WriteFile is a helper function to write a rendered file as an attachment.
+The hash of the document the file was rendered from, if any, is set in the X-Statement-Hash header.
+*/
+func WriteFile(w http.ResponseWriter, file *types.StatementFile) error {
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Filename))
+	if file.Hash != "" {
+		w.Header().Set("X-Statement-Hash", file.Hash)
+	}
+	w.WriteHeader(http.StatusOK)
+
+	_, err := w.Write(file.Content)
+	return err
+}
+
 /*
 getStringParameter is a helper function to get a string parameter from the request.
 It takes the request and the parameter name.
@@ -117,6 +155,46 @@ func GetIntParameter(r *http.Request, param string) (uint, error) {
 	return uint(parsedParameter), nil
 }
 
+/*
+GetIntQuery is a helper function to get an integer query parameter from the request.
+It takes the request, the parameter name and the value to use when the parameter is absent.
+It returns the parameter value and an error if the parameter is invalid.
+*/
+func GetIntQuery(r *http.Request, param string, defaultValue uint) (uint, error) {
+	p := r.URL.Query().Get(param)
+	if p == "" {
+		return defaultValue, nil
+	}
+
+	parsedParameter, err := strconv.Atoi(p)
+	if err != nil || parsedParameter < 0 {
+		return 0, NewApiError(http.StatusBadRequest, fmt.Sprintf("invalid_%s", param))
+	}
+
+	return uint(parsedParameter), nil
+}
+
+/*
+GetTimeQuery is a helper function to get a time query parameter from the request, as a date or an RFC 3339 time.
+It returns nil if the parameter is absent and an error if the parameter is invalid.
+*/
+func GetTimeQuery(r *http.Request, param string) (*time.Time, error) {
+	p := r.URL.Query().Get(param)
+	if p == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, p)
+	if err != nil {
+		t, err = time.Parse("2006-01-02", p)
+	}
+	if err != nil {
+		return nil, NewApiError(http.StatusBadRequest, fmt.Sprintf("invalid_%s", param))
+	}
+
+	return &t, nil
+}
+
 func GetTokenFromHeader(r *http.Request) (string, error) {
 	token := r.Header.Get(config.GetConfig().GetString(config.TOKEN_NAME))
 	if token == "" {
@@ -125,3 +203,15 @@ func GetTokenFromHeader(r *http.Request) (string, error) {
 
 	return token, nil
 }
+
+/*
+GetClientIP is a helper function to get the IP address of the client from the request.
+*/
+func GetClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
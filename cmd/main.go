@@ -7,6 +7,9 @@ import (
 
 	"github.com/farischt/gobank/api"
 	"github.com/farischt/gobank/config"
+	"github.com/farischt/gobank/pkg/jobs"
+	"github.com/farischt/gobank/pkg/mailer"
+	"github.com/farischt/gobank/pkg/services"
 	"github.com/farischt/gobank/pkg/store"
 )
 
@@ -29,6 +32,32 @@ func main() {
 		log.Fatal(err)
 	}
 
-	s := api.New(port, *storage)
+	m, err := mailer.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	service := services.New(*storage, m)
+
+	n, err := service.Account.AssignIBANs()
+	if err != nil {
+		log.Fatal(err)
+	} else if n > 0 {
+		log.Printf("%d account(s) given an IBAN", n)
+	}
+
+	runner := jobs.NewRunner(
+		jobs.NewDormancyJob(service),
+		jobs.NewInterestJob(service),
+		jobs.NewStandingOrderJob(service),
+		jobs.NewHoldExpiryJob(service),
+		jobs.NewFxRateImportJob(service),
+		jobs.NewStatementJob(service),
+		jobs.NewPaymentRequestExpiryJob(service),
+		jobs.NewBatchRecoveryJob(service),
+	)
+	runner.Start()
+
+	s := api.New(port, service)
 	s.Start()
 }
@@ -8,14 +8,40 @@ import (
 )
 
 const (
-	PORT        = "PORT"
-	TOKEN_NAME  = "TOKEN_NAME"
-	HOST        = "HOST"
-	DB_HOST     = "POSTGRES_HOSTNAME"
-	DB_PORT     = "POSTGRES_PORT"
-	DB_USER     = "POSTGRES_USER"
-	DB_PASSWORD = "POSTGRES_PASSWORD"
-	DB_NAME     = "POSTGRES_DB"
+	PORT                           = "PORT"
+	TOKEN_NAME                     = "TOKEN_NAME"
+	API_KEY_NAME                   = "API_KEY_NAME"
+	RATE_LIMIT                     = "RATE_LIMIT"
+	RATE_LIMIT_DEFAULT             = "RATE_LIMIT_DEFAULT"
+	MAILER                         = "MAILER"
+	MAILER_DIR                     = "MAILER_DIR"
+	EMAIL_VERIFICATION_TTL         = "EMAIL_VERIFICATION_TTL"
+	DORMANT_AFTER_DAYS             = "DORMANT_AFTER_DAYS"
+	SAVINGS_MAX_MONTHLY_TRANSFERS  = "SAVINGS_MAX_MONTHLY_TRANSFERS"
+	TRANSFER_LIMIT                 = "TRANSFER_LIMIT"
+	INTEREST_RATE                  = "INTEREST_RATE"
+	INTEREST_DAY_COUNT             = "INTEREST_DAY_COUNT"
+	FEE                            = "FEE"
+	STANDING_ORDER_MAX_ATTEMPTS    = "STANDING_ORDER_MAX_ATTEMPTS"
+	STANDING_ORDER_RETRY_DELAY     = "STANDING_ORDER_RETRY_DELAY"
+	HOLD_TTL                       = "HOLD_TTL"
+	DEFAULT_CURRENCY               = "DEFAULT_CURRENCY"
+	FX_MAX_STALENESS               = "FX_MAX_STALENESS"
+	FX_RATES_CSV                   = "FX_RATES_CSV"
+	IBAN_COUNTRY_CODE              = "IBAN_COUNTRY_CODE"
+	IBAN_BANK_CODE                 = "IBAN_BANK_CODE"
+	BENEFICIARY_COOLING_OFF        = "BENEFICIARY_COOLING_OFF"
+	BENEFICIARY_COOLING_OFF_AMOUNT = "BENEFICIARY_COOLING_OFF_AMOUNT"
+	BATCH_MAX_LINES                = "BATCH_MAX_LINES"
+	BATCH_STALE_AFTER              = "BATCH_STALE_AFTER"
+	PAYMENT_REQUEST_TTL            = "PAYMENT_REQUEST_TTL"
+	LEGACY_ACCOUNT_LOGIN           = "LEGACY_ACCOUNT_LOGIN"
+	HOST                           = "HOST"
+	DB_HOST                        = "POSTGRES_HOSTNAME"
+	DB_PORT                        = "POSTGRES_PORT"
+	DB_USER                        = "POSTGRES_USER"
+	DB_PASSWORD                    = "POSTGRES_PASSWORD"
+	DB_NAME                        = "POSTGRES_DB"
 )
 
 var config *viper.Viper
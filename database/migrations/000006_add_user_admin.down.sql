@@ -0,0 +1,6 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE "user"
+    DROP COLUMN IF EXISTS "is_admin";
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE "user"
+    ADD COLUMN "is_admin" BOOLEAN NOT NULL DEFAULT false;
+
+COMMIT;
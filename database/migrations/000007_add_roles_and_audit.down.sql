@@ -0,0 +1,12 @@
+BEGIN TRANSACTION;
+
+DROP TABLE IF EXISTS "audit_log";
+
+ALTER TABLE "user"
+    ADD COLUMN "is_admin" BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE "user" SET "is_admin" = true WHERE "id" IN (SELECT "user_id" FROM "user_role" WHERE "role" = 'admin');
+
+DROP TABLE IF EXISTS "user_role";
+
+COMMIT;
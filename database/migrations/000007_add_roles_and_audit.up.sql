@@ -0,0 +1,34 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS "user_role" (
+  "user_id" INTEGER NOT NULL,
+  "role" VARCHAR NOT NULL CHECK ("role" IN ('customer', 'support', 'operator', 'admin')),
+  "granted_by" INTEGER,
+  "created_at" TIMESTAMP DEFAULT (now()),
+  PRIMARY KEY ("user_id", "role")
+);
+
+ALTER TABLE "user_role"
+    ADD FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    ADD FOREIGN KEY ("granted_by") REFERENCES "user" ("id") ON DELETE SET NULL ON UPDATE CASCADE;
+
+INSERT INTO "user_role" ("user_id", "role") SELECT "id", 'customer' FROM "user";
+INSERT INTO "user_role" ("user_id", "role") SELECT "id", 'admin' FROM "user" WHERE "is_admin";
+
+ALTER TABLE "user"
+    DROP COLUMN IF EXISTS "is_admin";
+
+CREATE TABLE IF NOT EXISTS "audit_log" (
+  "id" SERIAL PRIMARY KEY,
+  "actor_user_id" INTEGER,
+  "permission" VARCHAR NOT NULL,
+  "method" VARCHAR NOT NULL,
+  "path" VARCHAR NOT NULL,
+  "status" INTEGER NOT NULL,
+  "created_at" TIMESTAMP DEFAULT (now())
+);
+
+ALTER TABLE "audit_log"
+    ADD FOREIGN KEY ("actor_user_id") REFERENCES "user" ("id") ON DELETE SET NULL ON UPDATE CASCADE;
+
+COMMIT;
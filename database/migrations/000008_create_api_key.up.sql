@@ -0,0 +1,24 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS "api_key" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" INTEGER NOT NULL,
+  "account_id" INTEGER NOT NULL,
+  "name" VARCHAR NOT NULL,
+  "prefix" VARCHAR UNIQUE NOT NULL,
+  "secret_hash" TEXT NOT NULL,
+  "scopes" TEXT[] NOT NULL DEFAULT '{}',
+  "allowed_ips" TEXT[] NOT NULL DEFAULT '{}',
+  "expires_at" TIMESTAMP,
+  "last_used_at" TIMESTAMP,
+  "last_used_ip" VARCHAR,
+  "revoked_at" TIMESTAMP,
+  "created_at" TIMESTAMP DEFAULT (now()),
+  "updated_at" TIMESTAMP DEFAULT (now())
+);
+
+ALTER TABLE "api_key"
+    ADD FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    ADD FOREIGN KEY ("account_id") REFERENCES "account" ("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE "transaction"
+    DROP CONSTRAINT IF EXISTS "transaction_from_id_fkey",
+    DROP CONSTRAINT IF EXISTS "transaction_to_id_fkey",
+    ADD FOREIGN KEY ("from_id") REFERENCES "account" ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    ADD FOREIGN KEY ("to_id") REFERENCES "account" ("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE "account"
+    DROP CONSTRAINT IF EXISTS "account_user_id_fkey",
+    ADD FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE "user"
+    DROP COLUMN IF EXISTS "deleted_at";
+
+COMMIT;
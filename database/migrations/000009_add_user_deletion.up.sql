@@ -0,0 +1,17 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE "user"
+    ADD COLUMN "deleted_at" TIMESTAMP;
+
+-- Deleting a user anonymizes it, rows holding history must never be cascaded away
+ALTER TABLE "account"
+    DROP CONSTRAINT IF EXISTS "account_user_id_fkey",
+    ADD FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON DELETE RESTRICT ON UPDATE CASCADE;
+
+ALTER TABLE "transaction"
+    DROP CONSTRAINT IF EXISTS "transaction_from_id_fkey",
+    DROP CONSTRAINT IF EXISTS "transaction_to_id_fkey",
+    ADD FOREIGN KEY ("from_id") REFERENCES "account" ("id") ON DELETE RESTRICT ON UPDATE CASCADE,
+    ADD FOREIGN KEY ("to_id") REFERENCES "account" ("id") ON DELETE RESTRICT ON UPDATE CASCADE;
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN TRANSACTION;
+
+DROP TABLE IF EXISTS "email_verification";
+
+ALTER TABLE "user"
+    DROP COLUMN IF EXISTS "verified_at";
+
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE "user"
+    ADD COLUMN "verified_at" TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS "email_verification" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" INTEGER NOT NULL,
+  "email" VARCHAR NOT NULL,
+  "token_hash" TEXT UNIQUE NOT NULL,
+  "expires_at" TIMESTAMP NOT NULL,
+  "consumed_at" TIMESTAMP,
+  "created_at" TIMESTAMP DEFAULT (now())
+);
+
+ALTER TABLE "email_verification"
+    ADD FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN TRANSACTION;
+
+DROP TABLE IF EXISTS "account_status_history";
+
+ALTER TABLE "account"
+    DROP COLUMN IF EXISTS "status",
+    DROP COLUMN IF EXISTS "last_activity_at";
+
+COMMIT;
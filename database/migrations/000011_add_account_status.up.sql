@@ -0,0 +1,21 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE "account"
+    ADD COLUMN "status" VARCHAR NOT NULL DEFAULT 'active' CHECK ("status" IN ('active', 'frozen', 'dormant', 'closed')),
+    ADD COLUMN "last_activity_at" TIMESTAMP NOT NULL DEFAULT (now());
+
+CREATE TABLE IF NOT EXISTS "account_status_history" (
+  "id" SERIAL PRIMARY KEY,
+  "account_id" INTEGER NOT NULL,
+  "from_status" VARCHAR NOT NULL,
+  "to_status" VARCHAR NOT NULL,
+  "reason" TEXT NOT NULL,
+  "actor_user_id" INTEGER,
+  "created_at" TIMESTAMP DEFAULT (now())
+);
+
+ALTER TABLE "account_status_history"
+    ADD FOREIGN KEY ("account_id") REFERENCES "account" ("id") ON DELETE RESTRICT ON UPDATE CASCADE,
+    ADD FOREIGN KEY ("actor_user_id") REFERENCES "user" ("id") ON DELETE SET NULL ON UPDATE CASCADE;
+
+COMMIT;
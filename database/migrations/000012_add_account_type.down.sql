@@ -0,0 +1,8 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE "account"
+    DROP CONSTRAINT IF EXISTS "account_term_deposit_maturity_check",
+    DROP COLUMN IF EXISTS "maturity_date",
+    DROP COLUMN IF EXISTS "type";
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE "account"
+    ADD COLUMN "type" VARCHAR NOT NULL DEFAULT 'checking' CHECK ("type" IN ('checking', 'savings', 'term_deposit')),
+    ADD COLUMN "maturity_date" DATE,
+    ADD CONSTRAINT "account_term_deposit_maturity_check" CHECK ("type" <> 'term_deposit' OR "maturity_date" IS NOT NULL);
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE "account"
+    DROP CONSTRAINT IF EXISTS "account_balance_floor_check",
+    DROP COLUMN IF EXISTS "overdraft_limit";
+
+COMMIT;
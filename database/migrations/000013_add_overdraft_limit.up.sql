@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE "account"
+    ADD COLUMN "overdraft_limit" DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK ("overdraft_limit" >= 0),
+    ADD CONSTRAINT "account_balance_floor_check" CHECK ("balance" >= -"overdraft_limit");
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN TRANSACTION;
+
+DROP INDEX IF EXISTS "transaction_from_id_created_at_idx";
+
+ALTER TABLE "account"
+    DROP COLUMN IF EXISTS "max_transfer_amount",
+    DROP COLUMN IF EXISTS "daily_transfer_amount",
+    DROP COLUMN IF EXISTS "daily_transfer_count";
+
+COMMIT;
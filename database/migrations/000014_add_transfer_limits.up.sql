@@ -0,0 +1,11 @@
+BEGIN TRANSACTION;
+
+-- Per account overrides of the transfer limits of the account type, NULL uses the type limit
+ALTER TABLE "account"
+    ADD COLUMN "max_transfer_amount" DECIMAL(15,2) CHECK ("max_transfer_amount" >= 0),
+    ADD COLUMN "daily_transfer_amount" DECIMAL(15,2) CHECK ("daily_transfer_amount" >= 0),
+    ADD COLUMN "daily_transfer_count" INTEGER CHECK ("daily_transfer_count" >= 0);
+
+CREATE INDEX IF NOT EXISTS "transaction_from_id_created_at_idx" ON "transaction" ("from_id", "created_at");
+
+COMMIT;
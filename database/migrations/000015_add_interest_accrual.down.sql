@@ -0,0 +1,21 @@
+BEGIN TRANSACTION;
+
+DROP TABLE IF EXISTS "interest_capitalization";
+DROP TABLE IF EXISTS "interest_accrual";
+
+DELETE FROM "transaction" WHERE "type" = 'interest';
+
+ALTER TABLE "transaction"
+    DROP COLUMN IF EXISTS "type";
+
+DELETE FROM "account" WHERE "system_code" IS NOT NULL;
+DELETE FROM "user_role" WHERE "user_id" IN (SELECT "id" FROM "user" WHERE "email" = 'system@gobank.internal');
+DELETE FROM "user" WHERE "email" = 'system@gobank.internal';
+
+ALTER TABLE "account"
+    DROP CONSTRAINT IF EXISTS "account_balance_floor_check",
+    DROP COLUMN IF EXISTS "accrued_interest",
+    DROP COLUMN IF EXISTS "system_code",
+    ADD CONSTRAINT "account_balance_floor_check" CHECK ("balance" >= -"overdraft_limit");
+
+COMMIT;
@@ -0,0 +1,47 @@
+BEGIN TRANSACTION;
+
+-- System accounts are the bank's own accounts, they can't log in and have no balance floor
+ALTER TABLE "account"
+    ADD COLUMN "system_code" VARCHAR UNIQUE,
+    ADD COLUMN "accrued_interest" DECIMAL(20,8) NOT NULL DEFAULT 0,
+    DROP CONSTRAINT IF EXISTS "account_balance_floor_check",
+    ADD CONSTRAINT "account_balance_floor_check" CHECK ("system_code" IS NOT NULL OR "balance" >= -"overdraft_limit");
+
+INSERT INTO "user" ("first_name", "last_name", "email", "verified_at")
+    VALUES ('Gobank', 'System', 'system@gobank.internal', now())
+    ON CONFLICT ("email") DO NOTHING;
+
+INSERT INTO "account" ("user_id", "password", "balance", "system_code")
+    SELECT "id", '', 0, 'interest_expense' FROM "user" WHERE "email" = 'system@gobank.internal';
+
+ALTER TABLE "transaction"
+    ADD COLUMN "type" VARCHAR NOT NULL DEFAULT 'transfer' CHECK ("type" IN ('transfer', 'interest'));
+
+CREATE TABLE IF NOT EXISTS "interest_accrual" (
+  "account_id" INTEGER NOT NULL,
+  "business_date" DATE NOT NULL,
+  "balance" DECIMAL(15,2) NOT NULL,
+  "rate" DECIMAL(9,6) NOT NULL,
+  "day_count" VARCHAR NOT NULL,
+  "amount" DECIMAL(20,8) NOT NULL,
+  "created_at" TIMESTAMP DEFAULT (now()),
+  PRIMARY KEY ("account_id", "business_date")
+);
+
+CREATE TABLE IF NOT EXISTS "interest_capitalization" (
+  "account_id" INTEGER NOT NULL,
+  "business_date" DATE NOT NULL,
+  "amount" DECIMAL(15,2) NOT NULL,
+  "transaction_id" INTEGER,
+  "created_at" TIMESTAMP DEFAULT (now()),
+  PRIMARY KEY ("account_id", "business_date")
+);
+
+ALTER TABLE "interest_accrual"
+    ADD FOREIGN KEY ("account_id") REFERENCES "account" ("id") ON DELETE RESTRICT ON UPDATE CASCADE;
+
+ALTER TABLE "interest_capitalization"
+    ADD FOREIGN KEY ("account_id") REFERENCES "account" ("id") ON DELETE RESTRICT ON UPDATE CASCADE,
+    ADD FOREIGN KEY ("transaction_id") REFERENCES "transaction" ("id") ON DELETE RESTRICT ON UPDATE CASCADE;
+
+COMMIT;
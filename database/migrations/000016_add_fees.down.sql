@@ -0,0 +1,11 @@
+BEGIN TRANSACTION;
+
+DELETE FROM "transaction" WHERE "type" = 'fee';
+DELETE FROM "account" WHERE "system_code" = 'fee_income';
+
+ALTER TABLE "transaction"
+    DROP COLUMN IF EXISTS "parent_id",
+    DROP CONSTRAINT IF EXISTS "transaction_type_check",
+    ADD CONSTRAINT "transaction_type_check" CHECK ("type" IN ('transfer', 'interest'));
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE "transaction"
+    DROP CONSTRAINT IF EXISTS "transaction_type_check",
+    ADD CONSTRAINT "transaction_type_check" CHECK ("type" IN ('transfer', 'interest', 'fee')),
+    ADD COLUMN "parent_id" INTEGER;
+
+ALTER TABLE "transaction"
+    ADD FOREIGN KEY ("parent_id") REFERENCES "transaction" ("id") ON DELETE RESTRICT ON UPDATE CASCADE;
+
+INSERT INTO "account" ("user_id", "password", "balance", "system_code")
+    SELECT "id", '', 0, 'fee_income' FROM "user" WHERE "email" = 'system@gobank.internal';
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN TRANSACTION;
+
+DROP TABLE IF EXISTS "standing_order_run";
+DROP TABLE IF EXISTS "standing_order";
+
+DROP INDEX IF EXISTS "transaction_from_id_idempotency_key_idx";
+
+ALTER TABLE "transaction"
+    DROP COLUMN IF EXISTS "idempotency_key";
+
+COMMIT;
@@ -0,0 +1,48 @@
+BEGIN TRANSACTION;
+
+-- A transfer made twice with the same key by the same sender is only made once
+ALTER TABLE "transaction"
+    ADD COLUMN "idempotency_key" VARCHAR;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "transaction_from_id_idempotency_key_idx" ON "transaction" ("from_id", "idempotency_key");
+
+CREATE TABLE IF NOT EXISTS "standing_order" (
+  "id" SERIAL PRIMARY KEY,
+  "account_id" INTEGER NOT NULL,
+  "to_id" INTEGER NOT NULL,
+  "amount" DECIMAL(15,2) NOT NULL CHECK ("amount" > 0),
+  "frequency" VARCHAR NOT NULL CHECK ("frequency" IN ('once', 'weekly', 'monthly', 'end_of_month')),
+  "day_of_month" INTEGER CHECK ("day_of_month" BETWEEN 1 AND 31),
+  "start_date" DATE NOT NULL,
+  "end_date" DATE,
+  "next_run_date" DATE,
+  "attempts" INTEGER NOT NULL DEFAULT 0,
+  "next_attempt_at" TIMESTAMP,
+  "status" VARCHAR NOT NULL DEFAULT 'active' CHECK ("status" IN ('active', 'completed', 'cancelled')),
+  "created_at" TIMESTAMP DEFAULT (now()),
+  "updated_at" TIMESTAMP DEFAULT (now())
+);
+
+CREATE TABLE IF NOT EXISTS "standing_order_run" (
+  "id" SERIAL PRIMARY KEY,
+  "standing_order_id" INTEGER NOT NULL,
+  "run_date" DATE NOT NULL,
+  "attempt" INTEGER NOT NULL,
+  "status" VARCHAR NOT NULL CHECK ("status" IN ('succeeded', 'failed')),
+  "error" VARCHAR,
+  "transaction_id" INTEGER,
+  "created_at" TIMESTAMP DEFAULT (now())
+);
+
+ALTER TABLE "standing_order"
+    ADD FOREIGN KEY ("account_id") REFERENCES "account" ("id") ON DELETE RESTRICT ON UPDATE CASCADE,
+    ADD FOREIGN KEY ("to_id") REFERENCES "account" ("id") ON DELETE RESTRICT ON UPDATE CASCADE;
+
+ALTER TABLE "standing_order_run"
+    ADD FOREIGN KEY ("standing_order_id") REFERENCES "standing_order" ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    ADD FOREIGN KEY ("transaction_id") REFERENCES "transaction" ("id") ON DELETE RESTRICT ON UPDATE CASCADE;
+
+CREATE INDEX IF NOT EXISTS "standing_order_next_run_date_idx" ON "standing_order" ("next_run_date") WHERE "status" = 'active';
+CREATE UNIQUE INDEX IF NOT EXISTS "standing_order_run_succeeded_idx" ON "standing_order_run" ("standing_order_id", "run_date") WHERE "status" = 'succeeded';
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN TRANSACTION;
+
+DELETE FROM "transaction" WHERE "type" = 'reversal';
+
+ALTER TABLE "transaction"
+    DROP CONSTRAINT IF EXISTS "transaction_reversed_amount_check",
+    DROP COLUMN IF EXISTS "reversed_amount",
+    DROP COLUMN IF EXISTS "reversal_of",
+    DROP COLUMN IF EXISTS "status",
+    DROP CONSTRAINT IF EXISTS "transaction_type_check",
+    ADD CONSTRAINT "transaction_type_check" CHECK ("type" IN ('transfer', 'interest', 'fee'));
+
+COMMIT;
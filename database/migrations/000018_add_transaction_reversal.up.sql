@@ -0,0 +1,14 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE "transaction"
+    DROP CONSTRAINT IF EXISTS "transaction_type_check",
+    ADD CONSTRAINT "transaction_type_check" CHECK ("type" IN ('transfer', 'interest', 'fee', 'reversal')),
+    ADD COLUMN "status" VARCHAR NOT NULL DEFAULT 'completed' CHECK ("status" IN ('completed', 'partially_reversed', 'reversed')),
+    ADD COLUMN "reversal_of" INTEGER,
+    ADD COLUMN "reversed_amount" DECIMAL(15,2) NOT NULL DEFAULT 0,
+    ADD CONSTRAINT "transaction_reversed_amount_check" CHECK ("reversed_amount" >= 0 AND "reversed_amount" <= "amount");
+
+ALTER TABLE "transaction"
+    ADD FOREIGN KEY ("reversal_of") REFERENCES "transaction" ("id") ON DELETE RESTRICT ON UPDATE CASCADE;
+
+COMMIT;
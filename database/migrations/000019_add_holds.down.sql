@@ -0,0 +1,8 @@
+BEGIN TRANSACTION;
+
+DROP TABLE IF EXISTS "hold";
+
+ALTER TABLE "account"
+    DROP COLUMN IF EXISTS "held_amount";
+
+COMMIT;
@@ -0,0 +1,27 @@
+BEGIN TRANSACTION;
+
+-- Sum of the active holds, the available balance is the balance minus the held amount
+ALTER TABLE "account"
+    ADD COLUMN "held_amount" DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK ("held_amount" >= 0);
+
+CREATE TABLE IF NOT EXISTS "hold" (
+  "id" SERIAL PRIMARY KEY,
+  "account_id" INTEGER NOT NULL,
+  "to_id" INTEGER NOT NULL,
+  "amount" DECIMAL(15,2) NOT NULL CHECK ("amount" > 0),
+  "captured_amount" DECIMAL(15,2),
+  "status" VARCHAR NOT NULL DEFAULT 'active' CHECK ("status" IN ('active', 'captured', 'released', 'expired')),
+  "transaction_id" INTEGER,
+  "expires_at" TIMESTAMP NOT NULL,
+  "created_at" TIMESTAMP DEFAULT (now()),
+  "updated_at" TIMESTAMP DEFAULT (now())
+);
+
+ALTER TABLE "hold"
+    ADD FOREIGN KEY ("account_id") REFERENCES "account" ("id") ON DELETE RESTRICT ON UPDATE CASCADE,
+    ADD FOREIGN KEY ("to_id") REFERENCES "account" ("id") ON DELETE RESTRICT ON UPDATE CASCADE,
+    ADD FOREIGN KEY ("transaction_id") REFERENCES "transaction" ("id") ON DELETE RESTRICT ON UPDATE CASCADE;
+
+CREATE INDEX IF NOT EXISTS "hold_expires_at_idx" ON "hold" ("expires_at") WHERE "status" = 'active';
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN TRANSACTION;
+
+DROP TABLE IF EXISTS "fx_rate";
+
+ALTER TABLE "transaction"
+    DROP COLUMN IF EXISTS "fx_rate",
+    DROP COLUMN IF EXISTS "to_currency",
+    DROP COLUMN IF EXISTS "to_amount",
+    DROP COLUMN IF EXISTS "currency";
+
+ALTER TABLE "account"
+    DROP COLUMN IF EXISTS "currency";
+
+COMMIT;
@@ -0,0 +1,30 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE "account"
+    ADD COLUMN "currency" CHAR(3) NOT NULL DEFAULT 'EUR' CHECK ("currency" ~ '^[A-Z]{3}$');
+
+-- Each leg of a transaction is booked in the currency of its account, with the rate applied from one to the other
+ALTER TABLE "transaction"
+    ADD COLUMN "currency" CHAR(3) NOT NULL DEFAULT 'EUR',
+    ADD COLUMN "to_amount" DECIMAL(15,2),
+    ADD COLUMN "to_currency" CHAR(3) NOT NULL DEFAULT 'EUR',
+    ADD COLUMN "fx_rate" DECIMAL(18,8);
+
+UPDATE "transaction" SET "to_amount" = "amount";
+
+ALTER TABLE "transaction"
+    ALTER COLUMN "to_amount" SET NOT NULL;
+
+CREATE TABLE IF NOT EXISTS "fx_rate" (
+  "id" SERIAL PRIMARY KEY,
+  "base" CHAR(3) NOT NULL,
+  "quote" CHAR(3) NOT NULL,
+  "rate" DECIMAL(18,8) NOT NULL CHECK ("rate" > 0),
+  "as_of" TIMESTAMP NOT NULL,
+  "source" VARCHAR NOT NULL,
+  "created_at" TIMESTAMP DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS "fx_rate_pair_as_of_idx" ON "fx_rate" ("base", "quote", "as_of" DESC);
+
+COMMIT;
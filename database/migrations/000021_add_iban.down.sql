@@ -0,0 +1,6 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE "account"
+    DROP COLUMN IF EXISTS "iban";
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+
+-- Set by the application, the accounts opened before are given one on startup
+ALTER TABLE "account"
+    ADD COLUMN "iban" VARCHAR(34) UNIQUE;
+
+COMMIT;
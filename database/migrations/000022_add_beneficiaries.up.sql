@@ -0,0 +1,24 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS "beneficiary" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" INTEGER NOT NULL,
+  "account_id" INTEGER NOT NULL,
+  "iban" VARCHAR(34) NOT NULL,
+  "nickname" VARCHAR NOT NULL,
+  "default_reference" VARCHAR,
+  -- Name of the recipient as shown to the user when adding the beneficiary
+  "masked_name" VARCHAR NOT NULL,
+  "name_match" VARCHAR NOT NULL CHECK ("name_match" IN ('match', 'close_match', 'no_match', 'not_checked')),
+  "confirmed_at" TIMESTAMP,
+  "created_at" TIMESTAMP DEFAULT (now()),
+  "updated_at" TIMESTAMP DEFAULT (now())
+);
+
+ALTER TABLE "beneficiary"
+    ADD FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    ADD FOREIGN KEY ("account_id") REFERENCES "account" ("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "beneficiary_user_id_account_id_idx" ON "beneficiary" ("user_id", "account_id");
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN TRANSACTION;
+
+DROP INDEX IF EXISTS "transaction_creditor_reference_idx";
+
+ALTER TABLE "transaction"
+    DROP COLUMN IF EXISTS "memo",
+    DROP COLUMN IF EXISTS "creditor_reference",
+    DROP COLUMN IF EXISTS "reference";
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN TRANSACTION;
+
+-- The reference and the creditor reference are shared with the recipient, the memo is only seen by the sender
+ALTER TABLE "transaction"
+    ADD COLUMN "reference" VARCHAR(140),
+    ADD COLUMN "creditor_reference" VARCHAR(25),
+    ADD COLUMN "memo" VARCHAR(500);
+
+CREATE INDEX IF NOT EXISTS "transaction_creditor_reference_idx" ON "transaction" ("creditor_reference") WHERE "creditor_reference" IS NOT NULL;
+
+COMMIT;
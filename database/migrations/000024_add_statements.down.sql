@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+
+DROP TABLE IF EXISTS "statement";
+
+DROP FUNCTION IF EXISTS "statement_immutable"();
+
+COMMIT;
@@ -0,0 +1,28 @@
+BEGIN TRANSACTION;
+
+-- The document is kept as rendered when the statement was generated, with its SHA-256 hash
+CREATE TABLE IF NOT EXISTS "statement" (
+  "id" SERIAL PRIMARY KEY,
+  "account_id" INTEGER NOT NULL,
+  "period_start" DATE NOT NULL,
+  "period_end" DATE NOT NULL CHECK ("period_end" >= "period_start"),
+  "document" TEXT NOT NULL,
+  "hash" CHAR(64) NOT NULL,
+  "created_at" TIMESTAMP DEFAULT (now())
+);
+
+ALTER TABLE "statement"
+    ADD FOREIGN KEY ("account_id") REFERENCES "account" ("id") ON DELETE RESTRICT ON UPDATE CASCADE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "statement_account_id_period_idx" ON "statement" ("account_id", "period_start", "period_end");
+
+CREATE OR REPLACE FUNCTION "statement_immutable"() RETURNS TRIGGER AS $$
+BEGIN
+  RAISE EXCEPTION 'statements are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "statement_immutable" BEFORE UPDATE ON "statement"
+    FOR EACH ROW EXECUTE PROCEDURE "statement_immutable"();
+
+COMMIT;
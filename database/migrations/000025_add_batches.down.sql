@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+
+DROP TABLE IF EXISTS "batch_line";
+
+DROP TABLE IF EXISTS "batch";
+
+COMMIT;
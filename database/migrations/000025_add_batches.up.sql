@@ -0,0 +1,47 @@
+BEGIN TRANSACTION;
+
+-- A batch is stored pending as a preview of its lines, and executed once confirmed
+CREATE TABLE IF NOT EXISTS "batch" (
+  "id" SERIAL PRIMARY KEY,
+  "account_id" INTEGER NOT NULL,
+  "format" VARCHAR NOT NULL CHECK ("format" IN ('pain001', 'csv')),
+  "mode" VARCHAR NOT NULL CHECK ("mode" IN ('all_or_nothing', 'best_effort')),
+  "status" VARCHAR NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'invalid', 'executing', 'completed', 'partially_completed', 'failed', 'cancelled')),
+  "message_id" VARCHAR(35),
+  "line_count" INTEGER NOT NULL,
+  "control_sum" DECIMAL(15,2) NOT NULL,
+  "executed_at" TIMESTAMP,
+  "created_at" TIMESTAMP DEFAULT (now()),
+  "updated_at" TIMESTAMP DEFAULT (now())
+);
+
+ALTER TABLE "batch"
+    ADD FOREIGN KEY ("account_id") REFERENCES "account" ("id") ON DELETE RESTRICT ON UPDATE CASCADE;
+
+-- A pain.001 message can't be uploaded twice
+CREATE UNIQUE INDEX IF NOT EXISTS "batch_account_id_message_id_idx" ON "batch" ("account_id", "message_id") WHERE "message_id" IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS "batch_line" (
+  "id" SERIAL PRIMARY KEY,
+  "batch_id" INTEGER NOT NULL,
+  "line_number" INTEGER NOT NULL,
+  "to_iban" VARCHAR NOT NULL,
+  "to_id" INTEGER,
+  "amount" DECIMAL(15,2) NOT NULL,
+  "reference" VARCHAR(140),
+  "creditor_reference" VARCHAR(25),
+  "end_to_end_id" VARCHAR(35),
+  "status" VARCHAR NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'invalid', 'executed', 'failed')),
+  "error" VARCHAR,
+  "transaction_id" INTEGER,
+  "updated_at" TIMESTAMP DEFAULT (now())
+);
+
+ALTER TABLE "batch_line"
+    ADD FOREIGN KEY ("batch_id") REFERENCES "batch" ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    ADD FOREIGN KEY ("to_id") REFERENCES "account" ("id") ON DELETE RESTRICT ON UPDATE CASCADE,
+    ADD FOREIGN KEY ("transaction_id") REFERENCES "transaction" ("id") ON DELETE RESTRICT ON UPDATE CASCADE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "batch_line_batch_id_line_number_idx" ON "batch_line" ("batch_id", "line_number");
+
+COMMIT;
@@ -0,0 +1,32 @@
+BEGIN TRANSACTION;
+
+-- A request from an account to another one to be paid, closed once accepted, declined, cancelled or expired
+CREATE TABLE IF NOT EXISTS "payment_request" (
+  "id" SERIAL PRIMARY KEY,
+  "requester_id" INTEGER NOT NULL,
+  "payer_id" INTEGER NOT NULL CHECK ("payer_id" <> "requester_id"),
+  "amount" DECIMAL(15,2) NOT NULL CHECK ("amount" > 0),
+  "reason" VARCHAR(140),
+  "status" VARCHAR NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'accepted', 'declined', 'cancelled', 'expired')),
+  "idempotency_key" VARCHAR(255),
+  "transaction_id" INTEGER,
+  "expires_at" TIMESTAMP NOT NULL,
+  "created_at" TIMESTAMP DEFAULT (now()),
+  "updated_at" TIMESTAMP DEFAULT (now())
+);
+
+ALTER TABLE "payment_request"
+    ADD FOREIGN KEY ("requester_id") REFERENCES "account" ("id") ON DELETE RESTRICT ON UPDATE CASCADE,
+    ADD FOREIGN KEY ("payer_id") REFERENCES "account" ("id") ON DELETE RESTRICT ON UPDATE CASCADE,
+    ADD FOREIGN KEY ("transaction_id") REFERENCES "transaction" ("id") ON DELETE RESTRICT ON UPDATE CASCADE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "payment_request_requester_id_idempotency_key_idx" ON "payment_request" ("requester_id", "idempotency_key") WHERE "idempotency_key" IS NOT NULL;
+
+-- The inbox of a payer and the expiry of pending requests
+CREATE INDEX IF NOT EXISTS "payment_request_payer_id_idx" ON "payment_request" ("payer_id") WHERE "status" = 'pending';
+CREATE INDEX IF NOT EXISTS "payment_request_expires_at_idx" ON "payment_request" ("expires_at") WHERE "status" = 'pending';
+
+-- A request is paid by a single transaction
+CREATE UNIQUE INDEX IF NOT EXISTS "payment_request_transaction_id_idx" ON "payment_request" ("transaction_id");
+
+COMMIT;
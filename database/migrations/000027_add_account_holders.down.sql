@@ -0,0 +1,6 @@
+BEGIN TRANSACTION;
+
+DROP TABLE IF EXISTS "account_holder_change";
+DROP TABLE IF EXISTS "account_holder";
+
+COMMIT;
@@ -0,0 +1,48 @@
+BEGIN TRANSACTION;
+
+-- The users holding an account and their role on it, the owner of the account being its primary holder
+CREATE TABLE IF NOT EXISTS "account_holder" (
+  "account_id" INTEGER NOT NULL,
+  "user_id" INTEGER NOT NULL,
+  "role" VARCHAR NOT NULL CHECK ("role" IN ('primary', 'joint', 'authorised_signatory', 'view_only')),
+  "created_at" TIMESTAMP DEFAULT (now()),
+  "updated_at" TIMESTAMP DEFAULT (now()),
+  PRIMARY KEY ("account_id", "user_id")
+);
+
+ALTER TABLE "account_holder"
+    ADD FOREIGN KEY ("account_id") REFERENCES "account" ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    ADD FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- An account has a single primary holder
+CREATE UNIQUE INDEX IF NOT EXISTS "account_holder_primary_idx" ON "account_holder" ("account_id") WHERE "role" = 'primary';
+CREATE INDEX IF NOT EXISTS "account_holder_user_id_idx" ON "account_holder" ("user_id");
+
+INSERT INTO "account_holder" ("account_id", "user_id", "role") SELECT "id", "user_id", 'primary' FROM "account";
+
+-- A holder added to or removed from an account, applied once the primary holder consents to it
+CREATE TABLE IF NOT EXISTS "account_holder_change" (
+  "id" SERIAL PRIMARY KEY,
+  "account_id" INTEGER NOT NULL,
+  "user_id" INTEGER NOT NULL,
+  "action" VARCHAR NOT NULL CHECK ("action" IN ('add', 'remove')),
+  "role" VARCHAR CHECK ("role" IN ('joint', 'authorised_signatory', 'view_only')),
+  "status" VARCHAR NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'approved', 'rejected')),
+  "requested_by" INTEGER NOT NULL,
+  "decided_by" INTEGER,
+  "decided_at" TIMESTAMP,
+  "created_at" TIMESTAMP DEFAULT (now()),
+  "updated_at" TIMESTAMP DEFAULT (now()),
+  CHECK (("action" = 'add') = ("role" IS NOT NULL))
+);
+
+ALTER TABLE "account_holder_change"
+    ADD FOREIGN KEY ("account_id") REFERENCES "account" ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    ADD FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    ADD FOREIGN KEY ("requested_by") REFERENCES "user" ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    ADD FOREIGN KEY ("decided_by") REFERENCES "user" ("id") ON DELETE SET NULL ON UPDATE CASCADE;
+
+-- A single change of a holder awaits consent at a time
+CREATE UNIQUE INDEX IF NOT EXISTS "account_holder_change_pending_idx" ON "account_holder_change" ("account_id", "user_id") WHERE "status" = 'pending';
+
+COMMIT;
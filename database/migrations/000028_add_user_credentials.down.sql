@@ -0,0 +1,13 @@
+BEGIN TRANSACTION;
+
+DELETE FROM "session_token" WHERE "account_id" IS NULL;
+
+DROP INDEX IF EXISTS "session_token_user_id_idx";
+
+ALTER TABLE "session_token"
+    DROP COLUMN IF EXISTS "user_id",
+    ALTER COLUMN "account_id" SET NOT NULL;
+
+ALTER TABLE "user" DROP COLUMN IF EXISTS "password";
+
+COMMIT;
@@ -0,0 +1,23 @@
+BEGIN TRANSACTION;
+
+-- Users log in with their email and password, the passwords of the accounts are only kept for the legacy login
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS "password" VARCHAR;
+
+-- The password of a user is the one of its oldest account
+UPDATE "user" AS u SET "password" = a."password"
+    FROM (SELECT DISTINCT ON ("user_id") "user_id", "password" FROM "account" WHERE "password" <> '' ORDER BY "user_id", "id") AS a
+    WHERE a."user_id" = u."id" AND u."deleted_at" IS NULL;
+
+-- Sessions are bound to a user, the account of a session being the one it acts on unless told otherwise
+ALTER TABLE "session_token" ADD COLUMN IF NOT EXISTS "user_id" INTEGER;
+
+UPDATE "session_token" AS s SET "user_id" = a."user_id" FROM "account" AS a WHERE a."id" = s."account_id";
+
+ALTER TABLE "session_token"
+    ALTER COLUMN "user_id" SET NOT NULL,
+    ALTER COLUMN "account_id" DROP NOT NULL,
+    ADD FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+CREATE INDEX IF NOT EXISTS "session_token_user_id_idx" ON "session_token" ("user_id");
+
+COMMIT;
@@ -1,8 +1,32 @@
 package dto
 
+import "time"
+
 type CreateAccountDTO struct {
-	UserID   uint   `json:"user_id" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	// The user of the principal when unset
+	UserID uint `json:"user_id"`
+	// Only needed by the legacy login with the account number
+	Password     string     `json:"password"`
+	Type         string     `json:"type"`
+	MaturityDate *time.Time `json:"maturity_date"`
+	Currency     string     `json:"currency"`
+	// Generated when the account is opened
+	IBAN string `json:"-"`
 }
 
 type UpdateAccountDTO CreateAccountDTO
+
+type ChangeAccountStatusDTO struct {
+	Status string `json:"status" binding:"required"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+type SetOverdraftDTO struct {
+	Limit float64 `json:"limit" binding:"required"`
+}
+
+type SetTransferLimitsDTO struct {
+	MaxAmount   *float64 `json:"max_amount"`
+	DailyAmount *float64 `json:"daily_amount"`
+	DailyCount  *int     `json:"daily_count"`
+}
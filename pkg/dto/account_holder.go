@@ -0,0 +1,7 @@
+package dto
+
+type AddAccountHolderDTO struct {
+	// Email of the user to add
+	Email string `json:"email" binding:"required"`
+	Role  string `json:"role" binding:"required"`
+}
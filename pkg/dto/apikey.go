@@ -0,0 +1,10 @@
+package dto
+
+import "time"
+
+type CreateApiKeyDTO struct {
+	Name       string     `json:"name" binding:"required"`
+	Scopes     []string   `json:"scopes" binding:"required"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	AllowedIPs []string   `json:"allowed_ips"`
+}
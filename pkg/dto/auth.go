@@ -1,6 +1,9 @@
 package dto
 
 type LoginDTO struct {
-	AccountNumber uint   `json:"account_number" binding:"required"`
+	Email string `json:"email"`
+	// IBAN of the account the session acts on by default. Without an email, logs in
+	// with the password of the account, the legacy login kept for compatibility.
+	AccountNumber string `json:"account_number"`
 	Password      string `json:"password" binding:"required"`
 }
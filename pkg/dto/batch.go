@@ -0,0 +1,28 @@
+package dto
+
+/*
+CreateBatchDTO is a batch of transfers as read from an uploaded pain.001 or CSV file.
+Amounts are kept as written in the file, to be validated line by line.
+*/
+type CreateBatchDTO struct {
+	Format string
+	Mode   string
+	// MsgId of a pain.001 message, unique per account
+	MessageID string
+	// IBAN of the account to debit, when given by the file
+	DebtorIBAN string
+	// Number of transfers and sum of their amounts declared by the file, when given
+	TransactionCount *int
+	ControlSum       *string
+	Lines            []BatchLineDTO
+}
+
+type BatchLineDTO struct {
+	// IBAN of the recipient
+	To                string
+	Amount            string
+	Currency          string
+	Reference         string
+	CreditorReference string
+	EndToEndID        string
+}
@@ -0,0 +1,14 @@
+package dto
+
+type CreateBeneficiaryDTO struct {
+	Nickname string `json:"nickname" binding:"required"`
+	IBAN     string `json:"iban" binding:"required"`
+	// Name of the recipient as known by the user, checked against the real one
+	Name             string  `json:"name"`
+	DefaultReference *string `json:"default_reference"`
+}
+
+type UpdateBeneficiaryDTO struct {
+	Nickname         *string `json:"nickname"`
+	DefaultReference *string `json:"default_reference"`
+}
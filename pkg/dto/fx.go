@@ -0,0 +1,11 @@
+package dto
+
+import "time"
+
+type SetFxRateDTO struct {
+	Base  string  `json:"base" binding:"required"`
+	Quote string  `json:"quote" binding:"required"`
+	Rate  float64 `json:"rate" binding:"required"`
+	// Unset means now
+	AsOf *time.Time `json:"as_of"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+type CreateHoldDTO struct {
+	// IBAN of the recipient
+	To     string  `json:"to" binding:"required"`
+	Amount float64 `json:"amount" binding:"required"`
+}
+
+type CaptureHoldDTO struct {
+	// Unset captures the whole hold, a smaller amount releases the rest
+	Amount *float64 `json:"amount"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+import "time"
+
+type CreatePaymentRequestDTO struct {
+	// IBAN of the account asked to pay
+	Payer  string  `json:"payer" binding:"required"`
+	Amount float64 `json:"amount" binding:"required"`
+	Reason string  `json:"reason"`
+	// Unset expires the request after PAYMENT_REQUEST_TTL
+	ExpiresAt *time.Time `json:"expires_at"`
+	// Set from the Idempotency-Key header
+	IdempotencyKey string `json:"-"`
+}
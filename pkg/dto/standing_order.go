@@ -0,0 +1,18 @@
+package dto
+
+import "time"
+
+type CreateStandingOrderDTO struct {
+	// IBAN of the recipient
+	To         string     `json:"to" binding:"required"`
+	Amount     float64    `json:"amount" binding:"required"`
+	Frequency  string     `json:"frequency" binding:"required"`
+	DayOfMonth *int       `json:"day_of_month"`
+	StartDate  time.Time  `json:"start_date" binding:"required"`
+	EndDate    *time.Time `json:"end_date"`
+}
+
+type UpdateStandingOrderDTO struct {
+	Amount  *float64   `json:"amount"`
+	EndDate *time.Time `json:"end_date"`
+}
@@ -0,0 +1,9 @@
+package dto
+
+import "time"
+
+type CreateStatementDTO struct {
+	// First and last days of the period, both included
+	PeriodStart time.Time `json:"period_start" binding:"required"`
+	PeriodEnd   time.Time `json:"period_end" binding:"required"`
+}
@@ -1,6 +1,39 @@
 package dto
 
+import "time"
+
 type CreateTransactionDTO struct {
-	To     uint    `json:"to" binding:"required"`
-	Amount float64 `json:"amount" binding:"required"`
+	// IBAN of the account sending the money, the account of the session when unset
+	From string `json:"from"`
+	// IBAN of the recipient, or one of the sender's beneficiaries
+	To            string  `json:"to"`
+	BeneficiaryID *uint   `json:"beneficiary_id"`
+	Amount        float64 `json:"amount" binding:"required"`
+	// Free text shared with the recipient, the default reference of the beneficiary when unset
+	Reference string `json:"reference"`
+	// ISO 11649 structured creditor reference (RF) of the invoice paid
+	CreditorReference string `json:"creditor_reference"`
+	// Only seen by the sender
+	Memo string `json:"memo"`
+	// Resolved from To, or set directly when the transfer is made by the bank
+	ToAccountID uint `json:"-"`
+	// Set from the Idempotency-Key header
+	IdempotencyKey string `json:"-"`
+}
+
+type ReverseTransactionDTO struct {
+	// Unset reverses whatever is left of the transaction
+	Amount *float64 `json:"amount"`
+	// Lets the reversal take the recipient into overdraft, beyond its overdraft limit if needed
+	Force bool `json:"force"`
+}
+
+type SearchTransactionsDTO struct {
+	// Matched against the references, and against the memo of the transactions sent
+	Query             string
+	CreditorReference string
+	Since             *time.Time
+	Until             *time.Time
+	Offset            uint
+	Limit             uint
 }
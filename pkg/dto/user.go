@@ -4,4 +4,26 @@ type CreateUserDTO struct {
 	FirstName string `json:"first_name" binding:"required"`
 	LastName  string `json:"last_name" binding:"required"`
 	Email     string `json:"email" binding:"required"`
+	// Left out of the user echoed back once created
+	Password string `json:"password,omitempty" binding:"required"`
+}
+
+type UpdateUserDTO struct {
+	FirstName *string `json:"first_name"`
+	LastName  *string `json:"last_name"`
+	Email     *string `json:"email"`
+}
+
+type VerifyEmailDTO struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ResendVerificationDTO struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type SetPasswordDTO struct {
+	// Required once the user has a password
+	CurrentPassword string `json:"current_password"`
+	Password        string `json:"password" binding:"required"`
 }
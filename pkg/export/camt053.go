@@ -0,0 +1,252 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+
+	"github.com/farischt/gobank/pkg/types"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.08"
+
+// ISO 20022 camt.053.001.08 elements, in the order of the schema, only those filled are declared
+
+type camtDocument struct {
+	XMLName       xml.Name          `xml:"Document"`
+	Xmlns         string            `xml:"xmlns,attr"`
+	BkToCstmrStmt camtBkToCstmrStmt `xml:"BkToCstmrStmt"`
+}
+
+type camtBkToCstmrStmt struct {
+	GrpHdr camtGrpHdr `xml:"GrpHdr"`
+	Stmt   camtStmt   `xml:"Stmt"`
+}
+
+type camtGrpHdr struct {
+	MsgId    string `xml:"MsgId"`
+	CreDtTm  string `xml:"CreDtTm"`
+	AddtlInf string `xml:"AddtlInf,omitempty"`
+}
+
+type camtStmt struct {
+	Id        string        `xml:"Id"`
+	CreDtTm   string        `xml:"CreDtTm"`
+	FrToDt    camtFrToDt    `xml:"FrToDt"`
+	Acct      camtAcct      `xml:"Acct"`
+	Bal       []camtBal     `xml:"Bal"`
+	TxsSummry camtTxsSummry `xml:"TxsSummry"`
+	Ntry      []camtNtry    `xml:"Ntry"`
+}
+
+type camtFrToDt struct {
+	FrDtTm string `xml:"FrDtTm"`
+	ToDtTm string `xml:"ToDtTm"`
+}
+
+type camtAcct struct {
+	IBAN string `xml:"Id>IBAN"`
+	Ccy  string `xml:"Ccy"`
+}
+
+type camtAmt struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type camtBal struct {
+	Cd        string  `xml:"Tp>CdOrPrtry>Cd"`
+	Amt       camtAmt `xml:"Amt"`
+	CdtDbtInd string  `xml:"CdtDbtInd"`
+	Dt        string  `xml:"Dt>Dt"`
+}
+
+type camtTxsSummry struct {
+	TtlNtries    camtTtlNtries `xml:"TtlNtries"`
+	TtlCdtNtries camtNbAndSum  `xml:"TtlCdtNtries"`
+	TtlDbtNtries camtNbAndSum  `xml:"TtlDbtNtries"`
+}
+
+type camtTtlNtries struct {
+	NbOfNtries string         `xml:"NbOfNtries"`
+	Sum        string         `xml:"Sum"`
+	TtlNetNtry camtTtlNetNtry `xml:"TtlNetNtry"`
+}
+
+type camtTtlNetNtry struct {
+	Amt       string `xml:"Amt"`
+	CdtDbtInd string `xml:"CdtDbtInd"`
+}
+
+type camtNbAndSum struct {
+	NbOfNtries string `xml:"NbOfNtries"`
+	Sum        string `xml:"Sum"`
+}
+
+type camtNtry struct {
+	NtryRef   string     `xml:"NtryRef"`
+	Amt       camtAmt    `xml:"Amt"`
+	CdtDbtInd string     `xml:"CdtDbtInd"`
+	RvslInd   bool       `xml:"RvslInd,omitempty"`
+	Sts       string     `xml:"Sts>Cd"`
+	BookgDt   string     `xml:"BookgDt>DtTm"`
+	ValDt     string     `xml:"ValDt>Dt"`
+	BkTxCd    camtBkTxCd `xml:"BkTxCd"`
+	TxDtls    camtTxDtls `xml:"NtryDtls>TxDtls"`
+}
+
+type camtBkTxCd struct {
+	Cd   string `xml:"Prtry>Cd"`
+	Issr string `xml:"Prtry>Issr"`
+}
+
+type camtTxDtls struct {
+	AcctSvcrRef string         `xml:"Refs>AcctSvcrRef"`
+	Amt         camtAmt        `xml:"Amt"`
+	CdtDbtInd   string         `xml:"CdtDbtInd"`
+	RltdPties   *camtRltdPties `xml:"RltdPties,omitempty"`
+	RmtInf      *camtRmtInf    `xml:"RmtInf,omitempty"`
+}
+
+type camtRltdPties struct {
+	DbtrAcct *camtPtyAcct `xml:"DbtrAcct,omitempty"`
+	CdtrAcct *camtPtyAcct `xml:"CdtrAcct,omitempty"`
+}
+
+type camtPtyAcct struct {
+	IBAN string `xml:"Id>IBAN"`
+}
+
+type camtRmtInf struct {
+	Ustrd string       `xml:"Ustrd,omitempty"`
+	Strd  *camtRmtStrd `xml:"Strd,omitempty"`
+}
+
+type camtRmtStrd struct {
+	Cd   string `xml:"CdtrRefInf>Tp>CdOrPrtry>Cd"`
+	Issr string `xml:"CdtrRefInf>Tp>Issr"`
+	Ref  string `xml:"CdtrRefInf>Ref"`
+}
+
+/*
+Camt053 renders a statement as an ISO 20022 camt.053.001.08 bank to customer statement.
+Every transaction is a booked entry, the counterparty being identified by its IBAN only.
+*/
+func Camt053(doc *types.StatementDocument) ([]byte, error) {
+	id := fmt.Sprintf("%d-%s-%s", doc.AccountID, doc.PeriodStart.Format("20060102"), doc.PeriodEnd.Format("20060102"))
+	created := doc.GeneratedAt.Format("2006-01-02T15:04:05Z")
+
+	stmt := camtStmt{
+		Id:      id,
+		CreDtTm: created,
+		FrToDt: camtFrToDt{
+			FrDtTm: doc.PeriodStart.Format("2006-01-02") + "T00:00:00Z",
+			ToDtTm: doc.PeriodEnd.Format("2006-01-02") + "T23:59:59Z",
+		},
+		Acct: camtAcct{IBAN: doc.IBAN, Ccy: doc.Currency},
+		Bal: []camtBal{
+			camtBalance("OPBD", doc.OpeningBalance, doc.Currency, doc.PeriodStart.Format("2006-01-02")),
+			camtBalance("CLBD", doc.ClosingBalance, doc.Currency, doc.PeriodEnd.Format("2006-01-02")),
+		},
+	}
+
+	var credits, debits int
+	for _, l := range doc.Lines {
+		indicator := creditDebit(l.Amount)
+		if l.Amount < 0 {
+			debits++
+		} else {
+			credits++
+		}
+
+		amt := camtAmt{Ccy: doc.Currency, Value: amount(math.Abs(l.Amount))}
+		ref := fmt.Sprintf("%d", l.TransactionID)
+
+		ntry := camtNtry{
+			NtryRef:   ref,
+			Amt:       amt,
+			CdtDbtInd: indicator,
+			RvslInd:   l.Type == types.TransactionReversal,
+			Sts:       "BOOK",
+			BookgDt:   l.Date.UTC().Format("2006-01-02T15:04:05Z"),
+			ValDt:     l.Date.UTC().Format("2006-01-02"),
+			BkTxCd:    camtBkTxCd{Cd: string(l.Type), Issr: "GOBANK"},
+			TxDtls: camtTxDtls{
+				AcctSvcrRef: ref,
+				Amt:         amt,
+				CdtDbtInd:   indicator,
+			},
+		}
+
+		if l.Counterparty != "" {
+			// The account of the other party, the debtor of a credit and the creditor of a debit
+			acct := &camtPtyAcct{IBAN: l.Counterparty}
+			if l.Amount < 0 {
+				ntry.TxDtls.RltdPties = &camtRltdPties{CdtrAcct: acct}
+			} else {
+				ntry.TxDtls.RltdPties = &camtRltdPties{DbtrAcct: acct}
+			}
+		}
+
+		if l.Reference != "" || l.CreditorReference != "" {
+			ntry.TxDtls.RmtInf = &camtRmtInf{Ustrd: l.Reference}
+			if l.CreditorReference != "" {
+				ntry.TxDtls.RmtInf.Strd = &camtRmtStrd{Cd: "SCOR", Issr: "ISO", Ref: l.CreditorReference}
+			}
+		}
+
+		stmt.Ntry = append(stmt.Ntry, ntry)
+	}
+
+	net := doc.TotalIn - doc.TotalOut
+	stmt.TxsSummry = camtTxsSummry{
+		TtlNtries: camtTtlNtries{
+			NbOfNtries: fmt.Sprintf("%d", len(doc.Lines)),
+			Sum:        amount(doc.TotalIn + doc.TotalOut),
+			TtlNetNtry: camtTtlNetNtry{Amt: amount(math.Abs(net)), CdtDbtInd: creditDebit(net)},
+		},
+		TtlCdtNtries: camtNbAndSum{NbOfNtries: fmt.Sprintf("%d", credits), Sum: amount(doc.TotalIn)},
+		TtlDbtNtries: camtNbAndSum{NbOfNtries: fmt.Sprintf("%d", debits), Sum: amount(doc.TotalOut)},
+	}
+
+	document := camtDocument{
+		Xmlns: camt053Namespace,
+		BkToCstmrStmt: camtBkToCstmrStmt{
+			GrpHdr: camtGrpHdr{MsgId: id, CreDtTm: created},
+			Stmt:   stmt,
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString(xml.Header)
+
+	enc := xml.NewEncoder(buf)
+	enc.Indent("", "  ")
+	err := enc.Encode(document)
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+
+	return buf.Bytes(), nil
+}
+
+func camtBalance(code string, balance float64, currency string, date string) camtBal {
+	return camtBal{
+		Cd:        code,
+		Amt:       camtAmt{Ccy: currency, Value: amount(math.Abs(balance))},
+		CdtDbtInd: creditDebit(balance),
+		Dt:        date,
+	}
+}
+
+/*
+creditDebit gives the ISO 20022 credit or debit indicator of a signed amount.
+*/
+func creditDebit(v float64) string {
+	if v < 0 {
+		return "DBIT"
+	}
+	return "CRDT"
+}
@@ -0,0 +1,327 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/farischt/gobank/pkg/types"
+)
+
+/*
+testStatement returns a statement with a credit, a debit, a fee and a reversal,
+the reference of the credit being as long as a reference can be.
+*/
+func testStatement() *types.StatementDocument {
+	start := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC)
+
+	return &types.StatementDocument{
+		AccountID:      7,
+		IBAN:           "FR7630006000011234567890189",
+		Currency:       "EUR",
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		OpeningBalance: 100,
+		TotalIn:        60,
+		TotalOut:       32.5,
+		Fees:           2.5,
+		ClosingBalance: 127.5,
+		GeneratedAt:    time.Date(2024, time.April, 1, 6, 0, 0, 0, time.UTC),
+		Lines: []types.StatementLine{
+			{
+				TransactionID:     11,
+				Date:              start.Add(26 * time.Hour),
+				Type:              types.TransactionTransfer,
+				Counterparty:      "DE89370400440532013000",
+				Reference:         ("Invoice 2024/03: " + strings.Repeat("consulting services rendered ", 5))[:140],
+				CreditorReference: "RF18539007547034",
+				Amount:            50,
+				Balance:           150,
+			},
+			{
+				TransactionID: 12,
+				Date:          start.Add(50 * time.Hour),
+				Type:          types.TransactionTransfer,
+				Counterparty:  "DE89370400440532013000",
+				Reference:     "Rent",
+				Amount:        -30,
+				Balance:       120,
+			},
+			{
+				TransactionID: 13,
+				Date:          start.Add(74 * time.Hour),
+				Type:          types.TransactionFee,
+				Amount:        -2.5,
+				Balance:       117.5,
+			},
+			{
+				TransactionID: 14,
+				Date:          start.Add(98 * time.Hour),
+				Type:          types.TransactionReversal,
+				Counterparty:  "DE89370400440532013000",
+				Amount:        10,
+				Balance:       127.5,
+			},
+		},
+	}
+}
+
+/*
+xmlChildren returns the names of the direct children of every element at the given path,
+in document order, one slice per element.
+*/
+func xmlChildren(t *testing.T, data []byte, path ...string) [][]string {
+	t.Helper()
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	stack := []string{}
+	found := [][]string{}
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			if reflect.DeepEqual(stack, path) {
+				found[len(found)-1] = append(found[len(found)-1], el.Name.Local)
+			}
+			stack = append(stack, el.Name.Local)
+			if reflect.DeepEqual(stack, path) {
+				found = append(found, []string{})
+			}
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	return found
+}
+
+type testCamtAmt struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type testCamtDocument struct {
+	XMLName xml.Name `xml:"urn:iso:std:iso:20022:tech:xsd:camt.053.001.08 Document"`
+	GrpHdr  struct {
+		MsgId   string `xml:"MsgId"`
+		CreDtTm string `xml:"CreDtTm"`
+	} `xml:"BkToCstmrStmt>GrpHdr"`
+	Stmt struct {
+		Id   string `xml:"Id"`
+		Acct struct {
+			IBAN string `xml:"Id>IBAN"`
+			Ccy  string `xml:"Ccy"`
+		} `xml:"Acct"`
+		Bal []struct {
+			Cd        string      `xml:"Tp>CdOrPrtry>Cd"`
+			Amt       testCamtAmt `xml:"Amt"`
+			CdtDbtInd string      `xml:"CdtDbtInd"`
+			Dt        string      `xml:"Dt>Dt"`
+		} `xml:"Bal"`
+		TxsSummry struct {
+			NbOfNtries    int    `xml:"TtlNtries>NbOfNtries"`
+			Sum           string `xml:"TtlNtries>Sum"`
+			NetAmt        string `xml:"TtlNtries>TtlNetNtry>Amt"`
+			NetCdtDbtInd  string `xml:"TtlNtries>TtlNetNtry>CdtDbtInd"`
+			CdtNbOfNtries int    `xml:"TtlCdtNtries>NbOfNtries"`
+			CdtSum        string `xml:"TtlCdtNtries>Sum"`
+			DbtNbOfNtries int    `xml:"TtlDbtNtries>NbOfNtries"`
+			DbtSum        string `xml:"TtlDbtNtries>Sum"`
+		} `xml:"TxsSummry"`
+		Ntry []struct {
+			NtryRef   string      `xml:"NtryRef"`
+			Amt       testCamtAmt `xml:"Amt"`
+			CdtDbtInd string      `xml:"CdtDbtInd"`
+			RvslInd   bool        `xml:"RvslInd"`
+			Sts       string      `xml:"Sts>Cd"`
+		} `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+func TestCamt053ElementOrder(t *testing.T) {
+	data, err := Camt053(testStatement())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path []string
+		want []string
+	}{
+		{[]string{"Document"}, []string{"BkToCstmrStmt"}},
+		{[]string{"Document", "BkToCstmrStmt"}, []string{"GrpHdr", "Stmt"}},
+		{[]string{"Document", "BkToCstmrStmt", "GrpHdr"}, []string{"MsgId", "CreDtTm"}},
+		{
+			[]string{"Document", "BkToCstmrStmt", "Stmt"},
+			[]string{"Id", "CreDtTm", "FrToDt", "Acct", "Bal", "Bal", "TxsSummry", "Ntry", "Ntry", "Ntry", "Ntry"},
+		},
+		{[]string{"Document", "BkToCstmrStmt", "Stmt", "FrToDt"}, []string{"FrDtTm", "ToDtTm"}},
+		{[]string{"Document", "BkToCstmrStmt", "Stmt", "Acct"}, []string{"Id", "Ccy"}},
+		{[]string{"Document", "BkToCstmrStmt", "Stmt", "Bal"}, []string{"Tp", "Amt", "CdtDbtInd", "Dt"}},
+		{[]string{"Document", "BkToCstmrStmt", "Stmt", "TxsSummry"}, []string{"TtlNtries", "TtlCdtNtries", "TtlDbtNtries"}},
+		{[]string{"Document", "BkToCstmrStmt", "Stmt", "TxsSummry", "TtlNtries"}, []string{"NbOfNtries", "Sum", "TtlNetNtry"}},
+		{[]string{"Document", "BkToCstmrStmt", "Stmt", "TxsSummry", "TtlNtries", "TtlNetNtry"}, []string{"Amt", "CdtDbtInd"}},
+		{[]string{"Document", "BkToCstmrStmt", "Stmt", "Ntry", "BkTxCd", "Prtry"}, []string{"Cd", "Issr"}},
+		{[]string{"Document", "BkToCstmrStmt", "Stmt", "Ntry", "NtryDtls"}, []string{"TxDtls"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.path, "/"), func(t *testing.T) {
+			found := xmlChildren(t, data, tt.path...)
+			if len(found) == 0 {
+				t.Fatalf("no %s element", strings.Join(tt.path, "/"))
+			}
+			for _, got := range found {
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("children = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	// Optional elements of an entry and of its details keep the order of the schema
+	entries := xmlChildren(t, data, "Document", "BkToCstmrStmt", "Stmt", "Ntry")
+	wantEntries := [][]string{
+		{"NtryRef", "Amt", "CdtDbtInd", "Sts", "BookgDt", "ValDt", "BkTxCd", "NtryDtls"},
+		{"NtryRef", "Amt", "CdtDbtInd", "Sts", "BookgDt", "ValDt", "BkTxCd", "NtryDtls"},
+		{"NtryRef", "Amt", "CdtDbtInd", "Sts", "BookgDt", "ValDt", "BkTxCd", "NtryDtls"},
+		{"NtryRef", "Amt", "CdtDbtInd", "RvslInd", "Sts", "BookgDt", "ValDt", "BkTxCd", "NtryDtls"},
+	}
+	if !reflect.DeepEqual(entries, wantEntries) {
+		t.Errorf("entries = %v, want %v", entries, wantEntries)
+	}
+
+	details := xmlChildren(t, data, "Document", "BkToCstmrStmt", "Stmt", "Ntry", "NtryDtls", "TxDtls")
+	wantDetails := [][]string{
+		{"Refs", "Amt", "CdtDbtInd", "RltdPties", "RmtInf"},
+		{"Refs", "Amt", "CdtDbtInd", "RltdPties", "RmtInf"},
+		{"Refs", "Amt", "CdtDbtInd"},
+		{"Refs", "Amt", "CdtDbtInd", "RltdPties"},
+	}
+	if !reflect.DeepEqual(details, wantDetails) {
+		t.Errorf("transaction details = %v, want %v", details, wantDetails)
+	}
+
+	remittance := xmlChildren(t, data, "Document", "BkToCstmrStmt", "Stmt", "Ntry", "NtryDtls", "TxDtls", "RmtInf")
+	wantRemittance := [][]string{{"Ustrd", "Strd"}, {"Ustrd"}}
+	if !reflect.DeepEqual(remittance, wantRemittance) {
+		t.Errorf("remittance information = %v, want %v", remittance, wantRemittance)
+	}
+}
+
+func TestCamt053Totals(t *testing.T) {
+	doc := testStatement()
+	data, err := Camt053(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	camt := new(testCamtDocument)
+	if err := xml.Unmarshal(data, camt); err != nil {
+		t.Fatal(err)
+	}
+
+	if camt.GrpHdr.MsgId == "" || camt.GrpHdr.CreDtTm == "" {
+		t.Error("group header without message id or creation time")
+	} else if camt.Stmt.Id != camt.GrpHdr.MsgId {
+		t.Errorf("statement id %q, want the message id %q", camt.Stmt.Id, camt.GrpHdr.MsgId)
+	}
+
+	if camt.Stmt.Acct.IBAN != doc.IBAN || camt.Stmt.Acct.Ccy != doc.Currency {
+		t.Errorf("account %s %s, want %s %s", camt.Stmt.Acct.IBAN, camt.Stmt.Acct.Ccy, doc.IBAN, doc.Currency)
+	}
+
+	if len(camt.Stmt.Bal) != 2 {
+		t.Fatalf("%d balances, want the opening and the closing balance", len(camt.Stmt.Bal))
+	}
+	opening, closing := camt.Stmt.Bal[0], camt.Stmt.Bal[1]
+	if opening.Cd != "OPBD" || opening.Amt.Value != "100.00" || opening.CdtDbtInd != "CRDT" || opening.Dt != "2024-03-01" {
+		t.Errorf("opening balance = %+v", opening)
+	}
+	if closing.Cd != "CLBD" || closing.Amt.Value != "127.50" || closing.CdtDbtInd != "CRDT" || closing.Dt != "2024-03-31" {
+		t.Errorf("closing balance = %+v", closing)
+	}
+
+	// The entries must take the opening balance to the closing balance
+	balance := signed(t, opening.Amt.Value, opening.CdtDbtInd)
+	var credits, debits float64
+	for _, n := range camt.Stmt.Ntry {
+		if n.Amt.Ccy != doc.Currency {
+			t.Errorf("entry %s in %s, want %s", n.NtryRef, n.Amt.Ccy, doc.Currency)
+		} else if n.Sts != "BOOK" {
+			t.Errorf("entry %s with status %s, want BOOK", n.NtryRef, n.Sts)
+		}
+
+		v := signed(t, n.Amt.Value, n.CdtDbtInd)
+		balance += v
+		if v < 0 {
+			debits -= v
+		} else {
+			credits += v
+		}
+	}
+	if want := signed(t, closing.Amt.Value, closing.CdtDbtInd); !equalCents(balance, want) {
+		t.Errorf("entries add up to a closing balance of %.2f, want %.2f", balance, want)
+	}
+
+	summary := camt.Stmt.TxsSummry
+	if summary.NbOfNtries != len(doc.Lines) || summary.CdtNbOfNtries != 2 || summary.DbtNbOfNtries != 2 {
+		t.Errorf("summary counts %d entries, %d credits and %d debits, want 4, 2 and 2",
+			summary.NbOfNtries, summary.CdtNbOfNtries, summary.DbtNbOfNtries)
+	}
+	if !equalCents(parseAmount(t, summary.CdtSum), credits) || !equalCents(parseAmount(t, summary.DbtSum), debits) {
+		t.Errorf("summary sums %s credits and %s debits, want %.2f and %.2f", summary.CdtSum, summary.DbtSum, credits, debits)
+	}
+	if !equalCents(parseAmount(t, summary.Sum), credits+debits) {
+		t.Errorf("summary sum = %s, want %.2f", summary.Sum, credits+debits)
+	}
+	if net := signed(t, summary.NetAmt, summary.NetCdtDbtInd); !equalCents(net, credits-debits) {
+		t.Errorf("summary net = %.2f, want %.2f", net, credits-debits)
+	}
+
+	if n := camt.Stmt.Ntry[3]; !n.RvslInd {
+		t.Errorf("reversal entry %s without reversal indicator", n.NtryRef)
+	}
+}
+
+func signed(t *testing.T, value string, indicator string) float64 {
+	t.Helper()
+
+	v := parseAmount(t, value)
+	switch indicator {
+	case "CRDT":
+		return v
+	case "DBIT":
+		return -v
+	default:
+		t.Fatalf("invalid credit or debit indicator %q", indicator)
+		return 0
+	}
+}
+
+func parseAmount(t *testing.T, value string) float64 {
+	t.Helper()
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		t.Fatalf("invalid amount %q", value)
+	}
+	return v
+}
+
+func equalCents(a float64, b float64) bool {
+	return strconv.FormatFloat(a, 'f', 2, 64) == strconv.FormatFloat(b, 'f', 2, 64)
+}
@@ -0,0 +1,53 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+
+	"github.com/farischt/gobank/pkg/types"
+)
+
+/*
+CSV renders a statement as CSV, one row per transaction between the opening and the closing balance.
+*/
+func CSV(doc *types.StatementDocument) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+
+	rows := [][]string{
+		{"date", "transaction_id", "type", "counterparty", "reference", "creditor_reference", "amount", "currency", "balance"},
+		{doc.PeriodStart.Format("2006-01-02"), "", "opening_balance", "", "", "", "", doc.Currency, amount(doc.OpeningBalance)},
+	}
+
+	for _, l := range doc.Lines {
+		rows = append(rows, []string{
+			l.Date.Format("2006-01-02"),
+			strconv.FormatUint(uint64(l.TransactionID), 10),
+			string(l.Type),
+			l.Counterparty,
+			l.Reference,
+			l.CreditorReference,
+			amount(l.Amount),
+			doc.Currency,
+			amount(l.Balance),
+		})
+	}
+
+	rows = append(rows, []string{doc.PeriodEnd.Format("2006-01-02"), "", "closing_balance", "", "", "", "", doc.Currency, amount(doc.ClosingBalance)})
+
+	err := w.WriteAll(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+/*
+amount formats an amount with two decimals.
+*/
+func amount(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
@@ -0,0 +1,74 @@
+package export
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/farischt/gobank/pkg/types"
+)
+
+var statementTemplate = template.Must(template.New("statement").Funcs(template.FuncMap{
+	"amount": amount,
+	"date": func(d interface{ Format(string) string }) string {
+		return d.Format("2006-01-02")
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Statement {{.Doc.IBAN}} {{date .Doc.PeriodStart}} - {{date .Doc.PeriodEnd}}</title>
+<style>
+body { font-family: sans-serif; font-size: 12px; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #ccc; padding: 4px; text-align: left; }
+td.amount, th.amount { text-align: right; }
+footer { margin-top: 2em; color: #666; font-size: 10px; }
+@media print { body { margin: 0; } }
+</style>
+</head>
+<body>
+<h1>Account statement</h1>
+<p>
+Account: {{.Doc.IBAN}} ({{.Doc.Currency}})<br>
+Period: {{date .Doc.PeriodStart}} to {{date .Doc.PeriodEnd}}
+</p>
+<table>
+<thead>
+<tr><th>Date</th><th>Type</th><th>Counterparty</th><th>Reference</th><th class="amount">Amount</th><th class="amount">Balance</th></tr>
+</thead>
+<tbody>
+<tr><td>{{date .Doc.PeriodStart}}</td><td colspan="4">Opening balance</td><td class="amount">{{amount .Doc.OpeningBalance}}</td></tr>
+{{- range .Doc.Lines}}
+<tr><td>{{date .Date}}</td><td>{{.Type}}</td><td>{{.Counterparty}}</td><td>{{.Reference}}{{if .CreditorReference}} {{.CreditorReference}}{{end}}</td><td class="amount">{{amount .Amount}}</td><td class="amount">{{amount .Balance}}</td></tr>
+{{- end}}
+<tr><td>{{date .Doc.PeriodEnd}}</td><td colspan="4">Closing balance</td><td class="amount">{{amount .Doc.ClosingBalance}}</td></tr>
+</tbody>
+</table>
+<h2>Summary</h2>
+<table>
+<tr><td>Total in</td><td class="amount">{{amount .Doc.TotalIn}}</td></tr>
+<tr><td>Total out</td><td class="amount">{{amount .Doc.TotalOut}}</td></tr>
+<tr><td>Of which fees</td><td class="amount">{{amount .Doc.Fees}}</td></tr>
+<tr><td>Of which interest</td><td class="amount">{{amount .Doc.Interest}}</td></tr>
+</table>
+<footer>Generated on {{.Doc.GeneratedAt.Format "2006-01-02 15:04:05 UTC"}}. Document SHA-256: {{.Hash}}</footer>
+</body>
+</html>
+`))
+
+/*
+HTML renders a statement as a printable HTML page, with the hash of its document.
+*/
+func HTML(doc *types.StatementDocument, hash string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	err := statementTemplate.Execute(buf, struct {
+		Doc  *types.StatementDocument
+		Hash string
+	}{doc, hash})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,155 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/farischt/gobank/pkg/types"
+)
+
+// Lines of a SWIFT message end with CRLF, a field of information to the account owner
+// holds at most 6 lines of 65 characters
+const (
+	mt940LineEnd     = "\r\n"
+	mt940InfoLines   = 6
+	mt940InfoLineLen = 65
+)
+
+/*
+MT940 renders a statement as the text block of a SWIFT MT940 customer statement message.
+The counterparty IBAN and the references of every transaction are given in its :86: field.
+*/
+func MT940(doc *types.StatementDocument) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	field := func(tag string, value string) {
+		buf.WriteString(":" + tag + ":" + value + mt940LineEnd)
+	}
+
+	field("20", "STMT"+doc.PeriodEnd.Format("060102"))
+	field("25", doc.IBAN)
+	field("28C", "00001/001")
+	field("60F", mt940Balance(doc.OpeningBalance, doc.PeriodStart.Format("060102"), doc.Currency))
+
+	for _, l := range doc.Lines {
+		mark := "C"
+		if l.Amount < 0 {
+			mark = "D"
+		}
+		if l.Type == types.TransactionReversal {
+			// A reversal credit cancels a debit and the other way around
+			if mark == "C" {
+				mark = "RD"
+			} else {
+				mark = "RC"
+			}
+		}
+
+		ref := "NONREF"
+		if l.CreditorReference != "" {
+			ref = l.CreditorReference
+		} else if l.Reference != "" {
+			ref = swiftText(l.Reference)
+		}
+		if len(ref) > 16 {
+			ref = ref[:16]
+		}
+
+		date := l.Date.UTC()
+		field("61", fmt.Sprintf("%s%s%s%s%s%s//%d",
+			date.Format("060102"),
+			date.Format("0102"),
+			mark,
+			mt940Amount(math.Abs(l.Amount)),
+			mt940TypeCode(l.Type),
+			ref,
+			l.TransactionID,
+		))
+
+		info := []string{}
+		if l.Counterparty != "" {
+			info = append(info, "IBAN "+l.Counterparty)
+		}
+		if l.CreditorReference != "" {
+			info = append(info, "CRED REF "+l.CreditorReference)
+		}
+		if l.Reference != "" {
+			info = append(info, "REF "+swiftText(l.Reference))
+		}
+		if len(info) > 0 {
+			field("86", strings.Join(mt940Wrap(strings.Join(info, " ")), mt940LineEnd))
+		}
+	}
+
+	field("62F", mt940Balance(doc.ClosingBalance, doc.PeriodEnd.Format("060102"), doc.Currency))
+	buf.WriteString("-" + mt940LineEnd)
+
+	return buf.Bytes(), nil
+}
+
+/*
+mt940Balance formats a balance field: the credit or debit mark, the date, the currency and the amount.
+*/
+func mt940Balance(balance float64, date string, currency string) string {
+	mark := "C"
+	if balance < 0 {
+		mark = "D"
+	}
+	return mark + date + currency + mt940Amount(math.Abs(balance))
+}
+
+/*
+mt940Amount formats an amount the SWIFT way, with a decimal comma.
+*/
+func mt940Amount(v float64) string {
+	return strings.Replace(amount(v), ".", ",", 1)
+}
+
+/*
+mt940TypeCode gives the SWIFT transaction type identification code of a transaction type.
+*/
+func mt940TypeCode(t types.TransactionType) string {
+	switch t {
+	case types.TransactionFee:
+		return "NCHG"
+	case types.TransactionInterest:
+		return "NINT"
+	default:
+		return "NTRF"
+	}
+}
+
+/*
+swiftText replaces the characters outside of the SWIFT character set with a dot.
+The colon is replaced too, so that a wrapped line is never taken for a new field.
+*/
+func swiftText(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case strings.ContainsRune("/-?().,'+ ", r):
+			return r
+		default:
+			return '.'
+		}
+	}, s)
+}
+
+/*
+mt940Wrap splits a text into the lines of a :86: field, cutting it beyond the last line.
+*/
+func mt940Wrap(s string) []string {
+	lines := []string{}
+	for len(s) > 0 && len(lines) < mt940InfoLines {
+		n := mt940InfoLineLen
+		if len(s) < n {
+			n = len(s)
+		}
+		lines = append(lines, s[:n])
+		s = s[n:]
+	}
+	return lines
+}
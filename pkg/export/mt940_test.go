@@ -0,0 +1,159 @@
+package export
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type testMT940Field struct {
+	tag   string
+	lines []string
+}
+
+/*
+parseMT940 splits an MT940 text block into its fields, checking that every line ends with CRLF
+and that the block ends with a dash.
+*/
+func parseMT940(t *testing.T, data []byte) []testMT940Field {
+	t.Helper()
+
+	text := string(data)
+	if !strings.HasSuffix(text, mt940LineEnd+"-"+mt940LineEnd) {
+		t.Fatalf("block doesn't end with a dash line: %q", text)
+	}
+	lines := strings.Split(strings.TrimSuffix(text, "-"+mt940LineEnd), mt940LineEnd)
+	lines = lines[:len(lines)-1]
+
+	tag := regexp.MustCompile(`^:([0-9]{2}[A-Z]?):`)
+	fields := []testMT940Field{}
+	for _, l := range lines {
+		if strings.ContainsAny(l, "\r\n") {
+			t.Fatalf("line %q not ended with CRLF", l)
+		}
+
+		if m := tag.FindStringSubmatch(l); m != nil {
+			fields = append(fields, testMT940Field{tag: m[1], lines: []string{strings.TrimPrefix(l, m[0])}})
+		} else if len(fields) == 0 {
+			t.Fatalf("line %q before the first field", l)
+		} else {
+			f := &fields[len(fields)-1]
+			f.lines = append(f.lines, l)
+		}
+	}
+
+	return fields
+}
+
+func TestMT940TagSequence(t *testing.T) {
+	data, err := MT940(testStatement())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := parseMT940(t, data)
+
+	// The fee has neither counterparty nor reference, so no :86: follows its :61:
+	tags := []string{}
+	for _, f := range fields {
+		tags = append(tags, f.tag)
+	}
+	want := []string{"20", "25", "28C", "60F", "61", "86", "61", "86", "61", "61", "86", "62F"}
+	if !reflect.DeepEqual(tags, want) {
+		t.Fatalf("tags = %v, want %v", tags, want)
+	}
+
+	values := map[string]string{}
+	for _, f := range fields {
+		values[f.tag] = f.lines[0]
+	}
+	if values["20"] != "STMT240331" {
+		t.Errorf(":20: = %q, want STMT240331", values["20"])
+	}
+	if values["25"] != "FR7630006000011234567890189" {
+		t.Errorf(":25: = %q, want the IBAN of the account", values["25"])
+	}
+	if values["28C"] != "00001/001" {
+		t.Errorf(":28C: = %q, want 00001/001", values["28C"])
+	}
+	if values["60F"] != "C240301EUR100,00" {
+		t.Errorf(":60F: = %q, want C240301EUR100,00", values["60F"])
+	}
+	if values["62F"] != "C240331EUR127,50" {
+		t.Errorf(":62F: = %q, want C240331EUR127,50", values["62F"])
+	}
+
+	statementLine := regexp.MustCompile(`^[0-9]{6}[0-9]{4}(C|D|RC|RD)[0-9]{1,12},[0-9]{2}N[A-Z]{3}[^/]{1,16}//[0-9]{1,16}$`)
+	marks := []string{}
+	for _, f := range fields {
+		if f.tag != "61" {
+			continue
+		}
+		m := statementLine.FindStringSubmatch(f.lines[0])
+		if m == nil {
+			t.Errorf(":61: = %q, not a statement line", f.lines[0])
+			continue
+		}
+		marks = append(marks, m[1])
+	}
+	if want := []string{"C", "D", "D", "RD"}; !reflect.DeepEqual(marks, want) {
+		t.Errorf("credit and debit marks = %v, want %v", marks, want)
+	}
+}
+
+func TestMT940LineLength(t *testing.T) {
+	data, err := MT940(testStatement())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrapped := false
+	for _, f := range parseMT940(t, data) {
+		if f.tag != "86" && len(f.lines) != 1 {
+			t.Errorf(":%s: spans %d lines, want 1", f.tag, len(f.lines))
+		} else if len(f.lines) > mt940InfoLines {
+			t.Errorf(":86: spans %d lines, want at most %d", len(f.lines), mt940InfoLines)
+		}
+		if len(f.lines) > 1 {
+			wrapped = true
+		}
+
+		for _, l := range f.lines {
+			if len(l) > mt940InfoLineLen {
+				t.Errorf(":%s: line %q is %d characters long, want at most %d", f.tag, l, len(l), mt940InfoLineLen)
+			}
+		}
+	}
+
+	if !wrapped {
+		t.Error("the long reference wasn't wrapped")
+	}
+}
+
+func TestMT940Wrap(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		lines int
+	}{
+		{"empty", "", 0},
+		{"one line", strings.Repeat("a", mt940InfoLineLen), 1},
+		{"two lines", strings.Repeat("a", mt940InfoLineLen+1), 2},
+		{"cut beyond the last line", strings.Repeat("a", mt940InfoLines*mt940InfoLineLen+100), mt940InfoLines},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := mt940Wrap(tt.text)
+			if len(lines) != tt.lines {
+				t.Fatalf("%d lines, want %d", len(lines), tt.lines)
+			}
+			for _, l := range lines {
+				if len(l) > mt940InfoLineLen {
+					t.Errorf("line of %d characters, want at most %d", len(l), mt940InfoLineLen)
+				}
+			}
+		})
+	}
+}
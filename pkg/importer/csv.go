@@ -0,0 +1,67 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/farischt/gobank/pkg/dto"
+)
+
+/*
+CSV reads the transfers of a CSV file, one per row after a header naming the columns.
+The iban and amount columns are required, the currency, reference, creditor_reference and
+end_to_end_id ones are optional.
+*/
+func CSV(r io.Reader) (*dto.CreateBatchDTO, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid_file")
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		// Spreadsheets may start the file with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	if _, ok := columns["iban"]; !ok {
+		return nil, fmt.Errorf("invalid_file")
+	} else if _, ok := columns["amount"]; !ok {
+		return nil, fmt.Errorf("invalid_file")
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	data := &dto.CreateBatchDTO{Lines: []dto.BatchLineDTO{}}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid_file")
+		}
+
+		data.Lines = append(data.Lines, dto.BatchLineDTO{
+			To:                field(record, "iban"),
+			Amount:            field(record, "amount"),
+			Currency:          field(record, "currency"),
+			Reference:         field(record, "reference"),
+			CreditorReference: field(record, "creditor_reference"),
+			EndToEndID:        field(record, "end_to_end_id"),
+		})
+	}
+
+	return data, nil
+}
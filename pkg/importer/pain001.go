@@ -0,0 +1,96 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/farischt/gobank/pkg/dto"
+)
+
+// Every version of the customer credit transfer initiation shares the elements read here
+const pain001NamespacePrefix = "urn:iso:std:iso:20022:tech:xsd:pain.001."
+
+type painDocument struct {
+	XMLName xml.Name `xml:"Document"`
+	GrpHdr  struct {
+		MsgId   string `xml:"MsgId"`
+		NbOfTxs string `xml:"NbOfTxs"`
+		CtrlSum string `xml:"CtrlSum"`
+	} `xml:"CstmrCdtTrfInitn>GrpHdr"`
+	PmtInf []struct {
+		DbtrIBAN    string `xml:"DbtrAcct>Id>IBAN"`
+		CdtTrfTxInf []struct {
+			EndToEndId string `xml:"PmtId>EndToEndId"`
+			InstdAmt   struct {
+				Ccy   string `xml:"Ccy,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"Amt>InstdAmt"`
+			CdtrIBAN string   `xml:"CdtrAcct>Id>IBAN"`
+			Ustrd    []string `xml:"RmtInf>Ustrd"`
+			Ref      []string `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+		} `xml:"CdtTrfTxInf"`
+	} `xml:"CstmrCdtTrfInitn>PmtInf"`
+}
+
+/*
+Pain001 reads the transfers of an ISO 20022 pain.001 customer credit transfer initiation.
+Every payment information block must debit the same account.
+*/
+func Pain001(r io.Reader) (*dto.CreateBatchDTO, error) {
+	doc := new(painDocument)
+
+	err := xml.NewDecoder(r).Decode(doc)
+	if err != nil || !strings.HasPrefix(doc.XMLName.Space, pain001NamespacePrefix) {
+		return nil, fmt.Errorf("invalid_file")
+	}
+
+	data := &dto.CreateBatchDTO{
+		MessageID: strings.TrimSpace(doc.GrpHdr.MsgId),
+		Lines:     []dto.BatchLineDTO{},
+	}
+	if data.MessageID == "" {
+		return nil, fmt.Errorf("invalid_file")
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(doc.GrpHdr.NbOfTxs))
+	if err != nil {
+		return nil, fmt.Errorf("invalid_file")
+	}
+	data.TransactionCount = &count
+
+	if sum := strings.TrimSpace(doc.GrpHdr.CtrlSum); sum != "" {
+		data.ControlSum = &sum
+	}
+
+	for i, pmt := range doc.PmtInf {
+		debtor := strings.TrimSpace(pmt.DbtrIBAN)
+		if i == 0 {
+			data.DebtorIBAN = debtor
+		} else if debtor != data.DebtorIBAN {
+			return nil, fmt.Errorf("debtor_account_mismatch")
+		}
+
+		for _, tx := range pmt.CdtTrfTxInf {
+			line := dto.BatchLineDTO{
+				To:        strings.TrimSpace(tx.CdtrIBAN),
+				Amount:    strings.TrimSpace(tx.InstdAmt.Value),
+				Currency:  strings.TrimSpace(tx.InstdAmt.Ccy),
+				Reference: strings.TrimSpace(strings.Join(tx.Ustrd, " ")),
+			}
+			if len(tx.Ref) > 0 {
+				line.CreditorReference = strings.TrimSpace(tx.Ref[0])
+			}
+			// NOTPROVIDED is the conventional value of a missing end to end identification
+			if id := strings.TrimSpace(tx.EndToEndId); id != "NOTPROVIDED" {
+				line.EndToEndID = id
+			}
+
+			data.Lines = append(data.Lines, line)
+		}
+	}
+
+	return data, nil
+}
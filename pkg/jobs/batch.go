@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/farischt/gobank/pkg/services"
+)
+
+/*
+BatchRecoveryJob resumes the batches left executing for BATCH_STALE_AFTER,
+the confirmation executing them having stopped midway.
+*/
+type BatchRecoveryJob struct {
+	service *services.Service
+}
+
+func NewBatchRecoveryJob(service *services.Service) *BatchRecoveryJob {
+	return &BatchRecoveryJob{
+		service: service,
+	}
+}
+
+func (j *BatchRecoveryJob) Name() string {
+	return "batch_recovery"
+}
+
+func (j *BatchRecoveryJob) Interval() time.Duration {
+	return 5 * time.Minute
+}
+
+func (j *BatchRecoveryJob) Run(now time.Time) error {
+	n, err := j.service.Batch.ResumeStaleBatches(now)
+	if err != nil {
+		return err
+	}
+
+	if n > 0 {
+		log.Printf("%d batch(es) resumed", n)
+	}
+
+	return nil
+}
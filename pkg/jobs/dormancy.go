@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/farischt/gobank/config"
+	"github.com/farischt/gobank/pkg/services"
+)
+
+/*
+DormancyJob moves the accounts without activity for DORMANT_AFTER_DAYS days to the dormant status.
+*/
+type DormancyJob struct {
+	service *services.Service
+}
+
+func NewDormancyJob(service *services.Service) *DormancyJob {
+	return &DormancyJob{
+		service: service,
+	}
+}
+
+func (j *DormancyJob) Name() string {
+	return "dormancy"
+}
+
+func (j *DormancyJob) Interval() time.Duration {
+	return time.Hour
+}
+
+func (j *DormancyJob) Run(now time.Time) error {
+	days := config.GetConfig().GetInt(config.DORMANT_AFTER_DAYS)
+	if days <= 0 {
+		days = 365
+	}
+
+	n, err := j.service.Account.MarkDormant(now.AddDate(0, 0, -days))
+	if err != nil {
+		return err
+	}
+
+	if n > 0 {
+		log.Printf("%d account(s) became dormant", n)
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/farischt/gobank/config"
+	"github.com/farischt/gobank/pkg/services"
+)
+
+/*
+FxRateImportJob imports the FX rates of the FX_RATES_CSV file, when set.
+Rates not newer than the ones already imported are skipped, so the file can be updated in place.
+*/
+type FxRateImportJob struct {
+	service *services.Service
+}
+
+func NewFxRateImportJob(service *services.Service) *FxRateImportJob {
+	return &FxRateImportJob{
+		service: service,
+	}
+}
+
+func (j *FxRateImportJob) Name() string {
+	return "fx_rate_import"
+}
+
+func (j *FxRateImportJob) Interval() time.Duration {
+	return time.Hour
+}
+
+func (j *FxRateImportJob) Run(now time.Time) error {
+	path := config.GetConfig().GetString(config.FX_RATES_CSV)
+	if path == "" {
+		return nil
+	}
+
+	n, err := j.service.Fx.ImportCSV(path)
+	if err != nil {
+		return err
+	}
+
+	if n > 0 {
+		log.Printf("%d fx rate(s) imported from %s", n, path)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/farischt/gobank/pkg/services"
+)
+
+/*
+HoldExpiryJob expires the holds not captured or released within HOLD_TTL.
+*/
+type HoldExpiryJob struct {
+	service *services.Service
+}
+
+func NewHoldExpiryJob(service *services.Service) *HoldExpiryJob {
+	return &HoldExpiryJob{
+		service: service,
+	}
+}
+
+func (j *HoldExpiryJob) Name() string {
+	return "hold_expiry"
+}
+
+func (j *HoldExpiryJob) Interval() time.Duration {
+	return 5 * time.Minute
+}
+
+func (j *HoldExpiryJob) Run(now time.Time) error {
+	n, err := j.service.Hold.ExpireHolds(now)
+	if err != nil {
+		return err
+	}
+
+	if n > 0 {
+		log.Printf("%d hold(s) expired", n)
+	}
+
+	return nil
+}
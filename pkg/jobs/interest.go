@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/farischt/gobank/pkg/services"
+)
+
+/*
+maxInterestCatchUpDays bounds how many missed business dates are accrued after a downtime.
+*/
+const maxInterestCatchUpDays = 31
+
+/*
+InterestJob accrues the interest of every business date up to yesterday, and capitalizes
+it at the end of each month. The last business date processed is run again in case it
+was interrupted, accounts already processed are skipped.
+*/
+type InterestJob struct {
+	service *services.Service
+}
+
+func NewInterestJob(service *services.Service) *InterestJob {
+	return &InterestJob{
+		service: service,
+	}
+}
+
+func (j *InterestJob) Name() string {
+	return "interest"
+}
+
+func (j *InterestJob) Interval() time.Duration {
+	return time.Hour
+}
+
+func (j *InterestJob) Run(now time.Time) error {
+	yesterday := services.BusinessDate(now).AddDate(0, 0, -1)
+
+	last, err := j.service.Interest.LastBusinessDate()
+	if err != nil {
+		return err
+	}
+
+	from := yesterday
+	if last != nil {
+		from = services.BusinessDate(*last)
+		if oldest := yesterday.AddDate(0, 0, 1-maxInterestCatchUpDays); from.Before(oldest) {
+			from = oldest
+		}
+	}
+
+	for d := from; !d.After(yesterday); d = d.AddDate(0, 0, 1) {
+		run, err := j.service.Interest.RunBusinessDate(d)
+		if err != nil {
+			return err
+		}
+
+		if run.Accrued > 0 || run.Capitalized > 0 {
+			log.Printf("interest for %s: %d account(s) accrued, %d capitalized", d.Format("2006-01-02"), run.Accrued, run.Capitalized)
+		}
+	}
+
+	return nil
+}
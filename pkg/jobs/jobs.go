@@ -0,0 +1,80 @@
+package jobs
+
+import (
+	"log"
+	"time"
+)
+
+/*
+Job is a background task run periodically by the Runner.
+*/
+type Job interface {
+	Name() string
+	Interval() time.Duration
+	Run(now time.Time) error
+}
+
+/*
+Runner runs every registered job at its own interval.
+*/
+type Runner struct {
+	jobs []Job
+	stop chan struct{}
+}
+
+/*
+NewRunner creates a runner for the given jobs.
+*/
+func NewRunner(jobs ...Job) *Runner {
+	return &Runner{
+		jobs: jobs,
+		stop: make(chan struct{}),
+	}
+}
+
+/*
+Start runs every job once, then at its interval, until Stop is called.
+It doesn't block.
+*/
+func (r *Runner) Start() {
+	for _, j := range r.jobs {
+		go r.loop(j)
+	}
+}
+
+/*
+Stop stops every job loop.
+*/
+func (r *Runner) Stop() {
+	close(r.stop)
+}
+
+func (r *Runner) loop(j Job) {
+	ticker := time.NewTicker(j.Interval())
+	defer ticker.Stop()
+
+	for {
+		run(j)
+
+		select {
+		case <-ticker.C:
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+/*
+run runs a job once, a failing or panicking job is logged and retried at its next tick.
+*/
+func run(j Job) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Printf("job %s panicked: %v", j.Name(), err)
+		}
+	}()
+
+	if err := j.Run(time.Now()); err != nil {
+		log.Printf("job %s failed: %v", j.Name(), err)
+	}
+}
@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/farischt/gobank/pkg/services"
+)
+
+/*
+PaymentRequestExpiryJob expires the payment requests not accepted, declined or cancelled before their expiry.
+*/
+type PaymentRequestExpiryJob struct {
+	service *services.Service
+}
+
+func NewPaymentRequestExpiryJob(service *services.Service) *PaymentRequestExpiryJob {
+	return &PaymentRequestExpiryJob{
+		service: service,
+	}
+}
+
+func (j *PaymentRequestExpiryJob) Name() string {
+	return "payment_request_expiry"
+}
+
+func (j *PaymentRequestExpiryJob) Interval() time.Duration {
+	return 5 * time.Minute
+}
+
+func (j *PaymentRequestExpiryJob) Run(now time.Time) error {
+	n, err := j.service.PaymentRequest.ExpirePaymentRequests(now)
+	if err != nil {
+		return err
+	}
+
+	if n > 0 {
+		log.Printf("%d payment request(s) expired", n)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/farischt/gobank/pkg/services"
+)
+
+/*
+StandingOrderJob makes the transfers of the standing orders that are due.
+*/
+type StandingOrderJob struct {
+	service *services.Service
+}
+
+func NewStandingOrderJob(service *services.Service) *StandingOrderJob {
+	return &StandingOrderJob{
+		service: service,
+	}
+}
+
+func (j *StandingOrderJob) Name() string {
+	return "standing_orders"
+}
+
+func (j *StandingOrderJob) Interval() time.Duration {
+	return 5 * time.Minute
+}
+
+func (j *StandingOrderJob) Run(now time.Time) error {
+	succeeded, failed, err := j.service.StandingOrder.ExecuteDue(now)
+	if err != nil {
+		return err
+	}
+
+	if succeeded > 0 || failed > 0 {
+		log.Printf("standing orders: %d run(s) succeeded, %d failed", succeeded, failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/farischt/gobank/pkg/services"
+)
+
+/*
+StatementJob generates the statements of the previous month once it has ended.
+Accounts already given their statement are skipped, so it can run at any time of the month.
+*/
+type StatementJob struct {
+	service *services.Service
+}
+
+func NewStatementJob(service *services.Service) *StatementJob {
+	return &StatementJob{
+		service: service,
+	}
+}
+
+func (j *StatementJob) Name() string {
+	return "statement"
+}
+
+func (j *StatementJob) Interval() time.Duration {
+	return time.Hour
+}
+
+func (j *StatementJob) Run(now time.Time) error {
+	n, err := j.service.Statement.GenerateMonthly(now)
+	if err != nil {
+		return err
+	}
+
+	if n > 0 {
+		log.Printf("%d monthly statement(s) generated", n)
+	}
+
+	return nil
+}
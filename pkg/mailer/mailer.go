@@ -0,0 +1,77 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/farischt/gobank/config"
+)
+
+/*
+Mailer delivers emails to the users.
+*/
+type Mailer interface {
+	Send(to string, subject string, body string) error
+}
+
+/*
+New creates the mailer selected by the MAILER configuration, "log" by default.
+*/
+func New() (Mailer, error) {
+	c := config.GetConfig()
+
+	switch c.GetString(config.MAILER) {
+	case "", "log":
+		return NewLogMailer(), nil
+	case "file":
+		return NewFileMailer(c.GetString(config.MAILER_DIR))
+	default:
+		return nil, fmt.Errorf("unknown mailer %q", c.GetString(config.MAILER))
+	}
+}
+
+type logMailer struct{}
+
+/*
+NewLogMailer creates a mailer that writes the emails to the standard logger.
+*/
+func NewLogMailer() Mailer {
+	return &logMailer{}
+}
+
+func (m *logMailer) Send(to string, subject string, body string) error {
+	log.Printf("mail to %s: %s\n%s", to, subject, body)
+	return nil
+}
+
+type fileMailer struct {
+	dir string
+}
+
+/*
+NewFileMailer creates a mailer that writes every email to its own file in the given directory.
+*/
+func NewFileMailer(dir string) (Mailer, error) {
+	if dir == "" {
+		dir = "mails"
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	return &fileMailer{dir: dir}, nil
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]`)
+
+func (m *fileMailer) Send(to string, subject string, body string) error {
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), unsafeFileChars.ReplaceAllString(to, "_"))
+	content := fmt.Sprintf("To: %s\r\nSubject: %s\r\nDate: %s\r\n\r\n%s\r\n", to, subject, time.Now().Format(time.RFC1123Z), body)
+
+	return os.WriteFile(filepath.Join(m.dir, name), []byte(content), 0o640)
+}
@@ -2,6 +2,9 @@ package services
 
 import (
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/farischt/gobank/pkg/dto"
 	"github.com/farischt/gobank/pkg/store"
@@ -10,10 +13,17 @@ import (
 )
 
 type AccountService interface {
-	Get(id uint, withUser bool) (*types.SerializedAccount, error)
-	GetAll() ([]*types.SerializedAccount, error)
+	Get(p *types.Principal, id uint, withUser bool) (*types.SerializedAccount, error)
+	GetAll(p *types.Principal) ([]*types.SerializedAccount, error)
 	HashPassword(password []byte) (string, error)
-	Create(data *dto.CreateAccountDTO) error
+	Create(p *types.Principal, data *dto.CreateAccountDTO) (*types.SerializedAccount, error)
+	AssignIBANs() (int, error)
+	ChangeStatus(p *types.Principal, id uint, data *dto.ChangeAccountStatusDTO) (*types.SerializedAccountStatusChange, error)
+	GetStatusHistory(p *types.Principal, id uint) ([]*types.SerializedAccountStatusChange, error)
+	MarkDormant(inactiveSince time.Time) (int64, error)
+	SetOverdraft(p *types.Principal, id uint, data *dto.SetOverdraftDTO) (*types.SerializedAccount, error)
+	GetLimits(p *types.Principal, id uint) (*types.TransferLimitsUsage, error)
+	SetLimits(p *types.Principal, id uint, data *dto.SetTransferLimitsDTO) (*types.TransferLimitsUsage, error)
 }
 
 type accountService struct {
@@ -26,7 +36,12 @@ func NewAccountService(store store.Store) AccountService {
 	}
 }
 
-func (a *accountService) Get(id uint, withUser bool) (*types.SerializedAccount, error) {
+/*
+Get returns the account with the given id.
+An account the principal is not allowed to read is reported as not found,
+so that its existence doesn't leak.
+*/
+func (a *accountService) Get(p *types.Principal, id uint, withUser bool) (*types.SerializedAccount, error) {
 	var acc *types.Account
 	var err error
 
@@ -44,13 +59,43 @@ func (a *accountService) Get(id uint, withUser bool) (*types.SerializedAccount,
 		return nil, err
 	}
 
+	readable, err := canReadAccount(a.store, p, acc)
+	if err != nil {
+		return nil, err
+	} else if !readable {
+		return nil, fmt.Errorf("account_not_found")
+	}
+
 	s := acc.Serialize()
 
 	return &s, nil
 }
 
-func (a *accountService) GetAll() ([]*types.SerializedAccount, error) {
-	accounts, err := a.store.Account.GetAllAccount()
+/*
+GetAll returns every account, audited, for a principal allowed to read any account, and the accounts
+the principal's user holds otherwise, with its role on each. API keys only list the accounts
+their user owns.
+*/
+func (a *accountService) GetAll(p *types.Principal) ([]*types.SerializedAccount, error) {
+	var accounts []*types.Account
+	var err error
+
+	if p == nil {
+		return nil, fmt.Errorf("unauthorized")
+	}
+
+	if p.Can(types.PermAccountReadAny) {
+		err = recordAnyAccess(a.store, p, types.PermAccountReadAny, http.MethodGet, "/account", http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+		accounts, err = a.store.Account.GetAllAccount()
+	} else if p.IsApiKey() {
+		accounts, err = a.store.Account.GetAccountsByUser(p.UserID)
+	} else {
+		accounts, err = a.store.Account.GetAccountsByHolder(p.UserID)
+	}
+
 	if err != nil {
 		return nil, err
 	}
@@ -65,7 +110,14 @@ func (a *accountService) GetAll() ([]*types.SerializedAccount, error) {
 	return serializedAccounts, nil
 }
 
+// Shortest password a user can set
+const minPasswordLength = 8
+
 func (a *accountService) HashPassword(password []byte) (string, error) {
+	return hashPassword(password)
+}
+
+func hashPassword(password []byte) (string, error) {
 	hash, err := bcrypt.GenerateFromPassword(password, bcrypt.MinCost)
 	if err != nil {
 		return "", err
@@ -74,24 +126,243 @@ func (a *accountService) HashPassword(password []byte) (string, error) {
 	return string(hash), nil
 }
 
-func (a *accountService) Create(data *dto.CreateAccountDTO) error {
+/*
+Create opens an account for the principal's own user, or for the given user on behalf of a principal
+with the account:open_any permission, which is audited. The password of the account is optional, it only lets
+the legacy login use the account number.
+*/
+func (a *accountService) Create(p *types.Principal, data *dto.CreateAccountDTO) (*types.SerializedAccount, error) {
+	if p == nil || p.IsApiKey() {
+		return nil, fmt.Errorf("forbidden")
+	}
+
+	if data.UserID == 0 {
+		data.UserID = p.UserID
+	} else if data.UserID != p.UserID && !p.Can(types.PermAccountOpenAny) {
+		return nil, fmt.Errorf("forbidden")
+	}
 
-	if data.UserID <= 0 {
-		return fmt.Errorf("invalid_user_id")
+	if data.Type == "" {
+		data.Type = string(types.AccountChecking)
+	}
+
+	if data.Currency == "" {
+		data.Currency = defaultCurrency()
+	}
+	data.Currency = strings.ToUpper(strings.TrimSpace(data.Currency))
+	if !types.IsValidCurrency(data.Currency) {
+		return nil, fmt.Errorf("invalid_currency")
 	}
 
-	// Check if user exists
-	_, err := a.store.User.GetUserByID(data.UserID)
+	switch accountType := types.AccountType(data.Type); {
+	case !accountType.IsValid():
+		return nil, fmt.Errorf("invalid_account_type")
+	case accountType == types.AccountTermDeposit && (data.MaturityDate == nil || !data.MaturityDate.After(time.Now())):
+		return nil, fmt.Errorf("invalid_maturity_date")
+	case accountType != types.AccountTermDeposit && data.MaturityDate != nil:
+		return nil, fmt.Errorf("invalid_maturity_date")
+	}
+
+	// Check if user exists and has verified its email
+	user, err := a.store.User.GetUserByID(data.UserID)
 	if err != nil {
+		return nil, err
+	} else if user.IsDeleted() {
+		return nil, fmt.Errorf("user_not_found")
+	} else if !user.IsVerified() {
+		return nil, fmt.Errorf("user_not_verified")
+	}
+
+	// Hash password, an account without one can't be logged in to with its number
+	if data.Password != "" {
+		hash, err := a.HashPassword([]byte(data.Password))
+		if err != nil {
+			return nil, err
+		}
+
+		data.Password = hash
+	}
+
+	// The account is opened for another user only through the permission, audited before it's opened
+	if data.UserID != p.UserID {
+		err = recordAnyAccess(a.store, p, types.PermAccountOpenAny, http.MethodPost, "/account", http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var account *types.Account
+	err = withNewIBAN(func(iban string) error {
+		data.IBAN = iban
+		account, err = a.store.Account.CreateAccount(data)
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Hash password
-	hash, err := a.HashPassword([]byte(data.Password))
+	s := account.Serialize()
+	return &s, nil
+}
+
+/*
+AssignIBANs gives an IBAN to every account opened before account numbers were IBANs.
+It returns the number of accounts given one.
+*/
+func (a *accountService) AssignIBANs() (int, error) {
+	ids, err := a.store.Account.GetAccountsWithoutIBAN()
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	for i, id := range ids {
+		err = withNewIBAN(func(iban string) error {
+			return a.store.Account.SetAccountIBAN(id, iban)
+		})
+		if err != nil {
+			return i, err
+		}
+	}
+
+	return len(ids), nil
+}
+
+/*
+ChangeStatus moves an account to a new status on behalf of the principal.
+Freezing and unfreezing requires the account:freeze permission, closing requires account:close.
+*/
+func (a *accountService) ChangeStatus(p *types.Principal, id uint, data *dto.ChangeAccountStatusDTO) (*types.SerializedAccountStatusChange, error) {
+	to := types.AccountStatus(data.Status)
+	reason := strings.TrimSpace(data.Reason)
+
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid_account_id")
+	} else if !to.IsValid() {
+		return nil, fmt.Errorf("invalid_status")
+	} else if len(reason) == 0 {
+		return nil, fmt.Errorf("empty_reason")
+	}
+
+	if to == types.AccountClosed && !p.Can(types.PermAccountClose) {
+		return nil, fmt.Errorf("forbidden")
+	} else if to != types.AccountClosed && !p.Can(types.PermAccountFreeze) {
+		return nil, fmt.Errorf("forbidden")
+	}
+
+	change, err := a.store.Account.ChangeAccountStatus(id, to, reason, &p.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	s := change.Serialize()
+	return &s, nil
+}
+
+/*
+GetStatusHistory returns the status transitions of an account the principal can read.
+*/
+func (a *accountService) GetStatusHistory(p *types.Principal, id uint) ([]*types.SerializedAccountStatusChange, error) {
+	acc, err := readableAccount(a.store, p, id)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := a.store.Account.GetAccountStatusHistory(acc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var serializedChanges []*types.SerializedAccountStatusChange
+	for _, c := range changes {
+		s := c.Serialize()
+		serializedChanges = append(serializedChanges, &s)
+	}
+
+	return serializedChanges, nil
+}
+
+/*
+MarkDormant moves the active accounts without activity since the given time to the dormant status.
+*/
+func (a *accountService) MarkDormant(inactiveSince time.Time) (int64, error) {
+	return a.store.Account.MarkDormantAccounts(inactiveSince)
+}
+
+/*
+SetOverdraft sets the overdraft limit of an account, on behalf of a principal
+with the account:overdraft permission. Only account types allowing an overdraft can have one.
+*/
+func (a *accountService) SetOverdraft(p *types.Principal, id uint, data *dto.SetOverdraftDTO) (*types.SerializedAccount, error) {
+	if !p.Can(types.PermAccountOverdraft) {
+		return nil, fmt.Errorf("forbidden")
+	} else if id <= 0 {
+		return nil, fmt.Errorf("invalid_account_id")
+	} else if data.Limit < 0 {
+		return nil, fmt.Errorf("invalid_overdraft_limit")
+	}
+
+	acc, err := a.store.Account.GetAccount(id)
+	if err != nil {
+		return nil, err
+	} else if data.Limit > 0 && !rulesFor(acc.Type).allowsOverdraft() {
+		return nil, fmt.Errorf("overdraft_not_allowed")
+	}
+
+	acc, err = a.store.Account.SetOverdraftLimit(id, data.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	s := acc.Serialize()
+	return &s, nil
+}
+
+/*
+GetLimits returns the effective transfer limits of an account the principal can read,
+and how much of them is used today.
+*/
+func (a *accountService) GetLimits(p *types.Principal, id uint) (*types.TransferLimitsUsage, error) {
+	acc, err := readableAccount(a.store, p, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.limitsUsage(acc)
+}
+
+/*
+SetLimits sets the transfer limits overrides of an account, on behalf of a principal
+with the account:limits permission.
+*/
+func (a *accountService) SetLimits(p *types.Principal, id uint, data *dto.SetTransferLimitsDTO) (*types.TransferLimitsUsage, error) {
+	if !p.Can(types.PermAccountLimits) {
+		return nil, fmt.Errorf("forbidden")
+	} else if id <= 0 {
+		return nil, fmt.Errorf("invalid_account_id")
+	} else if (data.MaxAmount != nil && *data.MaxAmount < 0) ||
+		(data.DailyAmount != nil && *data.DailyAmount < 0) ||
+		(data.DailyCount != nil && *data.DailyCount < 0) {
+		return nil, fmt.Errorf("invalid_limit")
+	}
+
+	acc, err := a.store.Account.SetTransferLimits(id, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.limitsUsage(acc)
+}
+
+func (a *accountService) limitsUsage(acc *types.Account) (*types.TransferLimitsUsage, error) {
+	today, err := a.store.Transaction.GetOutgoingTotalsSince(acc.ID, startOfDay(time.Now()))
+	if err != nil {
+		return nil, err
 	}
 
-	data.Password = hash
-	return a.store.Account.CreateAccount(data)
+	return &types.TransferLimitsUsage{
+		AccountID:   acc.ID,
+		Limits:      effectiveLimits(acc),
+		AmountToday: today.Amount,
+		CountToday:  today.Count,
+	}, nil
 }
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/store"
+	"github.com/farischt/gobank/pkg/types"
+)
+
+type AccountHolderService interface {
+	GetAll(p *types.Principal, accountId uint) ([]*types.SerializedAccountHolder, error)
+	Add(p *types.Principal, accountId uint, data *dto.AddAccountHolderDTO) (*types.SerializedAccountHolderChange, error)
+	Remove(p *types.Principal, accountId uint, userId uint) (*types.SerializedAccountHolderChange, error)
+	GetChanges(p *types.Principal, accountId uint) ([]*types.SerializedAccountHolderChange, error)
+	Approve(p *types.Principal, accountId uint, id uint) (*types.SerializedAccountHolderChange, error)
+	Reject(p *types.Principal, accountId uint, id uint) (*types.SerializedAccountHolderChange, error)
+}
+
+type accountHolderService struct {
+	store store.Store
+}
+
+func NewAccountHolderService(store store.Store) AccountHolderService {
+	return &accountHolderService{
+		store: store,
+	}
+}
+
+/*
+GetAll returns the holders of an account the principal can read, the primary holder first.
+*/
+func (s *accountHolderService) GetAll(p *types.Principal, accountId uint) ([]*types.SerializedAccountHolder, error) {
+	acc, err := readableAccount(s.store, p, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	holders, err := s.store.AccountHolder.GetAccountHolders(acc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	serializedHolders := []*types.SerializedAccountHolder{}
+	for _, h := range holders {
+		serialized := h.Serialize()
+		serializedHolders = append(serializedHolders, &serialized)
+	}
+
+	return serializedHolders, nil
+}
+
+/*
+Add asks for the verified user with the given email to hold an account with the given role,
+on behalf of its primary or a joint holder. The account has a single primary holder, so no one
+can be added as such. Asked by the primary holder, the user is added right away, otherwise once
+the primary holder approves it.
+*/
+func (s *accountHolderService) Add(p *types.Principal, accountId uint, data *dto.AddAccountHolderDTO) (*types.SerializedAccountHolderChange, error) {
+	acc, holder, err := s.holding(p, accountId)
+	if err != nil {
+		return nil, err
+	} else if !holder.Role.CanRequestHolderChange() {
+		return nil, fmt.Errorf("forbidden")
+	} else if !acc.Status.CanLogin() {
+		return nil, fmt.Errorf("account_%s", acc.Status)
+	}
+
+	role := types.HolderRole(data.Role)
+	if !role.IsValid() || role == types.HolderPrimary {
+		return nil, fmt.Errorf("invalid_holder_role")
+	}
+
+	data.Email = strings.TrimSpace(data.Email)
+	if data.Email == "" {
+		return nil, fmt.Errorf("invalid_email")
+	}
+
+	user, err := s.store.User.GetUserByEmail(data.Email)
+	if err != nil {
+		return nil, err
+	} else if user.IsDeleted() {
+		return nil, fmt.Errorf("user_not_found")
+	} else if !user.IsVerified() {
+		return nil, fmt.Errorf("user_not_verified")
+	}
+
+	existing, err := s.store.AccountHolder.GetAccountHolder(acc.ID, user.ID)
+	if err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, fmt.Errorf("account_holder_already_exist")
+	}
+
+	change, err := s.store.AccountHolder.CreateHolderChange(acc.ID, user.ID, types.HolderChangeAdd, &role, p.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.consent(holder, change)
+}
+
+/*
+Remove asks for a user to stop holding an account, on behalf of its primary or a joint holder,
+or of the user itself. The primary holder can't be removed. Asked by the primary holder,
+the user is removed right away, otherwise once the primary holder approves it.
+*/
+func (s *accountHolderService) Remove(p *types.Principal, accountId uint, userId uint) (*types.SerializedAccountHolderChange, error) {
+	if userId <= 0 {
+		return nil, fmt.Errorf("invalid_user_id")
+	}
+
+	acc, holder, err := s.holding(p, accountId)
+	if err != nil {
+		return nil, err
+	} else if !holder.Role.CanRequestHolderChange() && userId != p.UserID {
+		return nil, fmt.Errorf("forbidden")
+	}
+
+	removed, err := s.store.AccountHolder.GetAccountHolder(acc.ID, userId)
+	if err != nil {
+		return nil, err
+	} else if removed == nil {
+		return nil, fmt.Errorf("account_holder_not_found")
+	} else if removed.Role == types.HolderPrimary {
+		return nil, fmt.Errorf("cannot_remove_primary_holder")
+	}
+
+	change, err := s.store.AccountHolder.CreateHolderChange(acc.ID, userId, types.HolderChangeRemove, nil, p.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.consent(holder, change)
+}
+
+/*
+GetChanges returns the changes of the holders of an account the principal holds, latest first.
+*/
+func (s *accountHolderService) GetChanges(p *types.Principal, accountId uint) ([]*types.SerializedAccountHolderChange, error) {
+	acc, _, err := s.holding(p, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := s.store.AccountHolder.GetHolderChangesByAccount(acc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	serializedChanges := []*types.SerializedAccountHolderChange{}
+	for _, c := range changes {
+		serialized := c.Serialize()
+		serializedChanges = append(serializedChanges, &serialized)
+	}
+
+	return serializedChanges, nil
+}
+
+/*
+Approve applies a pending change of the holders of an account, on behalf of its primary holder.
+*/
+func (s *accountHolderService) Approve(p *types.Principal, accountId uint, id uint) (*types.SerializedAccountHolderChange, error) {
+	change, err := s.pendingChange(p, accountId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if change.Action == types.HolderChangeAdd {
+		// The user may have been deleted since it was asked for
+		user, err := s.store.User.GetUserByID(change.UserID)
+		if err != nil {
+			return nil, err
+		} else if user.IsDeleted() {
+			return nil, fmt.Errorf("user_not_found")
+		}
+	}
+
+	change, err = s.store.AccountHolder.ApproveHolderChange(change.ID, p.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := change.Serialize()
+	return &serialized, nil
+}
+
+/*
+Reject refuses a pending change of the holders of an account, on behalf of its primary holder.
+*/
+func (s *accountHolderService) Reject(p *types.Principal, accountId uint, id uint) (*types.SerializedAccountHolderChange, error) {
+	change, err := s.pendingChange(p, accountId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	change, err = s.store.AccountHolder.RejectHolderChange(change.ID, p.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := change.Serialize()
+	return &serialized, nil
+}
+
+/*
+holding returns an account the principal's user holds and its holding on it.
+Accounts the principal can't read are reported as not found, the other ones it doesn't hold as forbidden.
+*/
+func (s *accountHolderService) holding(p *types.Principal, accountId uint) (*types.Account, *types.AccountHolder, error) {
+	acc, err := readableAccount(s.store, p, accountId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	holder, err := accountHolder(s.store, p, acc.ID)
+	if err != nil {
+		return nil, nil, err
+	} else if holder == nil {
+		return nil, nil, fmt.Errorf("forbidden")
+	}
+
+	return acc, holder, nil
+}
+
+/*
+pendingChange returns a change of the holders of an account, checking that the principal's user
+is the primary holder of the account, the only one who can decide on it.
+*/
+func (s *accountHolderService) pendingChange(p *types.Principal, accountId uint, id uint) (*types.AccountHolderChange, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid_account_holder_change_id")
+	}
+
+	acc, holder, err := s.holding(p, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	change, err := s.store.AccountHolder.GetHolderChange(id)
+	if err != nil {
+		return nil, err
+	} else if change.AccountID != acc.ID {
+		return nil, fmt.Errorf("account_holder_change_not_found")
+	} else if holder.Role != types.HolderPrimary {
+		return nil, fmt.Errorf("forbidden")
+	} else if change.Status != types.HolderChangePending {
+		return nil, fmt.Errorf("account_holder_change_not_pending")
+	}
+
+	return change, nil
+}
+
+/*
+consent applies a change asked by the primary holder right away, the primary holder consenting to it by asking.
+Changes asked by other holders are left pending.
+*/
+func (s *accountHolderService) consent(holder *types.AccountHolder, change *types.AccountHolderChange) (*types.SerializedAccountHolderChange, error) {
+	if holder.Role == types.HolderPrimary {
+		approved, err := s.store.AccountHolder.ApproveHolderChange(change.ID, holder.UserID)
+		if err != nil {
+			return nil, err
+		}
+		change = approved
+	}
+
+	serialized := change.Serialize()
+	return &serialized, nil
+}
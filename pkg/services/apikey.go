@@ -0,0 +1,219 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/store"
+	"github.com/farischt/gobank/pkg/types"
+)
+
+/*
+An API key is made of a public prefix, used to look the key up, and a secret
+of which only the SHA-256 hash is stored: gbk_<prefix>_<secret>.
+*/
+const apiKeyTag = "gbk"
+
+type ApiKeyService interface {
+	Create(p *types.Principal, data *dto.CreateApiKeyDTO) (*types.SerializedApiKey, error)
+	GetAll(p *types.Principal) ([]*types.SerializedApiKey, error)
+	Revoke(p *types.Principal, id uint) error
+	Authenticate(rawKey string, ip string) (*types.Principal, error)
+}
+
+type apiKeyService struct {
+	store store.Store
+}
+
+func NewApiKeyService(store store.Store) ApiKeyService {
+	return &apiKeyService{
+		store: store,
+	}
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+/*
+Create issues a new API key acting on behalf of the account of the principal's session.
+The plain key is only returned once, in the response of this call.
+*/
+func (a *apiKeyService) Create(p *types.Principal, data *dto.CreateApiKeyDTO) (*types.SerializedApiKey, error) {
+	if p == nil || p.IsApiKey() {
+		return nil, fmt.Errorf("forbidden")
+	} else if p.AccountID == 0 {
+		return nil, fmt.Errorf("account_not_selected")
+	} else if len(strings.TrimSpace(data.Name)) == 0 {
+		return nil, fmt.Errorf("empty_name")
+	} else if len(data.Scopes) == 0 {
+		return nil, fmt.Errorf("empty_scopes")
+	} else if data.ExpiresAt != nil && !data.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("invalid_expires_at")
+	}
+
+	for _, scope := range data.Scopes {
+		if !types.IsValidScope(scope) {
+			return nil, fmt.Errorf("invalid_scope")
+		} else if scope == types.ScopeAdmin && !p.HasRole(types.RoleAdmin) {
+			return nil, fmt.Errorf("invalid_scope")
+		}
+	}
+
+	for _, ip := range data.AllowedIPs {
+		if net.ParseIP(ip) == nil {
+			if _, _, err := net.ParseCIDR(ip); err != nil {
+				return nil, fmt.Errorf("invalid_allowed_ip")
+			}
+		}
+	}
+
+	prefix, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := a.store.ApiKey.CreateApiKey(&types.ApiKey{
+		UserID:     p.UserID,
+		AccountID:  p.AccountID,
+		Name:       strings.TrimSpace(data.Name),
+		Prefix:     prefix,
+		SecretHash: hashSecret(secret),
+		Scopes:     data.Scopes,
+		AllowedIPs: data.AllowedIPs,
+		ExpiresAt:  data.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s := key.Serialize()
+	s.Key = fmt.Sprintf("%s_%s_%s", apiKeyTag, prefix, secret)
+
+	return &s, nil
+}
+
+func (a *apiKeyService) GetAll(p *types.Principal) ([]*types.SerializedApiKey, error) {
+	if p == nil || p.IsApiKey() {
+		return nil, fmt.Errorf("forbidden")
+	}
+
+	keys, err := a.store.ApiKey.GetApiKeysByUser(p.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var serializedKeys []*types.SerializedApiKey
+	for _, k := range keys {
+		s := k.Serialize()
+		serializedKeys = append(serializedKeys, &s)
+	}
+
+	return serializedKeys, nil
+}
+
+func (a *apiKeyService) Revoke(p *types.Principal, id uint) error {
+	if p == nil || p.IsApiKey() {
+		return fmt.Errorf("forbidden")
+	} else if id <= 0 {
+		return fmt.Errorf("invalid_api_key_id")
+	}
+
+	revoked, err := a.store.ApiKey.RevokeApiKey(id, p.UserID)
+	if err != nil {
+		return err
+	} else if !revoked {
+		return fmt.Errorf("api_key_not_found")
+	}
+
+	return nil
+}
+
+/*
+Authenticate resolves the principal behind a raw API key used from the given IP.
+It returns an error if the key is unknown, revoked, expired or used from a
+non allowed IP, and records the key usage otherwise.
+*/
+func (a *apiKeyService) Authenticate(rawKey string, ip string) (*types.Principal, error) {
+	parts := strings.Split(rawKey, "_")
+	if len(parts) != 3 || parts[0] != apiKeyTag {
+		return nil, fmt.Errorf("invalid_api_key")
+	}
+
+	key, err := a.store.ApiKey.GetApiKeyByPrefix(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid_api_key")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key.SecretHash), []byte(hashSecret(parts[2]))) != 1 {
+		return nil, fmt.Errorf("invalid_api_key")
+	} else if !key.IsActive(time.Now()) {
+		return nil, fmt.Errorf("invalid_api_key")
+	} else if !isAllowedIP(key.AllowedIPs, ip) {
+		return nil, fmt.Errorf("ip_not_allowed")
+	}
+
+	p, err := loadPrincipal(a.store, key.UserID, &key.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid_api_key")
+	}
+
+	p.ApiKeyID = key.ID
+	p.ApiKeyPrefix = key.Prefix
+	p.Scopes = key.Scopes
+
+	err = a.store.ApiKey.TouchApiKey(key.ID, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+/*
+isAllowedIP reports whether the ip matches the allow-list.
+An empty allow-list allows every IP.
+*/
+func isAllowedIP(allowed []string, ip string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, a := range allowed {
+		if _, network, err := net.ParseCIDR(a); err == nil {
+			if network.Contains(parsed) {
+				return true
+			}
+		} else if allowedIP := net.ParseIP(a); allowedIP != nil && allowedIP.Equal(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
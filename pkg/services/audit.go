@@ -0,0 +1,62 @@
+package services
+
+import (
+	"github.com/farischt/gobank/pkg/store"
+	"github.com/farischt/gobank/pkg/types"
+)
+
+type AuditService interface {
+	Record(p *types.Principal, perm types.Permission, method string, path string, status int) error
+	GetAll(offset uint, limit uint) ([]*types.SerializedAuditEntry, error)
+}
+
+type auditService struct {
+	store store.Store
+}
+
+func NewAuditService(store store.Store) AuditService {
+	return &auditService{
+		store: store,
+	}
+}
+
+/*
+Record appends a privileged action performed by the principal to the audit log.
+*/
+func (a *auditService) Record(p *types.Principal, perm types.Permission, method string, path string, status int) error {
+	entry := &types.AuditEntry{
+		Permission: string(perm),
+		Method:     method,
+		Path:       path,
+		Status:     status,
+	}
+
+	if p != nil {
+		entry.ActorUserID = &p.UserID
+	}
+
+	return a.store.Audit.CreateAuditEntry(entry)
+}
+
+/*
+recordAnyAccess records in the audit log an access the principal was only granted through
+a permission on any user or account, rather than as their owner or holder.
+*/
+func recordAnyAccess(s store.Store, p *types.Principal, perm types.Permission, method string, path string, status int) error {
+	return NewAuditService(s).Record(p, perm, method, path, status)
+}
+
+func (a *auditService) GetAll(offset uint, limit uint) ([]*types.SerializedAuditEntry, error) {
+	entries, err := a.store.Audit.GetAuditEntries(offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var serializedEntries []*types.SerializedAuditEntry
+	for _, e := range entries {
+		s := e.Serialize()
+		serializedEntries = append(serializedEntries, &s)
+	}
+
+	return serializedEntries, nil
+}
@@ -0,0 +1,492 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/farischt/gobank/config"
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/store"
+	"github.com/farischt/gobank/pkg/types"
+	"github.com/farischt/gobank/utils"
+)
+
+// An amount of a file has at most 2 decimals, and fits a DECIMAL(15,2)
+var batchAmountRegexp = regexp.MustCompile(`^[0-9]{1,13}(\.[0-9]{1,2})?$`)
+
+type BatchService interface {
+	Create(p *types.Principal, accountId uint, data *dto.CreateBatchDTO) (*types.SerializedBatch, error)
+	GetAll(p *types.Principal, accountId uint) ([]*types.SerializedBatch, error)
+	Get(p *types.Principal, accountId uint, id uint) (*types.SerializedBatch, error)
+	Confirm(p *types.Principal, accountId uint, id uint) (*types.SerializedBatch, error)
+	Cancel(p *types.Principal, accountId uint, id uint) (*types.SerializedBatch, error)
+	ResumeStaleBatches(now time.Time) (int, error)
+}
+
+type batchService struct {
+	store    store.Store
+	transfer *transactionService
+}
+
+func NewBatchService(store store.Store) BatchService {
+	return &batchService{
+		store:    store,
+		transfer: &transactionService{store: store},
+	}
+}
+
+/*
+batchMaxLines reads the maximum number of transfers of a batch from BATCH_MAX_LINES, 1000 by default.
+*/
+func batchMaxLines() int {
+	max := config.GetConfig().GetInt(config.BATCH_MAX_LINES)
+	if max <= 0 {
+		max = 1000
+	}
+	return max
+}
+
+/*
+batchStaleAfter reads how long a batch can be left executing with no line recorded before it is
+resumed from BATCH_STALE_AFTER, 15 minutes by default.
+*/
+func batchStaleAfter() time.Duration {
+	after := config.GetConfig().GetDuration(config.BATCH_STALE_AFTER)
+	if after <= 0 {
+		after = 15 * time.Minute
+	}
+	return after
+}
+
+/*
+Create records a batch of transfers from the principal's account and returns it with its lines as a preview.
+Every line is checked as a transfer would be, a line failing the checks making the whole batch invalid.
+The number of transfers and the control sum declared by the file must match its lines.
+*/
+func (s *batchService) Create(p *types.Principal, accountId uint, data *dto.CreateBatchDTO) (*types.SerializedBatch, error) {
+	acc, err := ownAccount(s.store, p, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	format, mode := types.BatchFormat(data.Format), types.BatchMode(data.Mode)
+	if mode == "" {
+		mode = types.BatchAllOrNothing
+	}
+
+	if format != types.BatchPain001 && format != types.BatchCSV {
+		return nil, fmt.Errorf("invalid_format")
+	} else if !mode.IsValid() {
+		return nil, fmt.Errorf("invalid_mode")
+	} else if len(data.Lines) == 0 {
+		return nil, fmt.Errorf("empty_batch")
+	} else if len(data.Lines) > batchMaxLines() {
+		return nil, fmt.Errorf("too_many_lines")
+	} else if data.TransactionCount != nil && *data.TransactionCount != len(data.Lines) {
+		return nil, fmt.Errorf("transaction_count_mismatch")
+	} else if utf8.RuneCountInString(data.MessageID) > 35 {
+		return nil, fmt.Errorf("invalid_message_id")
+	} else if data.DebtorIBAN != "" && (acc.IBAN == nil || types.NormalizeIBAN(data.DebtorIBAN) != *acc.IBAN) {
+		return nil, fmt.Errorf("debtor_account_mismatch")
+	}
+
+	entry := &types.BatchEntry{
+		AccountID: acc.ID,
+		Format:    format,
+		Mode:      mode,
+		Status:    types.BatchPending,
+		Lines:     []types.BatchLineEntry{},
+	}
+	if data.MessageID != "" {
+		entry.MessageID = &data.MessageID
+	}
+
+	for i, l := range data.Lines {
+		line := s.validateLine(acc, l)
+		line.LineNumber = i + 1
+
+		if line.Status == types.BatchLineInvalid {
+			entry.Status = types.BatchInvalid
+		}
+
+		entry.ControlSum += line.Amount
+		entry.Lines = append(entry.Lines, line)
+	}
+	entry.ControlSum = utils.RoundHalfEven(entry.ControlSum, 2)
+
+	if data.ControlSum != nil {
+		if !batchAmountRegexp.MatchString(*data.ControlSum) {
+			return nil, fmt.Errorf("invalid_control_sum")
+		}
+
+		sum, _ := strconv.ParseFloat(*data.ControlSum, 64)
+		if sum != entry.ControlSum {
+			return nil, fmt.Errorf("control_sum_mismatch")
+		}
+	}
+
+	batch, err := s.store.Batch.CreateBatch(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.withLines(batch)
+}
+
+/*
+GetAll returns the batches of an account the principal can read, without their lines.
+*/
+func (s *batchService) GetAll(p *types.Principal, accountId uint) ([]*types.SerializedBatch, error) {
+	_, err := readableAccount(s.store, p, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	batches, err := s.store.Batch.GetBatchesByAccount(accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	serializedBatches := []*types.SerializedBatch{}
+	for _, b := range batches {
+		serialized := b.Serialize()
+		serializedBatches = append(serializedBatches, &serialized)
+	}
+
+	return serializedBatches, nil
+}
+
+/*
+Get returns the report of a batch of an account the principal can read, with the outcome of every line.
+*/
+func (s *batchService) Get(p *types.Principal, accountId uint, id uint) (*types.SerializedBatch, error) {
+	_, err := readableAccount(s.store, p, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := s.accountBatch(accountId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.withLines(batch)
+}
+
+/*
+Confirm executes a pending batch of the principal's account and returns its report.
+An all or nothing batch is executed within a single sql transaction: the first line that fails
+cancels every transfer and the batch fails with it. A best effort batch executes every line
+on its own and records the lines that failed.
+Every line is keyed by the batch and its number, so that it is never executed twice.
+*/
+func (s *batchService) Confirm(p *types.Principal, accountId uint, id uint) (*types.SerializedBatch, error) {
+	_, err := ownAccount(s.store, p, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := s.accountBatch(accountId, id)
+	if err != nil {
+		return nil, err
+	} else if batch.Status == types.BatchInvalid {
+		return nil, fmt.Errorf("batch_invalid")
+	} else if batch.Status != types.BatchPending {
+		return nil, fmt.Errorf("batch_not_pending")
+	}
+
+	lines, err := s.store.Batch.GetBatchLines(batch.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if batch.Mode == types.BatchAllOrNothing {
+		batch, err = s.executeAll(batch, lines)
+	} else {
+		batch, err = s.executeEach(batch, lines)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.withLines(batch)
+}
+
+/*
+Cancel cancels a pending or invalid batch of the principal's account, so that it is never executed.
+*/
+func (s *batchService) Cancel(p *types.Principal, accountId uint, id uint) (*types.SerializedBatch, error) {
+	_, err := ownAccount(s.store, p, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := s.accountBatch(accountId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if batch.Status == types.BatchPending || batch.Status == types.BatchInvalid {
+		batch, err = s.store.Batch.SetBatchStatus(batch.ID, batch.Status, types.BatchCancelled)
+	} else {
+		err = fmt.Errorf("batch_not_pending")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := batch.Serialize()
+	return &serialized, nil
+}
+
+/*
+ResumeStaleBatches executes the remaining lines of the batches left executing, when the
+confirmation executing them stopped midway. The lines already executed or failed are kept,
+and a line is never executed twice, its transfer being keyed by the batch and its number.
+It returns the number of batches resumed.
+*/
+func (s *batchService) ResumeStaleBatches(now time.Time) (int, error) {
+	batches, err := s.store.Batch.ClaimStaleBatches(now.Add(-batchStaleAfter()))
+	if err != nil {
+		return 0, err
+	}
+
+	for i, batch := range batches {
+		lines, err := s.store.Batch.GetBatchLines(batch.ID)
+		if err != nil {
+			return i, err
+		}
+
+		_, err = s.executeLines(batch, lines)
+		if err != nil {
+			return i, err
+		}
+	}
+
+	return len(batches), nil
+}
+
+/*
+executeAll executes every line of a batch within a single sql transaction.
+When a line fails, nothing is executed and the batch is marked failed with the reason of the line.
+*/
+func (s *batchService) executeAll(batch *types.Batch, lines []*types.BatchLine) (*types.Batch, error) {
+	var failed *types.BatchLine
+
+	err := s.store.Batch.RunInTx(func(tx store.BatchTx) error {
+		// A concurrent confirmation waits for this one and finds the batch executed
+		locked, err := tx.LockBatch(batch.ID)
+		if err != nil {
+			return err
+		} else if locked.Status != types.BatchPending {
+			return fmt.Errorf("batch_not_pending")
+		}
+
+		now := time.Now()
+		for _, l := range lines {
+			txn, err := s.transfer.transfer(tx, batch.AccountID, batchTransfer(batch, l), now)
+			if err != nil {
+				failed = l
+				return err
+			}
+
+			err = tx.SetBatchLineResult(l.ID, types.BatchLineExecuted, &txn.ID, nil)
+			if err != nil {
+				return err
+			}
+		}
+
+		batch, err = tx.SetBatchStatus(batch.ID, types.BatchPending, types.BatchCompleted)
+		return err
+	})
+	if err == nil || failed == nil {
+		return batch, err
+	}
+
+	reason := err.Error()
+
+	batch, err = s.store.Batch.SetBatchStatus(batch.ID, types.BatchPending, types.BatchFailed)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.store.Batch.SetBatchLineResult(failed.ID, types.BatchLineFailed, nil, &reason)
+	if err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+/*
+executeEach executes every line of a batch within its own sql transaction, recording the outcome
+of the line along with its transfer.
+The batch is claimed beforehand, so that a concurrent confirmation doesn't execute it too.
+A batch left executing midway is resumed by ResumeStaleBatches.
+*/
+func (s *batchService) executeEach(batch *types.Batch, lines []*types.BatchLine) (*types.Batch, error) {
+	_, err := s.store.Batch.SetBatchStatus(batch.ID, types.BatchPending, types.BatchExecuting)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.executeLines(batch, lines)
+}
+
+/*
+executeLines executes the pending lines of an executing batch, then completes the batch
+according to the outcome of all of its lines.
+*/
+func (s *batchService) executeLines(batch *types.Batch, lines []*types.BatchLine) (*types.Batch, error) {
+	executed := 0
+	for _, l := range lines {
+		if l.Status == types.BatchLineExecuted {
+			executed++
+			continue
+		} else if l.Status != types.BatchLinePending {
+			continue
+		}
+
+		err := s.store.Batch.RunInTx(func(tx store.BatchTx) error {
+			txn, err := s.transfer.transfer(tx, batch.AccountID, batchTransfer(batch, l), time.Now())
+			if err != nil {
+				return err
+			}
+
+			return tx.SetBatchLineResult(l.ID, types.BatchLineExecuted, &txn.ID, nil)
+		})
+		if err != nil {
+			reason := err.Error()
+			err = s.store.Batch.SetBatchLineResult(l.ID, types.BatchLineFailed, nil, &reason)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		executed++
+	}
+
+	status := types.BatchPartiallyCompleted
+	if executed == len(lines) {
+		status = types.BatchCompleted
+	} else if executed == 0 {
+		status = types.BatchFailed
+	}
+
+	return s.store.Batch.SetBatchStatus(batch.ID, types.BatchExecuting, status)
+}
+
+/*
+validateLine checks a line of a batch as a transfer from the account, and resolves its recipient.
+The values of an invalid line are cut to what can be recorded.
+*/
+func (s *batchService) validateLine(acc *types.Account, l dto.BatchLineDTO) types.BatchLineEntry {
+	line := types.BatchLineEntry{
+		ToIBAN: truncate(types.NormalizeIBAN(l.To), 34),
+		Status: types.BatchLinePending,
+	}
+
+	data := &dto.CreateTransactionDTO{
+		To:                l.To,
+		Reference:         l.Reference,
+		CreditorReference: l.CreditorReference,
+	}
+
+	var err error
+	if !batchAmountRegexp.MatchString(l.Amount) {
+		err = fmt.Errorf("invalid_amount")
+	} else if l.Currency != "" && l.Currency != acc.Currency {
+		err = fmt.Errorf("currency_mismatch")
+	} else if utf8.RuneCountInString(l.EndToEndID) > 35 {
+		err = fmt.Errorf("invalid_end_to_end_id")
+	} else {
+		data.Amount, _ = strconv.ParseFloat(l.Amount, 64)
+		err = s.transfer.validateTransfer(acc.UserID, acc.ID, data)
+	}
+
+	line.Amount = data.Amount
+	if data.ToAccountID != 0 {
+		line.To = &data.ToAccountID
+	}
+	if ref := truncate(data.Reference, 140); ref != "" {
+		line.Reference = &ref
+	}
+	if ref := truncate(data.CreditorReference, 25); ref != "" {
+		line.CreditorReference = &ref
+	}
+	if id := truncate(l.EndToEndID, 35); id != "" {
+		line.EndToEndID = &id
+	}
+
+	if err != nil {
+		reason := err.Error()
+		line.Status = types.BatchLineInvalid
+		line.Error = &reason
+	}
+
+	return line
+}
+
+/*
+accountBatch returns a batch of an account, batches of other accounts being reported as not found.
+*/
+func (s *batchService) accountBatch(accountId uint, id uint) (*types.Batch, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid_batch_id")
+	}
+
+	batch, err := s.store.Batch.GetBatch(id)
+	if err != nil {
+		return nil, err
+	} else if batch.AccountID != accountId {
+		return nil, fmt.Errorf("batch_not_found")
+	}
+
+	return batch, nil
+}
+
+/*
+withLines serializes a batch with its lines and their report.
+*/
+func (s *batchService) withLines(batch *types.Batch) (*types.SerializedBatch, error) {
+	lines, err := s.store.Batch.GetBatchLines(batch.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := batch.SerializeWithLines(lines)
+	return &serialized, nil
+}
+
+/*
+batchTransfer gives the transfer of a line of a batch, keyed by the batch and the line number.
+*/
+func batchTransfer(batch *types.Batch, l *types.BatchLine) *dto.CreateTransactionDTO {
+	data := &dto.CreateTransactionDTO{
+		ToAccountID:    *l.To,
+		Amount:         utils.Uint8ToFloat(l.Amount),
+		IdempotencyKey: fmt.Sprintf("batch:%d:%d", batch.ID, l.LineNumber),
+	}
+	if l.Reference != nil {
+		data.Reference = *l.Reference
+	}
+	if l.CreditorReference != nil {
+		data.CreditorReference = *l.CreditorReference
+	}
+
+	return data
+}
+
+/*
+truncate cuts a string to at most n characters.
+*/
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
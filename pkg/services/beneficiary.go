@@ -0,0 +1,278 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/farischt/gobank/config"
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/store"
+	"github.com/farischt/gobank/pkg/types"
+)
+
+type BeneficiaryService interface {
+	Create(p *types.Principal, userId uint, data *dto.CreateBeneficiaryDTO) (*types.SerializedBeneficiary, error)
+	GetAll(p *types.Principal, userId uint) ([]*types.SerializedBeneficiary, error)
+	Get(p *types.Principal, userId uint, id uint) (*types.SerializedBeneficiary, error)
+	Update(p *types.Principal, userId uint, id uint, data *dto.UpdateBeneficiaryDTO) (*types.SerializedBeneficiary, error)
+	Confirm(p *types.Principal, userId uint, id uint) (*types.SerializedBeneficiary, error)
+	Delete(p *types.Principal, userId uint, id uint) error
+}
+
+type beneficiaryService struct {
+	store store.Store
+}
+
+func NewBeneficiaryService(store store.Store) BeneficiaryService {
+	return &beneficiaryService{
+		store: store,
+	}
+}
+
+/*
+CoolingOffError is returned when a transfer to a beneficiary added recently would take the total
+sent to it above the amount allowed during the cooling-off period.
+*/
+type CoolingOffError struct {
+	MaxAmount float64 `json:"max_amount"`
+	// Already sent to the beneficiary during the cooling-off period
+	Sent  float64   `json:"sent"`
+	Until time.Time `json:"until"`
+}
+
+func (e *CoolingOffError) Error() string {
+	return "beneficiary_cooling_off"
+}
+
+/*
+beneficiaryCoolingOff reads how long transfers to a new beneficiary are capped from BENEFICIARY_COOLING_OFF,
+24 hours by default, and the cap from BENEFICIARY_COOLING_OFF_AMOUNT, 500 by default.
+*/
+func beneficiaryCoolingOff() (time.Duration, float64) {
+	c := config.GetConfig()
+
+	period := c.GetDuration(config.BENEFICIARY_COOLING_OFF)
+	if period <= 0 {
+		period = 24 * time.Hour
+	}
+
+	amount := c.GetFloat64(config.BENEFICIARY_COOLING_OFF_AMOUNT)
+	if amount <= 0 {
+		amount = 500
+	}
+
+	return period, amount
+}
+
+/*
+Create saves an account as a beneficiary of the principal's user.
+The name given, if any, is checked against the name of the recipient, and the name of the
+recipient is returned masked so that the user can make sure the account is the right one
+before confirming the beneficiary.
+*/
+func (s *beneficiaryService) Create(p *types.Principal, userId uint, data *dto.CreateBeneficiaryDTO) (*types.SerializedBeneficiary, error) {
+	_, err := ownUser(s.store, p, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	nickname := strings.TrimSpace(data.Nickname)
+	if nickname == "" {
+		return nil, fmt.Errorf("empty_nickname")
+	}
+
+	recipient, err := accountByIBAN(s.store, data.IBAN)
+	if err != nil {
+		return nil, err
+	} else if recipient.IsSystem() {
+		return nil, fmt.Errorf("account_not_found")
+	}
+
+	recipient, err = s.store.Account.GetAccountWithUser(recipient.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := recipient.User.FirstName + " " + recipient.User.LastName
+
+	b := &types.Beneficiary{
+		UserID:     userId,
+		AccountID:  recipient.ID,
+		IBAN:       *recipient.IBAN,
+		Nickname:   nickname,
+		MaskedName: types.MaskName(name),
+		NameMatch:  types.MatchName(data.Name, recipient.User.FirstName, recipient.User.LastName),
+	}
+	if data.DefaultReference != nil && strings.TrimSpace(*data.DefaultReference) != "" {
+		reference := strings.TrimSpace(*data.DefaultReference)
+		b.DefaultReference = &reference
+	}
+
+	b, err = s.store.Beneficiary.CreateBeneficiary(b)
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := b.Serialize()
+	return &serialized, nil
+}
+
+/*
+GetAll returns the beneficiaries of the principal's user.
+*/
+func (s *beneficiaryService) GetAll(p *types.Principal, userId uint) ([]*types.SerializedBeneficiary, error) {
+	_, err := ownUser(s.store, p, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	beneficiaries, err := s.store.Beneficiary.GetBeneficiariesByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	serializedBeneficiaries := []*types.SerializedBeneficiary{}
+	for _, b := range beneficiaries {
+		serialized := b.Serialize()
+		serializedBeneficiaries = append(serializedBeneficiaries, &serialized)
+	}
+
+	return serializedBeneficiaries, nil
+}
+
+/*
+Get returns a beneficiary of the principal's user.
+*/
+func (s *beneficiaryService) Get(p *types.Principal, userId uint, id uint) (*types.SerializedBeneficiary, error) {
+	b, err := s.userBeneficiary(p, userId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := b.Serialize()
+	return &serialized, nil
+}
+
+/*
+Update changes the nickname or the default reference of a beneficiary of the principal's user.
+*/
+func (s *beneficiaryService) Update(p *types.Principal, userId uint, id uint, data *dto.UpdateBeneficiaryDTO) (*types.SerializedBeneficiary, error) {
+	b, err := s.userBeneficiary(p, userId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.Nickname != nil {
+		nickname := strings.TrimSpace(*data.Nickname)
+		if nickname == "" {
+			return nil, fmt.Errorf("empty_nickname")
+		}
+		data.Nickname = &nickname
+	}
+
+	if data.DefaultReference != nil {
+		reference := strings.TrimSpace(*data.DefaultReference)
+		data.DefaultReference = &reference
+	}
+
+	b, err = s.store.Beneficiary.UpdateBeneficiary(b.ID, data)
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := b.Serialize()
+	return &serialized, nil
+}
+
+/*
+Confirm records that the principal checked the masked name of the recipient of a beneficiary,
+which can then be used to send money.
+*/
+func (s *beneficiaryService) Confirm(p *types.Principal, userId uint, id uint) (*types.SerializedBeneficiary, error) {
+	b, err := s.userBeneficiary(p, userId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err = s.store.Beneficiary.ConfirmBeneficiary(b.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := b.Serialize()
+	return &serialized, nil
+}
+
+/*
+Delete deletes a beneficiary of the principal's user.
+*/
+func (s *beneficiaryService) Delete(p *types.Principal, userId uint, id uint) error {
+	b, err := s.userBeneficiary(p, userId, id)
+	if err != nil {
+		return err
+	}
+
+	return s.store.Beneficiary.DeleteBeneficiary(b.ID)
+}
+
+/*
+userBeneficiary returns a beneficiary of the principal's user.
+A beneficiary of another user is reported as not found.
+*/
+func (s *beneficiaryService) userBeneficiary(p *types.Principal, userId uint, id uint) (*types.Beneficiary, error) {
+	_, err := ownUser(s.store, p, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid_beneficiary_id")
+	}
+
+	b, err := s.store.Beneficiary.GetBeneficiary(id)
+	if err != nil {
+		return nil, err
+	} else if b.UserID != userId {
+		return nil, fmt.Errorf("beneficiary_not_found")
+	}
+
+	return b, nil
+}
+
+/*
+beneficiaryRecipient returns the beneficiary a transfer made by the user is made to.
+The beneficiary must belong to the user and be confirmed, so that a joint holder only pays
+its own beneficiaries, and during the cooling-off period following its creation the total
+the user sends to it is capped.
+*/
+func beneficiaryRecipient(s store.Store, userId uint, id uint, amount float64, now time.Time) (*types.Beneficiary, error) {
+	b, err := s.Beneficiary.GetBeneficiary(id)
+	if err != nil {
+		return nil, err
+	} else if b.UserID != userId {
+		return nil, fmt.Errorf("beneficiary_not_found")
+	} else if b.ConfirmedAt == nil {
+		return nil, fmt.Errorf("beneficiary_not_confirmed")
+	}
+
+	period, maxAmount := beneficiaryCoolingOff()
+	until := b.CreatedAt.Add(period)
+	if !now.Before(until) {
+		return b, nil
+	}
+
+	// Splitting an amount over several transfers doesn't get around the cap
+	sent, err := s.Transaction.GetSentByUserToAccountSince(userId, b.AccountID, b.CreatedAt)
+	if err != nil {
+		return nil, err
+	} else if sent+amount > maxAmount {
+		return nil, &CoolingOffError{
+			MaxAmount: maxAmount,
+			Sent:      sent,
+			Until:     until,
+		}
+	}
+
+	return b, nil
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+/*
+normalizeEmail validates the syntax of an email address (RFC 5322 addr-spec)
+and returns it trimmed and case folded.
+*/
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	if len(email) == 0 {
+		return "", fmt.Errorf("empty_email")
+	}
+
+	addr, err := mail.ParseAddress(email)
+	// Reject display names and angle brackets, only a bare address is accepted
+	if err != nil || addr.Name != "" || addr.Address != email {
+		return "", fmt.Errorf("invalid_email")
+	}
+
+	at := strings.LastIndex(addr.Address, "@")
+	if at <= 0 || at == len(addr.Address)-1 || len(addr.Address) > 254 {
+		return "", fmt.Errorf("invalid_email")
+	}
+
+	return strings.ToLower(addr.Address), nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/farischt/gobank/config"
+	"github.com/farischt/gobank/pkg/types"
+	"github.com/farischt/gobank/utils"
+)
+
+/*
+feeIncomeAccount is the code of the system account fees are paid to.
+*/
+const feeIncomeAccount = "fee_income"
+
+/*
+feeRule reads the fee rule of a transaction type on an account type from the configuration:
+FEE_<TRANSACTION TYPE>_<ACCOUNT TYPE>_{KIND,AMOUNT,MIN,MAX,FREE_PER_MONTH}.
+It returns nil when no fee is configured.
+*/
+func feeRule(txnType types.TransactionType, accountType types.AccountType) *types.FeeRule {
+	c := config.GetConfig()
+	prefix := fmt.Sprintf("%s_%s_%s", config.FEE, strings.ToUpper(string(txnType)), strings.ToUpper(string(accountType)))
+
+	kind := types.FeeKind(c.GetString(prefix + "_KIND"))
+	if !kind.IsValid() {
+		return nil
+	}
+
+	return &types.FeeRule{
+		Kind:         kind,
+		Amount:       c.GetFloat64(prefix + "_AMOUNT"),
+		Min:          c.GetFloat64(prefix + "_MIN"),
+		Max:          c.GetFloat64(prefix + "_MAX"),
+		FreePerMonth: c.GetInt(prefix + "_FREE_PER_MONTH"),
+	}
+}
+
+/*
+startOfMonth returns the first instant of the month of the given time.
+*/
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+/*
+quoteFee computes the fee of a transaction of the given amount, given the number of
+transactions already made this month.
+*/
+func quoteFee(rule *types.FeeRule, amount float64, sentThisMonth int) *types.TransferQuote {
+	quote := &types.TransferQuote{
+		Amount: amount,
+		Total:  amount,
+		Rule:   rule,
+	}
+
+	if rule == nil {
+		return quote
+	}
+
+	if rule.FreePerMonth > 0 {
+		remaining := int(math.Max(0, float64(rule.FreePerMonth-sentThisMonth)))
+		quote.FreeRemaining = &remaining
+
+		if remaining > 0 {
+			return quote
+		}
+	}
+
+	fee := rule.Amount
+	if rule.Kind == types.FeePercentage {
+		fee = amount * rule.Amount
+		if rule.Min > 0 {
+			fee = math.Max(fee, rule.Min)
+		}
+		if rule.Max > 0 {
+			fee = math.Min(fee, rule.Max)
+		}
+	}
+
+	quote.Fee = utils.RoundHalfEven(math.Max(0, fee), 2)
+	quote.Total = amount + quote.Fee
+
+	return quote
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/farischt/gobank/config"
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/store"
+	"github.com/farischt/gobank/pkg/types"
+	"github.com/farischt/gobank/utils"
+)
+
+type FxService interface {
+	SetRate(p *types.Principal, data *dto.SetFxRateDTO) (*types.SerializedFxRate, error)
+	GetRates() ([]*types.SerializedFxRate, error)
+	ImportCSV(path string) (int, error)
+}
+
+type fxService struct {
+	store store.Store
+}
+
+func NewFxService(store store.Store) FxService {
+	return &fxService{
+		store: store,
+	}
+}
+
+/*
+fxRater gives the latest rate of a currency pair, it is implemented by the store and by sql transactions.
+*/
+type fxRater interface {
+	GetFxRate(base string, quote string) (*types.FxRate, error)
+}
+
+/*
+defaultCurrency reads the currency of the accounts opened without one from DEFAULT_CURRENCY, EUR by default.
+*/
+func defaultCurrency() string {
+	currency := strings.ToUpper(config.GetConfig().GetString(config.DEFAULT_CURRENCY))
+	if !types.IsValidCurrency(currency) {
+		currency = "EUR"
+	}
+	return currency
+}
+
+/*
+fxMaxStaleness reads how old a rate can be to still be used from FX_MAX_STALENESS, 24 hours by default.
+*/
+func fxMaxStaleness() time.Duration {
+	staleness := config.GetConfig().GetDuration(config.FX_MAX_STALENESS)
+	if staleness <= 0 {
+		staleness = 24 * time.Hour
+	}
+	return staleness
+}
+
+/*
+fxRate returns the number of units of the quote currency one unit of the base currency buys.
+The rate of the inverse pair is used when the pair itself has none, whichever is used
+must not be older than FX_MAX_STALENESS.
+*/
+func fxRate(r fxRater, base string, quote string, now time.Time) (float64, error) {
+	if base == quote {
+		return 1, nil
+	}
+
+	rate, err := r.GetFxRate(base, quote)
+	if err != nil {
+		return 0, err
+	}
+
+	inverse := false
+	if rate == nil {
+		rate, err = r.GetFxRate(quote, base)
+		if err != nil {
+			return 0, err
+		} else if rate == nil {
+			return 0, fmt.Errorf("fx_rate_not_found")
+		}
+		inverse = true
+	}
+
+	if now.Sub(rate.AsOf) > fxMaxStaleness() {
+		return 0, fmt.Errorf("fx_rate_stale")
+	}
+
+	value := utils.Uint8ToFloat(rate.Rate)
+	if inverse {
+		value = 1 / value
+	}
+
+	return value, nil
+}
+
+/*
+bookLegs sets the currencies of a transaction entry and the amount credited to the recipient,
+converted from the amount debited when the currencies differ.
+*/
+func bookLegs(r fxRater, entry *types.TransactionEntry, from string, to string, now time.Time) error {
+	entry.Currency = from
+	entry.ToCurrency = to
+	entry.ToAmount = entry.Amount
+	entry.FxRate = nil
+
+	if from == to {
+		return nil
+	}
+
+	rate, err := fxRate(r, from, to, now)
+	if err != nil {
+		return err
+	}
+
+	entry.ToAmount = utils.RoundHalfEven(entry.Amount*rate, 2)
+	entry.FxRate = &rate
+
+	return nil
+}
+
+/*
+SetRate records the rate of a currency pair, on behalf of a principal with the fx:manage permission.
+*/
+func (s *fxService) SetRate(p *types.Principal, data *dto.SetFxRateDTO) (*types.SerializedFxRate, error) {
+	if !p.Can(types.PermFxManage) {
+		return nil, fmt.Errorf("forbidden")
+	}
+
+	return s.setRate(data, "manual")
+}
+
+/*
+GetRates returns the latest rate of every currency pair.
+*/
+func (s *fxService) GetRates() ([]*types.SerializedFxRate, error) {
+	rates, err := s.store.Fx.GetLatestFxRates()
+	if err != nil {
+		return nil, err
+	}
+
+	serializedRates := []*types.SerializedFxRate{}
+	for _, r := range rates {
+		serialized := r.Serialize()
+		serializedRates = append(serializedRates, &serialized)
+	}
+
+	return serializedRates, nil
+}
+
+/*
+ImportCSV records the rates of a CSV file with base,quote,rate,as_of lines, as_of being
+RFC 3339 and required so that a rate the file kept unchanged doesn't look fresh when imported again.
+A first line starting with "base" is a header and skipped.
+Rates not newer than the latest rate recorded for their pair are skipped, so the same file can be imported again.
+It returns the number of rates recorded.
+*/
+func (s *fxService) ImportCSV(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	imported := 0
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return imported, err
+		}
+
+		if line == 1 && strings.EqualFold(record[0], "base") {
+			continue
+		} else if len(record) < 3 {
+			return imported, fmt.Errorf("invalid fx rate on line %d", line)
+		} else if len(record) < 4 || record[3] == "" {
+			return imported, fmt.Errorf("missing fx rate date on line %d", line)
+		}
+
+		data := &dto.SetFxRateDTO{
+			Base:  record[0],
+			Quote: record[1],
+		}
+
+		data.Rate, err = strconv.ParseFloat(record[2], 64)
+		if err != nil {
+			return imported, fmt.Errorf("invalid fx rate on line %d", line)
+		}
+
+		asOf, err := time.Parse(time.RFC3339, record[3])
+		if err != nil {
+			return imported, fmt.Errorf("invalid fx rate date on line %d", line)
+		}
+		data.AsOf = &asOf
+
+		latest, err := s.store.Fx.GetFxRate(strings.ToUpper(data.Base), strings.ToUpper(data.Quote))
+		if err != nil {
+			return imported, err
+		} else if latest != nil && !data.AsOf.After(latest.AsOf) {
+			continue
+		}
+
+		_, err = s.setRate(data, "csv")
+		if err != nil {
+			return imported, fmt.Errorf("%s on line %d", err, line)
+		}
+		imported++
+	}
+
+	return imported, nil
+}
+
+func (s *fxService) setRate(data *dto.SetFxRateDTO, source string) (*types.SerializedFxRate, error) {
+	data.Base = strings.ToUpper(strings.TrimSpace(data.Base))
+	data.Quote = strings.ToUpper(strings.TrimSpace(data.Quote))
+
+	if !types.IsValidCurrency(data.Base) || !types.IsValidCurrency(data.Quote) || data.Base == data.Quote {
+		return nil, fmt.Errorf("invalid_currency")
+	} else if data.Rate <= 0 {
+		return nil, fmt.Errorf("invalid_rate")
+	} else if data.AsOf != nil && data.AsOf.After(time.Now()) {
+		return nil, fmt.Errorf("invalid_as_of")
+	}
+
+	rate, err := s.store.Fx.CreateFxRate(data, source)
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := rate.Serialize()
+	return &serialized, nil
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/farischt/gobank/config"
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/store"
+	"github.com/farischt/gobank/pkg/types"
+	"github.com/farischt/gobank/utils"
+)
+
+type HoldService interface {
+	Create(p *types.Principal, accountId uint, data *dto.CreateHoldDTO) (*types.SerializedHold, error)
+	GetAll(p *types.Principal, accountId uint) ([]*types.SerializedHold, error)
+	Get(p *types.Principal, accountId uint, id uint) (*types.SerializedHold, error)
+	Capture(p *types.Principal, accountId uint, id uint, data *dto.CaptureHoldDTO) (*types.SerializedHold, error)
+	Release(p *types.Principal, accountId uint, id uint) (*types.SerializedHold, error)
+	ExpireHolds(now time.Time) (int64, error)
+}
+
+type holdService struct {
+	store    store.Store
+	transfer *transactionService
+}
+
+func NewHoldService(store store.Store) HoldService {
+	return &holdService{
+		store:    store,
+		transfer: &transactionService{store: store},
+	}
+}
+
+/*
+holdTTL reads how long a hold lasts before it expires from HOLD_TTL, 7 days by default.
+*/
+func holdTTL() time.Duration {
+	ttl := config.GetConfig().GetDuration(config.HOLD_TTL)
+	if ttl <= 0 {
+		ttl = 7 * 24 * time.Hour
+	}
+	return ttl
+}
+
+/*
+Create reserves an amount of the principal's account for a later transfer to the recipient.
+The account must be able to send the amount from its available balance.
+*/
+func (s *holdService) Create(p *types.Principal, accountId uint, data *dto.CreateHoldDTO) (*types.SerializedHold, error) {
+	_, err := ownAccount(s.store, p, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.Amount <= 0 {
+		return nil, fmt.Errorf("invalid_amount")
+	}
+
+	to, err := accountByIBAN(s.store, data.To)
+	if err != nil {
+		return nil, err
+	} else if to.ID == accountId {
+		return nil, fmt.Errorf("cannot_transfer_to_yourself")
+	}
+
+	var hold *types.Hold
+	err = s.store.Hold.RunInTx(func(tx store.HoldTx) error {
+		accounts, err := tx.LockAccounts(accountId, to.ID)
+		if err != nil {
+			return err
+		}
+
+		sender, recipient := accounts[accountId], accounts[to.ID]
+
+		if !sender.Status.CanSend() {
+			return fmt.Errorf("account_%s", sender.Status)
+		} else if !recipient.Status.CanReceive() {
+			return fmt.Errorf("recipient_account_%s", recipient.Status)
+		}
+
+		serialized := sender.Serialize()
+		if !s.transfer.HasEnoughBalance(sender.Type, &serialized, data.Amount) {
+			return fmt.Errorf("insufficient_balance")
+		}
+
+		hold, err = tx.CreateHold(accountId, to.ID, data.Amount, time.Now().Add(holdTTL()))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := hold.Serialize()
+	return &serialized, nil
+}
+
+/*
+GetAll returns the holds placed on an account the principal can read.
+*/
+func (s *holdService) GetAll(p *types.Principal, accountId uint) ([]*types.SerializedHold, error) {
+	_, err := readableAccount(s.store, p, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	holds, err := s.store.Hold.GetHoldsByAccount(accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	serializedHolds := []*types.SerializedHold{}
+	for _, h := range holds {
+		serialized := h.Serialize()
+		serializedHolds = append(serializedHolds, &serialized)
+	}
+
+	return serializedHolds, nil
+}
+
+/*
+Get returns a hold placed on an account, for a principal that can read the account
+or that is the recipient of the hold.
+*/
+func (s *holdService) Get(p *types.Principal, accountId uint, id uint) (*types.SerializedHold, error) {
+	hold, err := s.accountHold(p, accountId, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := hold.Serialize()
+	return &serialized, nil
+}
+
+/*
+Capture turns an active hold into a transfer to its recipient, on behalf of the account owner
+or the recipient. Capturing less than the hold releases the rest.
+The transfer goes through the usual transfer checks, with the hold released beforehand.
+*/
+func (s *holdService) Capture(p *types.Principal, accountId uint, id uint, data *dto.CaptureHoldDTO) (*types.SerializedHold, error) {
+	hold, err := s.accountHold(p, accountId, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.Amount != nil && *data.Amount <= 0 {
+		return nil, fmt.Errorf("invalid_amount")
+	}
+
+	err = s.store.Hold.RunInTx(func(tx store.HoldTx) error {
+		// Lock both accounts before the hold, in the same order as expiries and transfers do,
+		// so that the transfer doesn't lock the recipient after another transaction locked it
+		_, err := tx.LockAccounts(hold.AccountID, hold.To)
+		if err != nil {
+			return err
+		}
+
+		hold, err = tx.LockHold(hold.ID)
+		if err != nil {
+			return err
+		} else if hold.Status != types.HoldActive {
+			return fmt.Errorf("hold_not_active")
+		} else if !hold.ExpiresAt.After(time.Now()) {
+			return fmt.Errorf("hold_expired")
+		}
+
+		amount := utils.Uint8ToFloat(hold.Amount)
+		if data.Amount != nil {
+			if *data.Amount > amount {
+				return fmt.Errorf("capture_exceeds_hold")
+			}
+			amount = *data.Amount
+		}
+
+		_, err = tx.CloseHold(hold.ID, types.HoldCaptured, &amount)
+		if err != nil {
+			return err
+		}
+
+		txn, err := s.transfer.transfer(tx, hold.AccountID, &dto.CreateTransactionDTO{
+			ToAccountID: hold.To,
+			Amount:      amount,
+		}, time.Now())
+		if err != nil {
+			return err
+		}
+
+		hold, err = tx.SetHoldTransaction(hold.ID, txn.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := hold.Serialize()
+	return &serialized, nil
+}
+
+/*
+Release gives the amount of an active hold back to the account, on behalf of the account
+owner or the recipient.
+*/
+func (s *holdService) Release(p *types.Principal, accountId uint, id uint) (*types.SerializedHold, error) {
+	hold, err := s.accountHold(p, accountId, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.store.Hold.RunInTx(func(tx store.HoldTx) error {
+		_, err := tx.LockAccounts(hold.AccountID)
+		if err != nil {
+			return err
+		}
+
+		hold, err = tx.CloseHold(hold.ID, types.HoldReleased, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := hold.Serialize()
+	return &serialized, nil
+}
+
+/*
+ExpireHolds expires the active holds past their expiry time.
+*/
+func (s *holdService) ExpireHolds(now time.Time) (int64, error) {
+	return s.store.Hold.ExpireHolds(now)
+}
+
+/*
+accountHold returns a hold placed on the given account, if the principal can read the
+account or is the recipient of the hold. Only the holders who can send money from the account
+and the recipient can manage it, that is capture or release it.
+*/
+func (s *holdService) accountHold(p *types.Principal, accountId uint, id uint, manage bool) (*types.Hold, error) {
+	if accountId <= 0 {
+		return nil, fmt.Errorf("invalid_account_id")
+	} else if id <= 0 {
+		return nil, fmt.Errorf("invalid_hold_id")
+	}
+
+	hold, err := s.store.Hold.GetHold(id)
+	if err != nil {
+		return nil, err
+	} else if hold.AccountID != accountId {
+		return nil, fmt.Errorf("hold_not_found")
+	}
+
+	if p.AccountID == hold.To {
+		return hold, nil
+	}
+
+	acc, err := readableAccount(s.store, p, accountId)
+	if err != nil {
+		return nil, fmt.Errorf("hold_not_found")
+	} else if !manage {
+		return hold, nil
+	}
+
+	transact, err := canTransact(s.store, p, acc)
+	if err != nil {
+		return nil, err
+	} else if !transact {
+		return nil, fmt.Errorf("forbidden")
+	}
+
+	return hold, nil
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/farischt/gobank/config"
+	"github.com/farischt/gobank/pkg/store"
+	"github.com/farischt/gobank/pkg/types"
+)
+
+// Number of random digits following the bank code in the BBAN
+const ibanAccountDigits = 12
+
+var (
+	ibanCountryFormat  = regexp.MustCompile(`^[A-Z]{2}$`)
+	ibanBankCodeFormat = regexp.MustCompile(`^[A-Z0-9]{1,18}$`)
+)
+
+/*
+ibanCountry reads the country of the IBANs from IBAN_COUNTRY_CODE, FR by default.
+*/
+func ibanCountry() string {
+	country := strings.ToUpper(config.GetConfig().GetString(config.IBAN_COUNTRY_CODE))
+	if !ibanCountryFormat.MatchString(country) {
+		country = "FR"
+	}
+	return country
+}
+
+/*
+ibanBankCode reads the bank code starting the BBAN of the IBANs from IBAN_BANK_CODE, GOBK by default.
+*/
+func ibanBankCode() string {
+	code := strings.ToUpper(config.GetConfig().GetString(config.IBAN_BANK_CODE))
+	if !ibanBankCodeFormat.MatchString(code) {
+		code = "GOBK"
+	}
+	return code
+}
+
+/*
+newIBAN generates the IBAN of a new account.
+The account number is random rather than derived from the account id, so that it can't be guessed.
+*/
+func newIBAN() (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(ibanAccountDigits), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+
+	bban := fmt.Sprintf("%s%0*d", ibanBankCode(), ibanAccountDigits, n)
+	return types.NewIBAN(ibanCountry(), bban), nil
+}
+
+/*
+withNewIBAN calls fn with newly generated IBANs until one isn't already taken, a few times at most.
+*/
+func withNewIBAN(fn func(iban string) error) error {
+	var err error
+	for i := 0; i < 3; i++ {
+		var iban string
+		iban, err = newIBAN()
+		if err != nil {
+			return err
+		}
+
+		err = fn(iban)
+		if err == nil || err.Error() != "iban_taken" {
+			return err
+		}
+	}
+	return err
+}
+
+/*
+accountByIBAN returns the account of an IBAN given by a client.
+It returns invalid_iban if the IBAN is malformed or its check digits are wrong,
+so that a typo is reported as such rather than sending money to someone else.
+*/
+func accountByIBAN(s store.Store, iban string) (*types.Account, error) {
+	iban = types.NormalizeIBAN(iban)
+	if !types.IsValidIBAN(iban) {
+		return nil, fmt.Errorf("invalid_iban")
+	}
+
+	return s.Account.GetAccountByIBAN(iban)
+}
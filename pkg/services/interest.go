@@ -0,0 +1,250 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/farischt/gobank/config"
+	"github.com/farischt/gobank/pkg/store"
+	"github.com/farischt/gobank/pkg/types"
+	"github.com/farischt/gobank/utils"
+)
+
+/*
+interestExpenseAccount is the code of the system account interest is paid from.
+*/
+const interestExpenseAccount = "interest_expense"
+
+/*
+errNothingToCapitalize rolls back a capitalization that was already made for the business date
+or that would pay nothing.
+*/
+var errNothingToCapitalize = errors.New("nothing_to_capitalize")
+
+type InterestService interface {
+	RunBusinessDate(businessDate time.Time) (*InterestRun, error)
+	LastBusinessDate() (*time.Time, error)
+}
+
+/*
+InterestRun sums up what was done for a business date.
+Accounts already processed for the business date are not counted again.
+*/
+type InterestRun struct {
+	BusinessDate time.Time
+	Accrued      int
+	Capitalized  int
+}
+
+type interestService struct {
+	store store.Store
+}
+
+func NewInterestService(store store.Store) InterestService {
+	return &interestService{
+		store: store,
+	}
+}
+
+/*
+interestRate reads the annual rate and the day-count convention of an account type from the configuration:
+INTEREST_RATE_<TYPE> and INTEREST_DAY_COUNT_<TYPE>, ACT/365 by default.
+*/
+func interestRate(t types.AccountType) (float64, types.DayCount) {
+	c := config.GetConfig()
+	suffix := strings.ToUpper(string(t))
+
+	dayCount := types.DayCount(c.GetString(fmt.Sprintf("%s_%s", config.INTEREST_DAY_COUNT, suffix)))
+	if !dayCount.IsValid() {
+		dayCount = types.DayCountAct365
+	}
+
+	return c.GetFloat64(fmt.Sprintf("%s_%s", config.INTEREST_RATE, suffix)), dayCount
+}
+
+/*
+BusinessDate returns the calendar date of the given time, as midnight UTC.
+*/
+func BusinessDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+/*
+isMonthEnd reports whether the business date is the last day of its month.
+*/
+func isMonthEnd(businessDate time.Time) bool {
+	return businessDate.AddDate(0, 0, 1).Month() != businessDate.Month()
+}
+
+/*
+dailyInterest returns the interest earned by a balance over one day, kept to 8 decimals.
+Negative balances earn nothing.
+*/
+func dailyInterest(balance float64, rate float64, dayCount types.DayCount) float64 {
+	if balance <= 0 || rate <= 0 {
+		return 0
+	}
+
+	return utils.RoundHalfEven(balance*rate/dayCount.YearDays(), 8)
+}
+
+/*
+RunBusinessDate accrues a day of interest on every interest bearing account and, at the
+end of a month, pays the accrued interest into the accounts.
+Running the same business date again does nothing for the accounts already processed.
+*/
+func (s *interestService) RunBusinessDate(businessDate time.Time) (*InterestRun, error) {
+	businessDate = BusinessDate(businessDate)
+	run := &InterestRun{BusinessDate: businessDate}
+
+	for _, t := range []types.AccountType{types.AccountChecking, types.AccountSavings, types.AccountTermDeposit} {
+		rate, dayCount := interestRate(t)
+		if rate <= 0 {
+			continue
+		}
+
+		ids, err := s.store.Interest.GetInterestBearingAccounts(t, businessDate)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range ids {
+			accrued, err := s.accrue(id, businessDate, rate, dayCount)
+			if err != nil {
+				return nil, err
+			} else if accrued {
+				run.Accrued++
+			}
+
+			if !isMonthEnd(businessDate) {
+				continue
+			}
+
+			capitalized, err := s.capitalize(id, businessDate)
+			if err != nil {
+				return nil, err
+			} else if capitalized {
+				run.Capitalized++
+			}
+		}
+	}
+
+	return run, nil
+}
+
+/*
+LastBusinessDate returns the last business date interest was accrued for, nil if none.
+*/
+func (s *interestService) LastBusinessDate() (*time.Time, error) {
+	return s.store.Interest.GetLastBusinessDate()
+}
+
+/*
+accrue adds the interest of the business date to the accrued interest of an account,
+earned by the balance of the account at the end of the business date.
+*/
+func (s *interestService) accrue(accountId uint, businessDate time.Time, rate float64, dayCount types.DayCount) (bool, error) {
+	accrued := false
+
+	err := s.store.Interest.RunInTx(func(tx store.InterestTx) error {
+		_, err := tx.LockAccounts(accountId)
+		if err != nil {
+			return err
+		}
+
+		// A late run must not accrue on what moved after the business date
+		balance, err := tx.GetBalanceAt(accountId, businessDate.AddDate(0, 0, 1))
+		if err != nil {
+			return err
+		}
+
+		accrued, err = tx.CreateAccrual(&types.InterestAccrual{
+			AccountID:    accountId,
+			BusinessDate: businessDate,
+			Balance:      balance,
+			Rate:         rate,
+			DayCount:     dayCount,
+			Amount:       dailyInterest(balance, rate, dayCount),
+		})
+		return err
+	})
+
+	return accrued, err
+}
+
+/*
+capitalize pays the accrued interest of an account, rounded to the cent, from the interest
+expense account. The rounding remainder stays accrued for the next month.
+*/
+func (s *interestService) capitalize(accountId uint, businessDate time.Time) (bool, error) {
+	err := s.store.Interest.RunInTx(func(tx store.InterestTx) error {
+		expense, err := tx.GetSystemAccount(interestExpenseAccount)
+		if err != nil {
+			return err
+		}
+
+		accounts, err := tx.LockAccounts(accountId, expense.ID)
+		if err != nil {
+			return err
+		}
+
+		account := accounts[accountId]
+		amount := utils.RoundHalfEven(account.Serialize().AccruedInterest, 2)
+		if amount <= 0 {
+			return errNothingToCapitalize
+		}
+
+		// Interest is in the currency of the account, the expense is booked in the currency of the expense account
+		entry := &types.TransactionEntry{
+			Type:       types.TransactionInterest,
+			From:       expense.ID,
+			To:         accountId,
+			Amount:     amount,
+			Currency:   expense.Currency,
+			ToAmount:   amount,
+			ToCurrency: account.Currency,
+		}
+		if expense.Currency != account.Currency {
+			rate, err := fxRate(tx, expense.Currency, account.Currency, time.Now())
+			if err != nil {
+				return err
+			}
+			entry.Amount = utils.RoundHalfEven(amount/rate, 2)
+			entry.FxRate = &rate
+		}
+
+		txn, err := tx.CreateTxn(entry)
+		if err != nil {
+			return err
+		}
+
+		ok, err := tx.CreateCapitalization(&types.InterestCapitalization{
+			AccountID:     accountId,
+			BusinessDate:  businessDate,
+			Amount:        amount,
+			TransactionID: txn.ID,
+		})
+		if err != nil {
+			return err
+		} else if !ok {
+			return errNothingToCapitalize
+		}
+
+		err = tx.Debit(expense.ID, entry.Amount)
+		if err != nil {
+			return err
+		}
+
+		return tx.Credit(accountId, entry.ToAmount)
+	})
+
+	if err == errNothingToCapitalize {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
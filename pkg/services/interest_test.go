@@ -0,0 +1,340 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/farischt/gobank/config"
+	"github.com/farischt/gobank/pkg/store"
+	"github.com/farischt/gobank/pkg/types"
+)
+
+func TestIsMonthEnd(t *testing.T) {
+	tests := []struct {
+		date string
+		want bool
+	}{
+		{"2024-01-31", true},
+		{"2024-01-30", false},
+		{"2024-02-28", false},
+		{"2024-02-29", true},
+		{"2023-02-28", true},
+		{"2024-04-30", true},
+		{"2024-12-31", true},
+		{"2024-12-01", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			date, err := time.Parse("2006-01-02", tt.date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := isMonthEnd(date); got != tt.want {
+				t.Errorf("isMonthEnd(%s) = %v, want %v", tt.date, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDailyInterest(t *testing.T) {
+	tests := []struct {
+		name     string
+		balance  float64
+		rate     float64
+		dayCount types.DayCount
+		want     float64
+	}{
+		{"ACT/365", 1000, 0.0365, types.DayCountAct365, 0.1},
+		{"ACT/360", 1000, 0.036, types.DayCountAct360, 0.1},
+		{"kept to 8 decimals", 1000, 0.05, types.DayCountAct365, 0.1369863},
+		{"zero balance", 0, 0.05, types.DayCountAct365, 0},
+		{"negative balance", -1000, 0.05, types.DayCountAct365, 0},
+		{"zero rate", 1000, 0, types.DayCountAct365, 0},
+		{"negative rate", 1000, -0.01, types.DayCountAct365, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dailyInterest(tt.balance, tt.rate, tt.dayCount); got != tt.want {
+				t.Errorf("dailyInterest(%v, %v, %s) = %v, want %v", tt.balance, tt.rate, tt.dayCount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunBusinessDate(t *testing.T) {
+	useInterestConfig(t, "INTEREST_RATE_SAVINGS=0.0365\nINTEREST_DAY_COUNT_SAVINGS=ACT/365\n")
+
+	// 500 were received after the business date, so 1000 earn interest on it
+	f := newFakeInterestStore()
+	f.balances[1] = 1500
+	f.receivedSince[1] = 500
+	f.accrued[1] = 0.2
+	s := NewInterestService(store.Store{Interest: f})
+
+	businessDate := time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC)
+
+	run, err := s.RunBusinessDate(businessDate)
+	if err != nil {
+		t.Fatal(err)
+	} else if run.Accrued != 1 || run.Capitalized != 1 {
+		t.Fatalf("first run accrued %d and capitalized %d, want 1 and 1", run.Accrued, run.Capitalized)
+	}
+
+	accrual := f.accruals[accrualKey(1, businessDate)]
+	if accrual == nil {
+		t.Fatal("no accrual recorded for the business date")
+	} else if accrual.Balance != 1000 {
+		t.Errorf("accrued on a balance of %v, want the end of day balance 1000", accrual.Balance)
+	} else if accrual.Amount != 0.1 {
+		t.Errorf("accrued %v, want 0.1", accrual.Amount)
+	}
+
+	if got := f.balances[1]; math.Abs(got-1500.3) > 1e-9 {
+		t.Errorf("balance after capitalization = %v, want 1500.3", got)
+	} else if got := f.accrued[1]; math.Abs(got) > 1e-9 {
+		t.Errorf("accrued interest after capitalization = %v, want 0", got)
+	}
+
+	// Running the business date again must change nothing
+	run, err = s.RunBusinessDate(businessDate)
+	if err != nil {
+		t.Fatal(err)
+	} else if run.Accrued != 0 || run.Capitalized != 0 {
+		t.Fatalf("second run accrued %d and capitalized %d, want 0 and 0", run.Accrued, run.Capitalized)
+	}
+
+	if got := f.balances[1]; math.Abs(got-1500.3) > 1e-9 {
+		t.Errorf("balance after the second run = %v, want 1500.3", got)
+	} else if got := f.accrued[1]; math.Abs(got) > 1e-9 {
+		t.Errorf("accrued interest after the second run = %v, want 0", got)
+	} else if len(f.txns) != 1 {
+		t.Errorf("%d interest transactions made, want 1", len(f.txns))
+	}
+}
+
+func TestRunBusinessDateFebruary(t *testing.T) {
+	useInterestConfig(t, "INTEREST_RATE_SAVINGS=0.0365\nINTEREST_DAY_COUNT_SAVINGS=ACT/365\n")
+
+	tests := []struct {
+		name     string
+		dates    []string
+		monthEnd string
+	}{
+		{"leap year", []string{"2024-02-28", "2024-02-29", "2024-03-01"}, "2024-02-29"},
+		{"non-leap year", []string{"2023-02-27", "2023-02-28", "2023-03-01"}, "2023-02-28"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeInterestStore()
+			f.balances[1] = 1000
+			s := NewInterestService(store.Store{Interest: f})
+
+			for _, d := range tt.dates {
+				businessDate, err := time.Parse("2006-01-02", d)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				want := 0
+				if d == tt.monthEnd {
+					want = 1
+				}
+
+				run, err := s.RunBusinessDate(businessDate)
+				if err != nil {
+					t.Fatal(err)
+				} else if run.Accrued != 1 || run.Capitalized != want {
+					t.Fatalf("%s accrued %d and capitalized %d, want 1 and %d", d, run.Accrued, run.Capitalized, want)
+				} else if f.accruals[accrualKey(1, businessDate)] == nil {
+					t.Fatalf("no accrual recorded for %s", d)
+				}
+			}
+
+			// The two days of February accrued 0.1 each, the first of March accrues on the capitalized balance
+			monthEnd, _ := time.Parse("2006-01-02", tt.monthEnd)
+			if len(f.capitalizations) != 1 {
+				t.Fatalf("%d capitalizations made, want 1", len(f.capitalizations))
+			} else if c := f.capitalizations[accrualKey(1, monthEnd)]; c == nil {
+				t.Fatalf("no capitalization on %s", tt.monthEnd)
+			} else if c.Amount != 0.2 {
+				t.Errorf("capitalized %v, want 0.2", c.Amount)
+			}
+
+			if got := f.balances[1]; math.Abs(got-1000.2) > 1e-9 {
+				t.Errorf("balance = %v, want 1000.2", got)
+			} else if got := f.accrued[1]; math.Abs(got-0.10002) > 1e-9 {
+				t.Errorf("accrued interest = %v, want 0.10002 accrued in March", got)
+			}
+		})
+	}
+}
+
+/*
+useInterestConfig loads the given configuration as the base configuration for the test.
+*/
+func useInterestConfig(t *testing.T, env string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, ".env.test"), []byte(env), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	} else if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(wd)
+	})
+
+	config.InitBaseConfig("test")
+}
+
+const fakeExpenseAccount uint = 99
+
+func accrualKey(accountId uint, businessDate time.Time) string {
+	return fmt.Sprintf("%d:%s", accountId, businessDate.Format("2006-01-02"))
+}
+
+/*
+fakeInterestStore keeps the interest bearing savings accounts in memory.
+A failing transaction is rolled back by restoring the state it started from.
+*/
+type fakeInterestStore struct {
+	balances        map[uint]float64
+	receivedSince   map[uint]float64
+	accrued         map[uint]float64
+	accruals        map[string]*types.InterestAccrual
+	capitalizations map[string]*types.InterestCapitalization
+	txns            []*types.TransactionEntry
+}
+
+func newFakeInterestStore() *fakeInterestStore {
+	return &fakeInterestStore{
+		balances:        map[uint]float64{fakeExpenseAccount: 0},
+		receivedSince:   map[uint]float64{},
+		accrued:         map[uint]float64{},
+		accruals:        map[string]*types.InterestAccrual{},
+		capitalizations: map[string]*types.InterestCapitalization{},
+	}
+}
+
+func (f *fakeInterestStore) GetInterestBearingAccounts(accountType types.AccountType, businessDate time.Time) ([]uint, error) {
+	if accountType != types.AccountSavings {
+		return []uint{}, nil
+	}
+	return []uint{1}, nil
+}
+
+func (f *fakeInterestStore) GetLastBusinessDate() (*time.Time, error) {
+	return nil, nil
+}
+
+func (f *fakeInterestStore) RunInTx(fn func(tx store.InterestTx) error) error {
+	balances, accrued, txns := copyFloats(f.balances), copyFloats(f.accrued), len(f.txns)
+
+	err := fn(&fakeInterestTx{f: f})
+	if err != nil {
+		f.balances, f.accrued, f.txns = balances, accrued, f.txns[:txns]
+	}
+
+	return err
+}
+
+func copyFloats(m map[uint]float64) map[uint]float64 {
+	c := make(map[uint]float64, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+/*
+fakeInterestTx implements the operations of store.InterestTx used by the interest service.
+*/
+type fakeInterestTx struct {
+	store.TransferTx
+	f *fakeInterestStore
+}
+
+func (t *fakeInterestTx) account(id uint) *types.Account {
+	acc := &types.Account{
+		ID:              id,
+		Balance:         []uint8(fmt.Sprint(t.f.balances[id])),
+		AccruedInterest: []uint8(fmt.Sprint(t.f.accrued[id])),
+		Type:            types.AccountSavings,
+		Currency:        "EUR",
+	}
+	if id == fakeExpenseAccount {
+		code := interestExpenseAccount
+		acc.SystemCode = &code
+	}
+	return acc
+}
+
+func (t *fakeInterestTx) LockAccounts(ids ...uint) (map[uint]*types.Account, error) {
+	accounts := make(map[uint]*types.Account)
+	for _, id := range ids {
+		accounts[id] = t.account(id)
+	}
+	return accounts, nil
+}
+
+func (t *fakeInterestTx) GetSystemAccount(code string) (*types.Account, error) {
+	if code != interestExpenseAccount {
+		return nil, fmt.Errorf("account_not_found")
+	}
+	return t.account(fakeExpenseAccount), nil
+}
+
+func (t *fakeInterestTx) GetBalanceAt(accountId uint, at time.Time) (float64, error) {
+	return t.f.balances[accountId] - t.f.receivedSince[accountId], nil
+}
+
+func (t *fakeInterestTx) CreateTxn(entry *types.TransactionEntry) (*types.Transaction, error) {
+	t.f.txns = append(t.f.txns, entry)
+	return &types.Transaction{ID: uint(len(t.f.txns))}, nil
+}
+
+func (t *fakeInterestTx) Debit(accountId uint, amount float64) error {
+	t.f.balances[accountId] -= amount
+	return nil
+}
+
+func (t *fakeInterestTx) Credit(accountId uint, amount float64) error {
+	t.f.balances[accountId] += amount
+	return nil
+}
+
+func (t *fakeInterestTx) CreateAccrual(a *types.InterestAccrual) (bool, error) {
+	key := accrualKey(a.AccountID, a.BusinessDate)
+	if t.f.accruals[key] != nil {
+		return false, nil
+	}
+
+	t.f.accruals[key] = a
+	t.f.accrued[a.AccountID] += a.Amount
+	return true, nil
+}
+
+func (t *fakeInterestTx) CreateCapitalization(c *types.InterestCapitalization) (bool, error) {
+	key := accrualKey(c.AccountID, c.BusinessDate)
+	if t.f.capitalizations[key] != nil {
+		return false, nil
+	}
+
+	t.f.capitalizations[key] = c
+	t.f.accrued[c.AccountID] -= c.Amount
+	return true, nil
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/farischt/gobank/config"
+	"github.com/farischt/gobank/pkg/types"
+	"github.com/farischt/gobank/utils"
+)
+
+/*
+LimitExceededError is returned when a transfer would exceed one of the transfer limits of the sender.
+It details the limit hit and the remaining allowance.
+*/
+type LimitExceededError struct {
+	Limit     string  `json:"limit"`
+	Max       float64 `json:"max"`
+	Remaining float64 `json:"remaining"`
+}
+
+func (e *LimitExceededError) Error() string {
+	return "limit_exceeded"
+}
+
+/*
+typeLimits reads the transfer limits of an account type from the configuration:
+TRANSFER_LIMIT_<TYPE>_MAX_AMOUNT, TRANSFER_LIMIT_<TYPE>_DAILY_AMOUNT and TRANSFER_LIMIT_<TYPE>_DAILY_COUNT.
+*/
+func typeLimits(t types.AccountType) types.TransferLimits {
+	c := config.GetConfig()
+	prefix := fmt.Sprintf("%s_%s", config.TRANSFER_LIMIT, strings.ToUpper(string(t)))
+
+	return types.TransferLimits{
+		MaxAmount:   c.GetFloat64(prefix + "_MAX_AMOUNT"),
+		DailyAmount: c.GetFloat64(prefix + "_DAILY_AMOUNT"),
+		DailyCount:  c.GetInt(prefix + "_DAILY_COUNT"),
+	}
+}
+
+/*
+effectiveLimits returns the limits of the account type, overridden by the limits set on the account.
+*/
+func effectiveLimits(a *types.Account) types.TransferLimits {
+	limits := typeLimits(a.Type)
+
+	if len(a.MaxTransferAmount) > 0 {
+		limits.MaxAmount = utils.Uint8ToFloat(a.MaxTransferAmount)
+	}
+	if len(a.DailyTransferAmount) > 0 {
+		limits.DailyAmount = utils.Uint8ToFloat(a.DailyTransferAmount)
+	}
+	if a.DailyTransferCount != nil {
+		limits.DailyCount = *a.DailyTransferCount
+	}
+
+	return limits
+}
+
+/*
+startOfDay returns the first instant of the day of the given time.
+*/
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+/*
+checkLimits returns a LimitExceededError if a transfer of the given amount would exceed
+the limits, given what has already been sent today.
+*/
+func checkLimits(limits types.TransferLimits, amount float64, today *types.OutgoingTotals) error {
+	if limits.MaxAmount > 0 && amount > limits.MaxAmount {
+		return &LimitExceededError{
+			Limit:     "max_amount",
+			Max:       limits.MaxAmount,
+			Remaining: limits.MaxAmount,
+		}
+	}
+
+	if limits.DailyAmount > 0 && today.Amount+amount > limits.DailyAmount {
+		return &LimitExceededError{
+			Limit:     "daily_amount",
+			Max:       limits.DailyAmount,
+			Remaining: math.Max(0, limits.DailyAmount-today.Amount),
+		}
+	}
+
+	if limits.DailyCount > 0 && today.Count+1 > limits.DailyCount {
+		return &LimitExceededError{
+			Limit:     "daily_count",
+			Max:       float64(limits.DailyCount),
+			Remaining: math.Max(0, float64(limits.DailyCount-today.Count)),
+		}
+	}
+
+	return nil
+}
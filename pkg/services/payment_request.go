@@ -0,0 +1,338 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/farischt/gobank/config"
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/store"
+	"github.com/farischt/gobank/pkg/types"
+	"github.com/farischt/gobank/utils"
+)
+
+// Longest a payment request can be left open
+const maxPaymentRequestTTL = 90 * 24 * time.Hour
+
+type PaymentRequestService interface {
+	Create(p *types.Principal, data *dto.CreatePaymentRequestDTO) (*types.SerializedPaymentRequest, error)
+	GetSent(p *types.Principal) ([]*types.SerializedPaymentRequest, error)
+	GetInbox(p *types.Principal) ([]*types.SerializedPaymentRequest, error)
+	Get(p *types.Principal, id uint) (*types.SerializedPaymentRequest, error)
+	Accept(p *types.Principal, id uint) (*types.SerializedPaymentRequest, error)
+	Decline(p *types.Principal, id uint) (*types.SerializedPaymentRequest, error)
+	Cancel(p *types.Principal, id uint) (*types.SerializedPaymentRequest, error)
+	ExpirePaymentRequests(now time.Time) (int64, error)
+}
+
+type paymentRequestService struct {
+	store    store.Store
+	transfer *transactionService
+}
+
+func NewPaymentRequestService(store store.Store) PaymentRequestService {
+	return &paymentRequestService{
+		store:    store,
+		transfer: &transactionService{store: store},
+	}
+}
+
+/*
+paymentRequestTTL reads how long a payment request lasts by default from PAYMENT_REQUEST_TTL, 7 days by default.
+*/
+func paymentRequestTTL() time.Duration {
+	ttl := config.GetConfig().GetDuration(config.PAYMENT_REQUEST_TTL)
+	if ttl <= 0 {
+		ttl = 7 * 24 * time.Hour
+	}
+	return ttl
+}
+
+/*
+Create asks the payer to pay an amount to the principal's account, in the currency of both accounts.
+A payer that can't be asked, whatever the reason, is reported as invalid_payer.
+A request with an idempotency key already used by the principal's account isn't made again,
+the request made the first time is returned instead.
+*/
+func (s *paymentRequestService) Create(p *types.Principal, data *dto.CreatePaymentRequestDTO) (*types.SerializedPaymentRequest, error) {
+	requester, err := ownAccount(s.store, p, p.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	data.Reason = strings.TrimSpace(data.Reason)
+	now := time.Now()
+
+	if data.Amount <= 0 || utils.RoundHalfEven(data.Amount, 2) != data.Amount {
+		return nil, fmt.Errorf("invalid_amount")
+	} else if utf8.RuneCountInString(data.Reason) > 140 {
+		return nil, fmt.Errorf("invalid_reason")
+	} else if len(data.IdempotencyKey) > 255 {
+		return nil, fmt.Errorf("invalid_idempotency_key")
+	} else if data.ExpiresAt != nil && (!data.ExpiresAt.After(now) || data.ExpiresAt.After(now.Add(maxPaymentRequestTTL))) {
+		return nil, fmt.Errorf("invalid_expires_at")
+	}
+
+	if data.IdempotencyKey != "" {
+		previous, err := s.store.PaymentRequest.GetPaymentRequestByIdempotencyKey(requester.ID, data.IdempotencyKey)
+		if err != nil {
+			return nil, err
+		} else if previous != nil {
+			return s.previousRequest(previous, data)
+		}
+	}
+
+	// Every failure on the payer's side is the same error, so that the accounts of others can't be probed
+	payer, err := accountByIBAN(s.store, data.Payer)
+	if err != nil && (err.Error() == "invalid_iban" || err.Error() == "account_not_found") {
+		return nil, fmt.Errorf("invalid_payer")
+	} else if err != nil {
+		return nil, err
+	} else if payer.ID == requester.ID {
+		return nil, fmt.Errorf("cannot_request_from_yourself")
+	} else if payer.Currency != requester.Currency || !payer.Status.CanSend() {
+		return nil, fmt.Errorf("invalid_payer")
+	}
+
+	expiresAt := now.Add(paymentRequestTTL())
+	if data.ExpiresAt != nil {
+		expiresAt = *data.ExpiresAt
+	}
+
+	request, err := s.store.PaymentRequest.CreatePaymentRequest(requester.ID, payer.ID, data, expiresAt)
+	if err != nil && err.Error() == "payment_request_already_exist" {
+		// Made concurrently with the same key
+		previous, err := s.store.PaymentRequest.GetPaymentRequestByIdempotencyKey(requester.ID, data.IdempotencyKey)
+		if err != nil {
+			return nil, err
+		}
+		return s.previousRequest(previous, data)
+	} else if err != nil {
+		return nil, err
+	}
+
+	serialized := request.Serialize()
+	return &serialized, nil
+}
+
+/*
+GetSent returns the payment requests made by the principal's account.
+*/
+func (s *paymentRequestService) GetSent(p *types.Principal) ([]*types.SerializedPaymentRequest, error) {
+	acc, err := ownAccount(s.store, p, p.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	requests, err := s.store.PaymentRequest.GetPaymentRequestsByRequester(acc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return serializePaymentRequests(requests), nil
+}
+
+/*
+GetInbox returns the payment requests the principal's account is asked to pay and can still accept.
+*/
+func (s *paymentRequestService) GetInbox(p *types.Principal) ([]*types.SerializedPaymentRequest, error) {
+	acc, err := ownAccount(s.store, p, p.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	requests, err := s.store.PaymentRequest.GetPendingPaymentRequestsByPayer(acc.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return serializePaymentRequests(requests), nil
+}
+
+/*
+Get returns a payment request made by or to the principal's account.
+*/
+func (s *paymentRequestService) Get(p *types.Principal, id uint) (*types.SerializedPaymentRequest, error) {
+	request, err := s.ownRequest(p, id)
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := request.Serialize()
+	return &serialized, nil
+}
+
+/*
+Accept pays a payment request made to the principal's account with a transfer to the requester,
+the reason of the request being the reference of the transfer.
+The request is locked while it is paid and the transfer is keyed by the request, so that a
+request is never paid twice. Accepting a request already accepted returns it as is,
+and a request can't be accepted by a payer whose account can't send money.
+*/
+func (s *paymentRequestService) Accept(p *types.Principal, id uint) (*types.SerializedPaymentRequest, error) {
+	request, err := s.ownRequest(p, id)
+	if err != nil {
+		return nil, err
+	} else if request.PayerID != p.AccountID {
+		return nil, fmt.Errorf("forbidden")
+	}
+
+	payer, err := ownAccount(s.store, p, request.PayerID)
+	if err != nil {
+		return nil, err
+	} else if !payer.Status.CanSend() {
+		return nil, fmt.Errorf("payer_account_%s", payer.Status)
+	}
+
+	err = s.store.PaymentRequest.RunInTx(func(tx store.PaymentRequestTx) error {
+		locked, err := tx.LockPaymentRequest(request.ID)
+		if err != nil {
+			return err
+		} else if locked.Status != types.PaymentRequestPending {
+			return nil
+		} else if !locked.ExpiresAt.After(time.Now()) {
+			return fmt.Errorf("payment_request_expired")
+		}
+
+		data := &dto.CreateTransactionDTO{
+			ToAccountID:    locked.RequesterID,
+			Amount:         utils.Uint8ToFloat(locked.Amount),
+			IdempotencyKey: fmt.Sprintf("payment_request:%d", locked.ID),
+		}
+		if locked.Reason != nil {
+			data.Reference = *locked.Reason
+		}
+
+		txn, err := s.transfer.transfer(tx, locked.PayerID, data, time.Now())
+		if err != nil {
+			return err
+		}
+
+		return tx.AcceptPaymentRequest(locked.ID, txn.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.closed(request.ID, types.PaymentRequestAccepted)
+}
+
+/*
+Decline closes a payment request made to the principal's account without paying it.
+Declining a request already declined returns it as is.
+*/
+func (s *paymentRequestService) Decline(p *types.Principal, id uint) (*types.SerializedPaymentRequest, error) {
+	request, err := s.ownRequest(p, id)
+	if err != nil {
+		return nil, err
+	} else if request.PayerID != p.AccountID {
+		return nil, fmt.Errorf("forbidden")
+	} else if _, err := ownAccount(s.store, p, request.PayerID); err != nil {
+		return nil, err
+	}
+
+	return s.close(request, types.PaymentRequestDeclined)
+}
+
+/*
+Cancel withdraws a payment request made by the principal's account.
+Cancelling a request already cancelled returns it as is.
+*/
+func (s *paymentRequestService) Cancel(p *types.Principal, id uint) (*types.SerializedPaymentRequest, error) {
+	request, err := s.ownRequest(p, id)
+	if err != nil {
+		return nil, err
+	} else if request.RequesterID != p.AccountID {
+		return nil, fmt.Errorf("forbidden")
+	} else if _, err := ownAccount(s.store, p, request.RequesterID); err != nil {
+		return nil, err
+	}
+
+	return s.close(request, types.PaymentRequestCancelled)
+}
+
+/*
+ExpirePaymentRequests closes every pending payment request past its expiry time.
+*/
+func (s *paymentRequestService) ExpirePaymentRequests(now time.Time) (int64, error) {
+	return s.store.PaymentRequest.ExpirePaymentRequests(now)
+}
+
+/*
+close closes a pending payment request with the given status.
+*/
+func (s *paymentRequestService) close(request *types.PaymentRequest, status types.PaymentRequestStatus) (*types.SerializedPaymentRequest, error) {
+	if request.Status == types.PaymentRequestPending {
+		_, err := s.store.PaymentRequest.ClosePaymentRequest(request.ID, status)
+		if err != nil && err.Error() != "payment_request_not_pending" {
+			return nil, err
+		}
+	}
+
+	return s.closed(request.ID, status)
+}
+
+/*
+closed returns a payment request expected to be closed with the given status, or the reason it isn't.
+*/
+func (s *paymentRequestService) closed(id uint, status types.PaymentRequestStatus) (*types.SerializedPaymentRequest, error) {
+	request, err := s.store.PaymentRequest.GetPaymentRequest(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Status != status {
+		if request.Status == types.PaymentRequestExpired || !request.ExpiresAt.After(time.Now()) {
+			return nil, fmt.Errorf("payment_request_expired")
+		}
+		return nil, fmt.Errorf("payment_request_not_pending")
+	}
+
+	serialized := request.Serialize()
+	return &serialized, nil
+}
+
+/*
+ownRequest returns a payment request made by or to the principal's account.
+Requests of other accounts are reported as not found.
+*/
+func (s *paymentRequestService) ownRequest(p *types.Principal, id uint) (*types.PaymentRequest, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid_payment_request_id")
+	}
+
+	request, err := s.store.PaymentRequest.GetPaymentRequest(id)
+	if err != nil {
+		return nil, err
+	} else if p.AccountID == 0 || (request.RequesterID != p.AccountID && request.PayerID != p.AccountID) {
+		return nil, fmt.Errorf("payment_request_not_found")
+	}
+
+	return request, nil
+}
+
+/*
+previousRequest returns the request made the first time with an idempotency key, if it was made
+for the same payer and amount.
+*/
+func (s *paymentRequestService) previousRequest(previous *types.PaymentRequest, data *dto.CreatePaymentRequestDTO) (*types.SerializedPaymentRequest, error) {
+	if previous.PayerIBAN == nil || *previous.PayerIBAN != types.NormalizeIBAN(data.Payer) ||
+		utils.Uint8ToFloat(previous.Amount) != data.Amount {
+		return nil, fmt.Errorf("idempotency_key_reused")
+	}
+
+	serialized := previous.Serialize()
+	return &serialized, nil
+}
+
+func serializePaymentRequests(requests []*types.PaymentRequest) []*types.SerializedPaymentRequest {
+	serializedRequests := []*types.SerializedPaymentRequest{}
+	for _, r := range requests {
+		serialized := r.Serialize()
+		serializedRequests = append(serializedRequests, &serialized)
+	}
+
+	return serializedRequests
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/farischt/gobank/pkg/store"
+	"github.com/farischt/gobank/pkg/types"
+)
+
+/*
+loadPrincipal builds the principal of a user acting on behalf of the given account, if any,
+with the roles of the user. It fails for deleted users, for accounts that can't log in
+and for accounts the user doesn't hold.
+*/
+func loadPrincipal(s store.Store, userId uint, accountId *uint) (*types.Principal, error) {
+	user, err := s.User.GetUserByID(userId)
+	if err != nil {
+		return nil, err
+	} else if user.IsDeleted() {
+		return nil, fmt.Errorf("user_not_found")
+	}
+
+	p := &types.Principal{
+		UserID: user.ID,
+	}
+
+	if accountId != nil {
+		a, err := s.Account.GetAccount(*accountId)
+		if err != nil {
+			return nil, err
+		} else if !a.Status.CanLogin() {
+			return nil, fmt.Errorf("account_%s", a.Status)
+		}
+
+		holder, err := s.AccountHolder.GetAccountHolder(a.ID, user.ID)
+		if err != nil {
+			return nil, err
+		} else if holder == nil {
+			return nil, fmt.Errorf("forbidden")
+		}
+
+		p.AccountID = a.ID
+	}
+
+	roles, err := s.Role.GetUserRoles(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range roles {
+		p.Roles = append(p.Roles, r.Role)
+	}
+
+	return p, nil
+}
+
+/*
+readableAccount returns an account the principal can read.
+*/
+func readableAccount(s store.Store, p *types.Principal, accountId uint) (*types.Account, error) {
+	if accountId <= 0 {
+		return nil, fmt.Errorf("invalid_account_id")
+	}
+
+	acc, err := s.Account.GetAccount(accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	readable, err := canReadAccount(s, p, acc)
+	if err != nil {
+		return nil, err
+	} else if !readable {
+		return nil, fmt.Errorf("account_not_found")
+	}
+
+	return acc, nil
+}
+
+/*
+ownAccount returns an account the principal can send money from: an account its user holds with
+a role allowing to transact, the account of the key only for API keys.
+*/
+func ownAccount(s store.Store, p *types.Principal, accountId uint) (*types.Account, error) {
+	acc, err := readableAccount(s, p, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	transact, err := canTransact(s, p, acc)
+	if err != nil {
+		return nil, err
+	} else if !transact {
+		return nil, fmt.Errorf("forbidden")
+	}
+
+	return acc, nil
+}
+
+/*
+accountHolder returns the holding of the principal's user on an account, nil if it holds none.
+API keys only act on behalf of their own account and never on the other accounts of their user.
+*/
+func accountHolder(s store.Store, p *types.Principal, accountId uint) (*types.AccountHolder, error) {
+	if p == nil || p.IsApiKey() {
+		return nil, nil
+	}
+
+	return s.AccountHolder.GetAccountHolder(accountId, p.UserID)
+}
+
+/*
+canReadAccount reports whether the principal can read an account, either as one of the holders
+of the account or as allowed by its roles. A read only allowed by the roles is audited.
+*/
+func canReadAccount(s store.Store, p *types.Principal, acc *types.Account) (bool, error) {
+	if p == nil || acc == nil {
+		return false, nil
+	} else if p.UserID == acc.UserID {
+		return true, nil
+	}
+
+	holder, err := accountHolder(s, p, acc.ID)
+	if err != nil {
+		return false, err
+	} else if holder != nil {
+		return true, nil
+	} else if !p.CanReadAccount(acc) {
+		return false, nil
+	}
+
+	err = recordAnyAccess(s, p, types.PermAccountReadAny, http.MethodGet, fmt.Sprintf("/account/%d", acc.ID), http.StatusOK)
+	return err == nil, err
+}
+
+/*
+canTransact reports whether the principal can send money from an account,
+its user holding the account with a role allowing to transact.
+*/
+func canTransact(s store.Store, p *types.Principal, acc *types.Account) (bool, error) {
+	if p == nil || (p.IsApiKey() && acc.ID != p.AccountID) {
+		return false, nil
+	}
+
+	// API keys can't do more on their account than the user they were issued to
+	holder, err := s.AccountHolder.GetAccountHolder(acc.ID, p.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	return holder != nil && holder.Role.CanTransact(), nil
+}
+
+/*
+ownUser returns the user with the given id if it is the principal's own user.
+Users the principal cannot read are reported as not found, other users as forbidden.
+*/
+func ownUser(s store.Store, p *types.Principal, id uint) (*types.User, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid_user_id")
+	} else if !p.CanReadUser(id) {
+		return nil, fmt.Errorf("user_not_found")
+	} else if p.UserID != id || p.IsApiKey() {
+		return nil, fmt.Errorf("forbidden")
+	}
+
+	user, err := s.User.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	} else if user.IsDeleted() {
+		return nil, fmt.Errorf("user_not_found")
+	}
+
+	return user, nil
+}
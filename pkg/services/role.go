@@ -0,0 +1,87 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/farischt/gobank/pkg/store"
+	"github.com/farischt/gobank/pkg/types"
+)
+
+type RoleService interface {
+	GetRoles(userId uint) ([]*types.SerializedUserRole, error)
+	Grant(p *types.Principal, userId uint, role types.Role) error
+	Revoke(p *types.Principal, userId uint, role types.Role) error
+}
+
+type roleService struct {
+	store store.Store
+}
+
+func NewRoleService(store store.Store) RoleService {
+	return &roleService{
+		store: store,
+	}
+}
+
+func (r *roleService) GetRoles(userId uint) ([]*types.SerializedUserRole, error) {
+	if userId <= 0 {
+		return nil, fmt.Errorf("invalid_user_id")
+	}
+
+	_, err := r.store.User.GetUserByID(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := r.store.Role.GetUserRoles(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	var serializedRoles []*types.SerializedUserRole
+	for _, role := range roles {
+		s := role.Serialize()
+		serializedRoles = append(serializedRoles, &s)
+	}
+
+	return serializedRoles, nil
+}
+
+func (r *roleService) Grant(p *types.Principal, userId uint, role types.Role) error {
+	if !p.Can(types.PermRoleManage) {
+		return fmt.Errorf("forbidden")
+	} else if userId <= 0 {
+		return fmt.Errorf("invalid_user_id")
+	} else if !role.IsValid() {
+		return fmt.Errorf("invalid_role")
+	}
+
+	_, err := r.store.User.GetUserByID(userId)
+	if err != nil {
+		return err
+	}
+
+	return r.store.Role.GrantRole(userId, role, p.UserID)
+}
+
+func (r *roleService) Revoke(p *types.Principal, userId uint, role types.Role) error {
+	if !p.Can(types.PermRoleManage) {
+		return fmt.Errorf("forbidden")
+	} else if userId <= 0 {
+		return fmt.Errorf("invalid_user_id")
+	} else if !role.IsValid() {
+		return fmt.Errorf("invalid_role")
+	} else if userId == p.UserID && role == types.RoleAdmin {
+		// Prevents the last admin from locking everyone out
+		return fmt.Errorf("cannot_revoke_own_admin_role")
+	}
+
+	revoked, err := r.store.Role.RevokeRole(userId, role)
+	if err != nil {
+		return err
+	} else if !revoked {
+		return fmt.Errorf("role_not_granted")
+	}
+
+	return nil
+}
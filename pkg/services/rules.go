@@ -0,0 +1,106 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/farischt/gobank/config"
+	"github.com/farischt/gobank/pkg/types"
+)
+
+/*
+transferContext is what the account type rules know about a transfer being made.
+*/
+type transferContext struct {
+	sender    *types.Account
+	recipient *types.Account
+	amount    float64
+	now       time.Time
+	// outgoingCount returns the number of transfers sent by the sender since the given time
+	outgoingCount func(since time.Time) (int, error)
+}
+
+/*
+accountTypeRules holds the rules specific to an account type.
+*/
+type accountTypeRules interface {
+	// checkOutgoing returns an error if the sender can't make the transfer
+	checkOutgoing(ctx *transferContext) error
+	// allowsOverdraft reports whether the balance of the account may go negative
+	allowsOverdraft() bool
+}
+
+/*
+rulesFor returns the rule set of an account type.
+*/
+func rulesFor(t types.AccountType) accountTypeRules {
+	switch t {
+	case types.AccountSavings:
+		return &savingsRules{}
+	case types.AccountTermDeposit:
+		return &termDepositRules{}
+	default:
+		return &checkingRules{}
+	}
+}
+
+/*
+checkingRules: no restriction on outgoing transfers, overdraft allowed.
+*/
+type checkingRules struct{}
+
+func (r *checkingRules) checkOutgoing(ctx *transferContext) error {
+	return nil
+}
+
+func (r *checkingRules) allowsOverdraft() bool {
+	return true
+}
+
+/*
+savingsRules: outgoing transfers only to the owner's own accounts,
+at most SAVINGS_MAX_MONTHLY_TRANSFERS per calendar month.
+*/
+type savingsRules struct{}
+
+func (r *savingsRules) checkOutgoing(ctx *transferContext) error {
+	if ctx.recipient.UserID != ctx.sender.UserID {
+		return fmt.Errorf("savings_transfer_to_other_owner")
+	}
+
+	max := config.GetConfig().GetInt(config.SAVINGS_MAX_MONTHLY_TRANSFERS)
+	if max <= 0 {
+		max = 6
+	}
+
+	monthStart := time.Date(ctx.now.Year(), ctx.now.Month(), 1, 0, 0, 0, 0, ctx.now.Location())
+	count, err := ctx.outgoingCount(monthStart)
+	if err != nil {
+		return err
+	} else if count >= max {
+		return fmt.Errorf("savings_monthly_transfer_limit_reached")
+	}
+
+	return nil
+}
+
+func (r *savingsRules) allowsOverdraft() bool {
+	return false
+}
+
+/*
+termDepositRules: the funds are locked until the maturity date.
+*/
+type termDepositRules struct{}
+
+func (r *termDepositRules) checkOutgoing(ctx *transferContext) error {
+	if ctx.sender.MaturityDate == nil || ctx.now.Before(*ctx.sender.MaturityDate) {
+		return fmt.Errorf("term_deposit_not_matured")
+	}
+
+	return nil
+}
+
+func (r *termDepositRules) allowsOverdraft() bool {
+	return false
+}
@@ -1,19 +1,48 @@
 package services
 
-import "github.com/farischt/gobank/pkg/store"
+import (
+	"github.com/farischt/gobank/pkg/mailer"
+	"github.com/farischt/gobank/pkg/store"
+)
 
 type Service struct {
-	Account     AccountService
-	User        UserService
-	Transaction TransactionService
-	Session     SessionService
+	Account        AccountService
+	AccountHolder  AccountHolderService
+	User           UserService
+	Transaction    TransactionService
+	Session        SessionService
+	Role           RoleService
+	Audit          AuditService
+	ApiKey         ApiKeyService
+	Interest       InterestService
+	StandingOrder  StandingOrderService
+	Hold           HoldService
+	Fx             FxService
+	Beneficiary    BeneficiaryService
+	Statement      StatementService
+	Batch          BatchService
+	PaymentRequest PaymentRequestService
 }
 
-func New(store store.Store) *Service {
+func New(store store.Store, mailer mailer.Mailer) *Service {
+	transaction := NewTransactionService(store)
+
 	return &Service{
-		Account:     NewAccountService(store),
-		User:        NewUserService(store),
-		Transaction: NewTransactionService(store),
-		Session:     NewSessionService(store),
+		Account:        NewAccountService(store),
+		AccountHolder:  NewAccountHolderService(store),
+		User:           NewUserService(store, mailer),
+		Transaction:    transaction,
+		Session:        NewSessionService(store),
+		Role:           NewRoleService(store),
+		Audit:          NewAuditService(store),
+		ApiKey:         NewApiKeyService(store),
+		Interest:       NewInterestService(store),
+		StandingOrder:  NewStandingOrderService(store, transaction),
+		Hold:           NewHoldService(store),
+		Fx:             NewFxService(store),
+		Beneficiary:    NewBeneficiaryService(store),
+		Statement:      NewStatementService(store),
+		Batch:          NewBatchService(store),
+		PaymentRequest: NewPaymentRequestService(store),
 	}
 }
@@ -2,8 +2,11 @@ package services
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/farischt/gobank/config"
+	"github.com/farischt/gobank/pkg/dto"
 	"github.com/farischt/gobank/pkg/store"
 	"github.com/farischt/gobank/pkg/types"
 	"golang.org/x/crypto/bcrypt"
@@ -12,8 +15,9 @@ import (
 type SessionService interface {
 	Get(tokenId string) (*types.SerializedSessionToken, error)
 	comparePassword(hashedPassword string, password []byte) bool
-	Create(accountId uint, password string) (*types.SerializedSessionToken, error)
+	Create(data *dto.LoginDTO) (*types.SerializedSessionToken, error)
 	IsValidSessionToken(tokenId string) (*types.SerializedSessionToken, bool)
+	GetPrincipal(tokenId string) (*types.Principal, error)
 	Delete(tokenId string) error
 }
 
@@ -42,27 +46,159 @@ func (s *sessionService) comparePassword(hashedPassword string, password []byte)
 	return err == nil
 }
 
-func (s *sessionService) Create(accountId uint, password string) (*types.SerializedSessionToken, error) {
+/*
+legacyAccountLogin reads from LEGACY_ACCOUNT_LOGIN whether users can still log in with
+the number and the password of an account, on by default.
+*/
+func legacyAccountLogin() bool {
+	c := config.GetConfig()
+	return !c.IsSet(config.LEGACY_ACCOUNT_LOGIN) || c.GetBool(config.LEGACY_ACCOUNT_LOGIN)
+}
+
+/*
+Create logs a user in with its email and password. The session acts on the account given by
+its number, which the user must hold, or on the default account of the user.
+Without an email, the user logs in with the number and the password of an account instead.
+*/
+func (s *sessionService) Create(data *dto.LoginDTO) (*types.SerializedSessionToken, error) {
+	email := strings.TrimSpace(data.Email)
+	if email == "" {
+		return s.createWithAccount(data.AccountNumber, data.Password)
+	}
+
+	// The same error whatever is wrong, so that logging in doesn't tell which emails are registered
+	user, err := s.store.User.GetUserByEmail(email)
+	if err != nil {
+		if err.Error() == "user_not_found" {
+			return nil, fmt.Errorf("invalid_credentials")
+		}
+		return nil, err
+	} else if user.IsDeleted() || !user.HasPassword() || !s.comparePassword(*user.Password, []byte(data.Password)) {
+		return nil, fmt.Errorf("invalid_credentials")
+	}
+
+	var a *types.Account
+	if strings.TrimSpace(data.AccountNumber) != "" {
+		a, err = s.heldAccount(user.ID, data.AccountNumber)
+	} else {
+		a, err = s.defaultAccount(user.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createSession(user.ID, a)
+}
 
-	if accountId <= 0 {
-		return nil, fmt.Errorf("missing_account_number")
+/*
+createWithAccount logs the owner of an account in with the number and the password of the account,
+the login kept for compatibility while LEGACY_ACCOUNT_LOGIN is on, and only for users who don't
+have a password of their own yet. The first time a user logs in this way, the password of the account
+becomes its own, so that the user logs in with its email from then on.
+*/
+func (s *sessionService) createWithAccount(accountNumber string, password string) (*types.SerializedSessionToken, error) {
+	if strings.TrimSpace(accountNumber) == "" || !legacyAccountLogin() {
+		return nil, fmt.Errorf("missing_email")
 	}
 
 	// Check if the account exists
-	a, err := s.store.Account.GetAccount(accountId)
+	a, err := accountByIBAN(s.store, accountNumber)
 	if err != nil {
-		return nil, fmt.Errorf("invalid_id")
+		if err.Error() == "invalid_iban" {
+			return nil, err
+		}
+		return nil, fmt.Errorf("invalid_credentials")
 	}
 
 	// Compare the password
-	if !s.comparePassword(a.Password, []byte(password)) {
-		return nil, fmt.Errorf("invalid_password")
+	if !s.comparePassword(a.Password, []byte(password)) || !a.Status.CanLogin() {
+		return nil, fmt.Errorf("invalid_credentials")
+	}
+
+	user, err := s.store.User.GetUserByID(a.UserID)
+	if err != nil {
+		return nil, err
+	} else if user.IsDeleted() || user.HasPassword() {
+		return nil, fmt.Errorf("invalid_credentials")
+	}
+
+	_, err = s.store.User.AdoptAccountPassword(a.UserID, a.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createSession(a.UserID, a)
+}
+
+/*
+heldAccount returns the account with the given number if the user holds it and can log in to it.
+Accounts the user doesn't hold or can't log in to are reported as invalid credentials,
+so that logging in doesn't tell anything about the accounts of others.
+*/
+func (s *sessionService) heldAccount(userId uint, accountNumber string) (*types.Account, error) {
+	a, err := accountByIBAN(s.store, accountNumber)
+	if err != nil {
+		if err.Error() == "invalid_iban" {
+			return nil, err
+		}
+		return nil, fmt.Errorf("invalid_credentials")
+	}
+
+	holder, err := s.store.AccountHolder.GetAccountHolder(a.ID, userId)
+	if err != nil {
+		return nil, err
+	} else if holder == nil || !a.Status.CanLogin() {
+		return nil, fmt.Errorf("invalid_credentials")
+	}
+
+	return a, nil
+}
+
+/*
+defaultAccount returns the account a session of the user acts on when none is given: the oldest
+account the user is the primary holder of, or else the oldest one it holds. It returns nil if the
+user holds no account it can log in to.
+*/
+func (s *sessionService) defaultAccount(userId uint) (*types.Account, error) {
+	accounts, err := s.store.Account.GetAccountsByHolder(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	var held *types.Account
+	for _, a := range accounts {
+		if !a.Status.CanLogin() || a.SystemCode != nil {
+			continue
+		} else if a.HolderRole != nil && *a.HolderRole == types.HolderPrimary {
+			return a, nil
+		} else if held == nil {
+			held = a
+		}
+	}
+
+	return held, nil
+}
+
+/*
+createSession creates a session of the user acting on the given account, if any.
+*/
+func (s *sessionService) createSession(userId uint, a *types.Account) (*types.SerializedSessionToken, error) {
+	var accountId *uint
+	if a != nil {
+		err := s.store.Account.TouchAccountActivity(a.ID)
+		if err != nil {
+			return nil, err
+		}
+		accountId = &a.ID
 	}
 
 	// Create a new session token
-	token, err := s.store.SessionToken.CreateSessionToken(a.ID)
+	token, err := s.store.SessionToken.CreateSessionToken(userId, accountId)
+	if err != nil {
+		return nil, err
+	}
 
-	return token.Serialize(), err
+	return token.Serialize(), nil
 }
 
 func (s *sessionService) IsValidSessionToken(tokenId string) (*types.SerializedSessionToken, bool) {
@@ -75,6 +211,24 @@ func (s *sessionService) IsValidSessionToken(tokenId string) (*types.SerializedS
 	return st, elapsed <= time.Second*1000
 }
 
+/*
+GetPrincipal resolves the principal behind a session token.
+It returns an error if the token is invalid or expired.
+*/
+func (s *sessionService) GetPrincipal(tokenId string) (*types.Principal, error) {
+	st, valid := s.IsValidSessionToken(tokenId)
+	if !valid {
+		return nil, fmt.Errorf("invalid_token")
+	}
+
+	p, err := loadPrincipal(s.store, st.UserID, st.AccountId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid_token")
+	}
+
+	return p, nil
+}
+
 func (s *sessionService) Delete(tokenId string) error {
 	return s.store.SessionToken.DeleteSessionToken(tokenId)
 }
@@ -0,0 +1,328 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/farischt/gobank/config"
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/store"
+	"github.com/farischt/gobank/pkg/types"
+	"github.com/farischt/gobank/utils"
+)
+
+type StandingOrderService interface {
+	Create(p *types.Principal, accountId uint, data *dto.CreateStandingOrderDTO) (*types.SerializedStandingOrder, error)
+	GetAll(p *types.Principal, accountId uint) ([]*types.SerializedStandingOrder, error)
+	Get(p *types.Principal, accountId uint, id uint) (*types.SerializedStandingOrder, error)
+	Update(p *types.Principal, accountId uint, id uint, data *dto.UpdateStandingOrderDTO) (*types.SerializedStandingOrder, error)
+	Cancel(p *types.Principal, accountId uint, id uint) (*types.SerializedStandingOrder, error)
+	ExecuteDue(now time.Time) (int, int, error)
+}
+
+type standingOrderService struct {
+	store       store.Store
+	transaction TransactionService
+}
+
+func NewStandingOrderService(store store.Store, transaction TransactionService) StandingOrderService {
+	return &standingOrderService{
+		store:       store,
+		transaction: transaction,
+	}
+}
+
+/*
+Create sets up a standing order sending money from the principal's account.
+A one-off order schedules a single transfer at the start date.
+*/
+func (s *standingOrderService) Create(p *types.Principal, accountId uint, data *dto.CreateStandingOrderDTO) (*types.SerializedStandingOrder, error) {
+	_, err := ownAccount(s.store, p, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	frequency := types.StandingOrderFrequency(data.Frequency)
+	start := BusinessDate(data.StartDate)
+
+	switch {
+	case data.Amount <= 0:
+		return nil, fmt.Errorf("invalid_amount")
+	case !frequency.IsValid():
+		return nil, fmt.Errorf("invalid_frequency")
+	case frequency == types.FrequencyMonthly && (data.DayOfMonth == nil || *data.DayOfMonth < 1 || *data.DayOfMonth > 31):
+		return nil, fmt.Errorf("invalid_day_of_month")
+	case frequency != types.FrequencyMonthly && data.DayOfMonth != nil:
+		return nil, fmt.Errorf("invalid_day_of_month")
+	case start.Before(BusinessDate(time.Now())):
+		return nil, fmt.Errorf("invalid_start_date")
+	}
+
+	day := 0
+	if data.DayOfMonth != nil {
+		day = *data.DayOfMonth
+	}
+
+	data.StartDate = start
+	firstRun := frequency.FirstRun(start, day)
+
+	if data.EndDate != nil {
+		end := BusinessDate(*data.EndDate)
+		if end.Before(firstRun) {
+			return nil, fmt.Errorf("invalid_end_date")
+		}
+		data.EndDate = &end
+	}
+
+	recipient, err := accountByIBAN(s.store, data.To)
+	if err != nil {
+		return nil, err
+	} else if recipient.ID == accountId {
+		return nil, fmt.Errorf("cannot_transfer_to_yourself")
+	}
+
+	order, err := s.store.StandingOrder.CreateStandingOrder(accountId, recipient.ID, data, firstRun)
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := order.Serialize()
+	return &serialized, nil
+}
+
+/*
+GetAll returns the standing orders of an account the principal can read.
+*/
+func (s *standingOrderService) GetAll(p *types.Principal, accountId uint) ([]*types.SerializedStandingOrder, error) {
+	_, err := readableAccount(s.store, p, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := s.store.StandingOrder.GetStandingOrdersByAccount(accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	serializedOrders := []*types.SerializedStandingOrder{}
+	for _, o := range orders {
+		serialized := o.Serialize()
+		serializedOrders = append(serializedOrders, &serialized)
+	}
+
+	return serializedOrders, nil
+}
+
+/*
+Get returns a standing order of an account the principal can read, with its runs.
+*/
+func (s *standingOrderService) Get(p *types.Principal, accountId uint, id uint) (*types.SerializedStandingOrder, error) {
+	_, err := readableAccount(s.store, p, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.accountOrder(accountId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	runs, err := s.store.StandingOrder.GetStandingOrderRuns(order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := order.Serialize()
+	for _, r := range runs {
+		serialized.Runs = append(serialized.Runs, r.Serialize())
+	}
+
+	return &serialized, nil
+}
+
+/*
+Update changes the amount or the end date of an active standing order of the principal's account.
+*/
+func (s *standingOrderService) Update(p *types.Principal, accountId uint, id uint, data *dto.UpdateStandingOrderDTO) (*types.SerializedStandingOrder, error) {
+	_, err := ownAccount(s.store, p, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.Amount != nil && *data.Amount <= 0 {
+		return nil, fmt.Errorf("invalid_amount")
+	}
+
+	order, err := s.accountOrder(accountId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.EndDate != nil {
+		end := BusinessDate(*data.EndDate)
+		if end.Before(order.StartDate) {
+			return nil, fmt.Errorf("invalid_end_date")
+		}
+		data.EndDate = &end
+	}
+
+	order, err = s.store.StandingOrder.UpdateStandingOrder(order.ID, data)
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := order.Serialize()
+	return &serialized, nil
+}
+
+/*
+Cancel cancels an active standing order of the principal's account.
+*/
+func (s *standingOrderService) Cancel(p *types.Principal, accountId uint, id uint) (*types.SerializedStandingOrder, error) {
+	_, err := ownAccount(s.store, p, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.accountOrder(accountId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err = s.store.StandingOrder.CancelStandingOrder(order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := order.Serialize()
+	return &serialized, nil
+}
+
+/*
+ExecuteDue makes the transfers of every standing order due at the given time.
+It returns the number of runs that succeeded and failed.
+*/
+func (s *standingOrderService) ExecuteDue(now time.Time) (int, int, error) {
+	orders, err := s.store.StandingOrder.GetDueStandingOrders(BusinessDate(now), now)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	succeeded, failed := 0, 0
+	for _, o := range orders {
+		ok, err := s.execute(o, now)
+		if err != nil {
+			return succeeded, failed, err
+		} else if ok {
+			succeeded++
+		} else {
+			failed++
+		}
+	}
+
+	return succeeded, failed, nil
+}
+
+/*
+execute makes the transfer of the next run of a standing order and logs the run.
+The transfer is keyed by the order and its run date, so that a run made before a crash
+but not logged isn't made twice.
+A failed run is retried after STANDING_ORDER_RETRY_DELAY, up to STANDING_ORDER_MAX_ATTEMPTS
+attempts, before the order moves on to its next run.
+*/
+func (s *standingOrderService) execute(o *types.StandingOrder, now time.Time) (bool, error) {
+	runDate := BusinessDate(*o.NextRunDate)
+
+	txn, err := s.transaction.TransferFrom(o.AccountID, &dto.CreateTransactionDTO{
+		ToAccountID:    o.To,
+		Amount:         utils.Uint8ToFloat(o.Amount),
+		IdempotencyKey: fmt.Sprintf("standing_order:%d:%s", o.ID, runDate.Format("2006-01-02")),
+	})
+
+	run := &types.StandingOrderRun{
+		StandingOrderID: o.ID,
+		RunDate:         runDate,
+		Attempt:         o.Attempts + 1,
+		Status:          types.RunSucceeded,
+	}
+
+	maxAttempts, retryDelay := standingOrderRetryPolicy()
+
+	if err != nil {
+		msg := err.Error()
+		run.Status = types.RunFailed
+		run.Error = &msg
+	} else {
+		run.TransactionID = &txn.ID
+	}
+
+	if err != nil && run.Attempt < maxAttempts {
+		retryAt := now.Add(retryDelay)
+		o.Attempts = run.Attempt
+		o.NextAttemptAt = &retryAt
+	} else {
+		o.Attempts = 0
+		o.NextAttemptAt = nil
+		advanceStandingOrder(o, runDate)
+	}
+
+	if err := s.store.StandingOrder.RecordStandingOrderRun(run, o); err != nil {
+		return false, err
+	}
+
+	return run.Status == types.RunSucceeded, nil
+}
+
+/*
+standingOrderRetryPolicy reads STANDING_ORDER_MAX_ATTEMPTS and STANDING_ORDER_RETRY_DELAY
+from the configuration, 3 attempts an hour apart by default.
+*/
+func standingOrderRetryPolicy() (int, time.Duration) {
+	c := config.GetConfig()
+
+	maxAttempts := c.GetInt(config.STANDING_ORDER_MAX_ATTEMPTS)
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+
+	retryDelay := c.GetDuration(config.STANDING_ORDER_RETRY_DELAY)
+	if retryDelay <= 0 {
+		retryDelay = time.Hour
+	}
+
+	return maxAttempts, retryDelay
+}
+
+/*
+advanceStandingOrder schedules the run following the given run date, or completes
+the order when there is none before its end date.
+*/
+func advanceStandingOrder(o *types.StandingOrder, runDate time.Time) {
+	day := 0
+	if o.DayOfMonth != nil {
+		day = *o.DayOfMonth
+	}
+
+	next := o.Frequency.NextRun(runDate, day)
+	if next == nil || (o.EndDate != nil && next.After(BusinessDate(*o.EndDate))) {
+		o.NextRunDate = nil
+		o.Status = types.StandingOrderCompleted
+		return
+	}
+
+	o.NextRunDate = next
+}
+
+/*
+accountOrder returns a standing order of the given account.
+*/
+func (s *standingOrderService) accountOrder(accountId uint, id uint) (*types.StandingOrder, error) {
+	order, err := s.store.StandingOrder.GetStandingOrder(id)
+	if err != nil {
+		return nil, err
+	} else if order.AccountID != accountId {
+		return nil, fmt.Errorf("standing_order_not_found")
+	}
+
+	return order, nil
+}
@@ -0,0 +1,351 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/export"
+	"github.com/farischt/gobank/pkg/store"
+	"github.com/farischt/gobank/pkg/types"
+	"github.com/farischt/gobank/utils"
+)
+
+type StatementService interface {
+	Generate(p *types.Principal, accountId uint, data *dto.CreateStatementDTO) (*types.SerializedStatement, error)
+	GetAll(p *types.Principal, accountId uint) ([]*types.SerializedStatement, error)
+	Render(p *types.Principal, accountId uint, id uint, format types.StatementFormat) (*types.StatementFile, error)
+	Export(p *types.Principal, accountId uint, format types.StatementFormat, from *time.Time, to *time.Time) (*types.StatementFile, error)
+	GenerateMonthly(now time.Time) (int, error)
+}
+
+type statementService struct {
+	store store.Store
+}
+
+func NewStatementService(store store.Store) StatementService {
+	return &statementService{
+		store: store,
+	}
+}
+
+/*
+Generate generates the statement of an account the principal can read for a period that has ended.
+A period already generated is not generated again, its statement is returned instead.
+*/
+func (s *statementService) Generate(p *types.Principal, accountId uint, data *dto.CreateStatementDTO) (*types.SerializedStatement, error) {
+	acc, err := readableAccount(s.store, p, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := BusinessDate(data.PeriodStart), BusinessDate(data.PeriodEnd)
+	now := time.Now()
+
+	if end.Before(start) || end.After(start.AddDate(1, 0, 0)) {
+		return nil, fmt.Errorf("invalid_period")
+	} else if !end.Before(BusinessDate(now)) {
+		return nil, fmt.Errorf("period_not_ended")
+	}
+
+	statement, err := s.generate(acc, start, end, now)
+	if err != nil {
+		return nil, err
+	}
+
+	serialized := statement.Serialize()
+	return &serialized, nil
+}
+
+/*
+GetAll returns the statements of an account the principal can read.
+*/
+func (s *statementService) GetAll(p *types.Principal, accountId uint) ([]*types.SerializedStatement, error) {
+	_, err := readableAccount(s.store, p, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	statements, err := s.store.Statement.GetStatementsByAccount(accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	serializedStatements := []*types.SerializedStatement{}
+	for _, st := range statements {
+		serialized := st.Serialize()
+		serializedStatements = append(serializedStatements, &serialized)
+	}
+
+	return serializedStatements, nil
+}
+
+/*
+Render renders a statement of an account the principal can read in the given format.
+Every format is rendered from the document stored when the statement was generated, the JSON
+format being the document itself, so that rendering it again yields the same file.
+*/
+func (s *statementService) Render(p *types.Principal, accountId uint, id uint, format types.StatementFormat) (*types.StatementFile, error) {
+	acc, err := readableAccount(s.store, p, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !format.IsValid() {
+		return nil, fmt.Errorf("invalid_format")
+	}
+
+	statement, err := s.store.Statement.GetStatement(id)
+	if err != nil {
+		return nil, err
+	} else if statement.AccountID != acc.ID {
+		return nil, fmt.Errorf("statement_not_found")
+	}
+
+	doc := new(types.StatementDocument)
+	err = json.Unmarshal([]byte(statement.Document), doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == types.StatementJSON {
+		return &types.StatementFile{
+			Filename:    statementFilename(doc, format),
+			ContentType: "application/json",
+			Content:     []byte(statement.Document),
+			Hash:        statement.Hash,
+		}, nil
+	}
+
+	return renderStatement(doc, statement.Hash, format)
+}
+
+/*
+Export renders the transactions of an account the principal can read from a day to another, both
+included, in one of the bank formats. The period defaults to the current month up to today.
+Unlike a statement, an export is computed when requested and is not stored.
+*/
+func (s *statementService) Export(p *types.Principal, accountId uint, format types.StatementFormat, from *time.Time, to *time.Time) (*types.StatementFile, error) {
+	acc, err := readableAccount(s.store, p, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !format.IsBankFormat() {
+		return nil, fmt.Errorf("invalid_format")
+	}
+
+	now := time.Now()
+
+	end := BusinessDate(now)
+	if to != nil {
+		end = BusinessDate(*to)
+	}
+	start := startOfMonth(end)
+	if from != nil {
+		start = BusinessDate(*from)
+	}
+
+	if end.Before(start) || end.After(start.AddDate(1, 0, 0)) || start.After(BusinessDate(now)) {
+		return nil, fmt.Errorf("invalid_period")
+	}
+
+	opening, entries, err := s.store.Statement.GetLedger(acc.ID, start, end.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	doc := buildStatement(acc, opening, entries, start, end)
+	doc.GeneratedAt = now.UTC().Truncate(time.Second)
+
+	return renderStatement(doc, "", format)
+}
+
+/*
+GenerateMonthly generates the statement of the previous month of every customer account that has none.
+It returns the number of statements generated.
+*/
+func (s *statementService) GenerateMonthly(now time.Time) (int, error) {
+	end := startOfMonth(BusinessDate(now)).AddDate(0, 0, -1)
+	start := startOfMonth(end)
+
+	ids, err := s.store.Statement.GetAccountsWithoutStatement(start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, id := range ids {
+		acc, err := s.store.Account.GetAccount(id)
+		if err != nil {
+			return i, err
+		}
+
+		_, err = s.generate(acc, start, end, now)
+		if err != nil {
+			return i, err
+		}
+	}
+
+	return len(ids), nil
+}
+
+/*
+generate generates and stores the statement of an account for the period from start to end, both included.
+If the statement of the period is generated concurrently, the one stored first is returned.
+*/
+func (s *statementService) generate(acc *types.Account, start time.Time, end time.Time, now time.Time) (*types.Statement, error) {
+	existing, err := s.store.Statement.GetStatementByPeriod(acc.ID, start, end)
+	if err != nil {
+		return nil, err
+	} else if existing != nil {
+		return existing, nil
+	}
+
+	opening, entries, err := s.store.Statement.GetLedger(acc.ID, start, end.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	doc := buildStatement(acc, opening, entries, start, end)
+	doc.GeneratedAt = now.UTC().Truncate(time.Second)
+
+	document, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(document)
+
+	statement, err := s.store.Statement.CreateStatement(&types.Statement{
+		AccountID:   acc.ID,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Document:    string(document),
+		Hash:        hex.EncodeToString(sum[:]),
+		CreatedAt:   doc.GeneratedAt,
+	})
+	if err != nil && err.Error() == "statement_already_exist" {
+		return s.store.Statement.GetStatementByPeriod(acc.ID, start, end)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return statement, nil
+}
+
+/*
+renderStatement renders a statement document in a format other than JSON, hash being the one of
+the stored document if any.
+*/
+func renderStatement(doc *types.StatementDocument, hash string, format types.StatementFormat) (*types.StatementFile, error) {
+	var err error
+
+	file := &types.StatementFile{
+		Filename: statementFilename(doc, format),
+		Hash:     hash,
+	}
+
+	switch format {
+	case types.StatementCSV:
+		file.ContentType = "text/csv; charset=utf-8"
+		file.Content, err = export.CSV(doc)
+	case types.StatementHTML:
+		file.ContentType = "text/html; charset=utf-8"
+		file.Content, err = export.HTML(doc, hash)
+	case types.StatementCamt053:
+		file.ContentType = "application/xml; charset=utf-8"
+		file.Content, err = export.Camt053(doc)
+	case types.StatementMT940:
+		file.ContentType = "text/plain; charset=us-ascii"
+		file.Content, err = export.MT940(doc)
+	default:
+		err = fmt.Errorf("invalid_format")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+/*
+statementFilename gives the name of the file of a statement document in a format.
+*/
+func statementFilename(doc *types.StatementDocument, format types.StatementFormat) string {
+	ext := string(format)
+	switch format {
+	case types.StatementCamt053:
+		ext = "xml"
+	case types.StatementMT940:
+		ext = "sta"
+	}
+
+	return fmt.Sprintf("statement-%s-%s-%s.%s", doc.IBAN, doc.PeriodStart.Format("20060102"), doc.PeriodEnd.Format("20060102"), ext)
+}
+
+/*
+buildStatement computes the statement of an account from its balance at the start of the period
+and its transactions within the period, with the running balance after each of them.
+*/
+func buildStatement(acc *types.Account, opening float64, entries []*types.LedgerEntry, start time.Time, end time.Time) *types.StatementDocument {
+	doc := &types.StatementDocument{
+		AccountID:      acc.ID,
+		Currency:       acc.Currency,
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		OpeningBalance: utils.RoundHalfEven(opening, 2),
+		Lines:          []types.StatementLine{},
+	}
+	if acc.IBAN != nil {
+		doc.IBAN = *acc.IBAN
+	}
+
+	balance := doc.OpeningBalance
+	for _, e := range entries {
+		line := types.StatementLine{
+			TransactionID: e.ID,
+			Date:          e.CreatedAt,
+			Type:          e.Type,
+		}
+		if e.CounterpartyIBAN != nil {
+			line.Counterparty = *e.CounterpartyIBAN
+		}
+		if e.Reference != nil {
+			line.Reference = *e.Reference
+		}
+		if e.CreditorReference != nil {
+			line.CreditorReference = *e.CreditorReference
+		}
+
+		if e.From == acc.ID {
+			amount := utils.Uint8ToFloat(e.Amount)
+			line.Amount = -amount
+			doc.TotalOut += amount
+			if e.Type == types.TransactionFee {
+				doc.Fees += amount
+			}
+		} else {
+			amount := utils.Uint8ToFloat(e.ToAmount)
+			line.Amount = amount
+			doc.TotalIn += amount
+			if e.Type == types.TransactionInterest {
+				doc.Interest += amount
+			}
+		}
+
+		balance = utils.RoundHalfEven(balance+line.Amount, 2)
+		line.Balance = balance
+		doc.Lines = append(doc.Lines, line)
+	}
+
+	doc.TotalIn = utils.RoundHalfEven(doc.TotalIn, 2)
+	doc.TotalOut = utils.RoundHalfEven(doc.TotalOut, 2)
+	doc.Fees = utils.RoundHalfEven(doc.Fees, 2)
+	doc.Interest = utils.RoundHalfEven(doc.Interest, 2)
+	doc.ClosingBalance = balance
+
+	return doc
+}
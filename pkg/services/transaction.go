@@ -2,14 +2,22 @@ package services
 
 import (
 	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/farischt/gobank/pkg/dto"
 	"github.com/farischt/gobank/pkg/store"
 	"github.com/farischt/gobank/pkg/types"
+	"github.com/farischt/gobank/utils"
 )
 
 type TransactionService interface {
-	Transfer(senderId uint, data *dto.CreateTransactionDTO) error
+	Transfer(p *types.Principal, data *dto.CreateTransactionDTO) (*types.Transaction, error)
+	TransferFrom(senderId uint, data *dto.CreateTransactionDTO) (*types.Transaction, error)
+	Quote(p *types.Principal, data *dto.CreateTransactionDTO) (*types.TransferQuote, error)
+	Reverse(p *types.Principal, id uint, data *dto.ReverseTransactionDTO) (*types.Reversal, error)
+	Search(p *types.Principal, accountId uint, filter *dto.SearchTransactionsDTO) ([]types.SerializedTransaction, error)
 }
 
 type transactionService struct {
@@ -26,39 +34,523 @@ func (t *transactionService) Create() error {
 	return nil
 }
 
-func (t *transactionService) Transfer(senderId uint, data *dto.CreateTransactionDTO) error {
+/*
+Transfer sends money from the account of the principal given by the transfer, or the account of
+its session, to the recipient and returns the transaction.
+A transfer with an idempotency key already used by the sender isn't made again, the
+transaction made the first time is returned instead.
+*/
+func (t *transactionService) Transfer(p *types.Principal, data *dto.CreateTransactionDTO) (*types.Transaction, error) {
+	senderId, err := t.sender(p, data)
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.validateTransfer(p.UserID, senderId, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.send(senderId, data)
+}
+
+/*
+TransferFrom sends money from the sender to the recipient on behalf of the bank, as standing orders do,
+and returns the transaction. The sender isn't checked against anyone's holdings, and can only pay
+the beneficiaries of the user it was opened for.
+*/
+func (t *transactionService) TransferFrom(senderId uint, data *dto.CreateTransactionDTO) (*types.Transaction, error) {
+	sender, err := t.store.Account.GetAccount(senderId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.validateTransfer(sender.UserID, senderId, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.send(senderId, data)
+}
+
+/*
+send makes a validated transfer within a sql transaction.
+*/
+func (t *transactionService) send(senderId uint, data *dto.CreateTransactionDTO) (*types.Transaction, error) {
+	var err error
+	var txn *types.Transaction
+	err = t.store.Transaction.RunInTx(func(tx store.TransferTx) error {
+		txn, err = t.transfer(tx, senderId, data, time.Now())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return txn, nil
+}
+
+/*
+Quote previews the fee a transfer would be charged if it was made now, and what the
+recipient would be credited in its currency.
+*/
+func (t *transactionService) Quote(p *types.Principal, data *dto.CreateTransactionDTO) (*types.TransferQuote, error) {
+	senderId, err := t.sender(p, data)
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.validateTransfer(p.UserID, senderId, data)
+	if err != nil {
+		return nil, err
+	}
+
+	sender, err := t.store.Account.GetAccount(senderId)
+	if err != nil {
+		return nil, err
+	}
+
+	recipient, err := t.store.Account.GetAccount(data.ToAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	month, err := t.store.Transaction.GetOutgoingTotalsSince(sender.ID, startOfMonth(now))
+	if err != nil {
+		return nil, err
+	}
+
+	quote := quoteFee(feeRule(types.TransactionTransfer, sender.Type), data.Amount, month.Count)
+
+	entry := &types.TransactionEntry{Amount: data.Amount}
+	err = bookLegs(t.store.Fx, entry, sender.Currency, recipient.Currency, now)
+	if err != nil {
+		return nil, err
+	}
+
+	quote.ToAmount = entry.ToAmount
+	quote.ToCurrency = entry.ToCurrency
+	quote.FxRate = entry.FxRate
+
+	return quote, nil
+}
+
+/*
+sender returns the id of the account a transfer of the principal is sent from: the account given
+by its IBAN, which the principal must be able to send money from, or the account of the session.
+*/
+func (t *transactionService) sender(p *types.Principal, data *dto.CreateTransactionDTO) (uint, error) {
+	if p == nil {
+		return 0, fmt.Errorf("unauthorized")
+	}
+
+	accountId := p.AccountID
+	if strings.TrimSpace(data.From) != "" {
+		acc, err := accountByIBAN(t.store, data.From)
+		if err != nil {
+			return 0, err
+		}
+		accountId = acc.ID
+	} else if accountId == 0 {
+		return 0, fmt.Errorf("missing_source_account")
+	}
+
+	acc, err := ownAccount(t.store, p, accountId)
+	if err != nil {
+		return 0, err
+	}
+
+	return acc.ID, nil
+}
+
+/*
+Search returns the transactions of an account the principal can read matching the filter, newest first.
+The memo of the transactions sent by the account is only shown to, and searched for, the holders
+who can send money from it.
+*/
+func (t *transactionService) Search(p *types.Principal, accountId uint, filter *dto.SearchTransactionsDTO) ([]types.SerializedTransaction, error) {
+	acc, err := readableAccount(t.store, p, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.Query = strings.TrimSpace(filter.Query)
+	filter.CreditorReference = types.NormalizeCreditorReference(filter.CreditorReference)
+
+	if filter.Limit == 0 || filter.Limit > 100 {
+		return nil, fmt.Errorf("invalid_limit")
+	} else if filter.Since != nil && filter.Until != nil && !filter.Until.After(*filter.Since) {
+		return nil, fmt.Errorf("invalid_period")
+	}
+
+	owner, err := canTransact(t.store, p, acc)
+	if err != nil {
+		return nil, err
+	}
+
+	// The memo of the sender mustn't be searched by someone else
+	txns, err := t.store.Transaction.SearchTxnsByAccount(acc.ID, filter, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	serializedTxns := []types.SerializedTransaction{}
+	for _, txn := range txns {
+		if owner && txn.From == acc.ID {
+			serializedTxns = append(serializedTxns, types.SerializeSentTransaction(*txn))
+		} else {
+			serializedTxns = append(serializedTxns, types.SerializeTransaction(*txn))
+		}
+	}
+
+	return serializedTxns, nil
+}
+
+/*
+validateTransfer checks a transfer and resolves the account of the recipient from its IBAN or
+from the beneficiary, unless the account is already set. Only the beneficiaries of the given user
+can be paid, the one making the transfer rather than the one the sending account was opened for.
+*/
+func (t *transactionService) validateTransfer(userId uint, senderId uint, data *dto.CreateTransactionDTO) error {
+	data.Reference = strings.TrimSpace(data.Reference)
+	data.CreditorReference = types.NormalizeCreditorReference(data.CreditorReference)
+	data.Memo = strings.TrimSpace(data.Memo)
 
 	if data.Amount <= 0 {
 		return fmt.Errorf("invalid_amount")
-	} else if data.To <= 0 {
-		return fmt.Errorf("invalid_to_account_id")
-	} else if data.To == senderId {
+	} else if len(data.IdempotencyKey) > 255 {
+		return fmt.Errorf("invalid_idempotency_key")
+	} else if utf8.RuneCountInString(data.Reference) > 140 {
+		return fmt.Errorf("invalid_reference")
+	} else if data.CreditorReference != "" && !types.IsValidCreditorReference(data.CreditorReference) {
+		return fmt.Errorf("invalid_creditor_reference")
+	} else if utf8.RuneCountInString(data.Memo) > 500 {
+		return fmt.Errorf("invalid_memo")
+	}
+
+	if data.ToAccountID == 0 && data.BeneficiaryID != nil {
+		if data.To != "" {
+			return fmt.Errorf("to_and_beneficiary_id")
+		}
+
+		b, err := beneficiaryRecipient(t.store, userId, *data.BeneficiaryID, data.Amount, time.Now())
+		if err != nil {
+			return err
+		}
+		data.ToAccountID = b.AccountID
+
+		if data.Reference == "" && b.DefaultReference != nil {
+			data.Reference = *b.DefaultReference
+		}
+	} else if data.ToAccountID == 0 {
+		recipient, err := accountByIBAN(t.store, data.To)
+		if err != nil {
+			return err
+		}
+		data.ToAccountID = recipient.ID
+	}
+
+	if data.ToAccountID == senderId {
 		return fmt.Errorf("cannot_transfer_to_yourself")
 	}
 
-	sender, err := t.store.Account.GetAccount(senderId)
+	return nil
+}
+
+/*
+transfer makes a transfer within the given sql transaction.
+The sender and the recipient are locked before anything is evaluated, so that
+concurrent transfers of the same sender are checked one after the other.
+The amount is in the currency of the sender, and converted to the currency of the recipient.
+The fee of the transfer, if any, is posted to the fee income account as a separate transaction.
+*/
+func (t *transactionService) transfer(tx store.TransferTx, senderId uint, data *dto.CreateTransactionDTO, now time.Time) (*types.Transaction, error) {
+	accounts, err := tx.LockAccounts(senderId, data.ToAccountID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	sender, recipient := accounts[senderId], accounts[data.ToAccountID]
+
+	// The sender is locked, so a concurrent transfer with the same key has either committed or not started
+	if data.IdempotencyKey != "" {
+		previous, err := tx.GetTxnByIdempotencyKey(sender.ID, data.IdempotencyKey)
+		if err != nil {
+			return nil, err
+		} else if previous != nil {
+			if previous.To != data.ToAccountID || utils.Uint8ToFloat(previous.Amount) != data.Amount {
+				return nil, fmt.Errorf("idempotency_key_reused")
+			}
+			return previous, nil
+		}
+	}
+
+	if !sender.Status.CanSend() {
+		return nil, fmt.Errorf("account_%s", sender.Status)
+	} else if !recipient.Status.CanReceive() {
+		return nil, fmt.Errorf("recipient_account_%s", recipient.Status)
+	}
+
+	err = rulesFor(sender.Type).checkOutgoing(&transferContext{
+		sender:    sender,
+		recipient: recipient,
+		amount:    data.Amount,
+		now:       now,
+		outgoingCount: func(since time.Time) (int, error) {
+			totals, err := tx.GetOutgoingTotalsSince(sender.ID, since)
+			if err != nil {
+				return 0, err
+			}
+			return totals.Count, nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	today, err := tx.GetOutgoingTotalsSince(sender.ID, startOfDay(now))
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkLimits(effectiveLimits(sender), data.Amount, today)
+	if err != nil {
+		return nil, err
+	}
+
+	month, err := tx.GetOutgoingTotalsSince(sender.ID, startOfMonth(now))
+	if err != nil {
+		return nil, err
+	}
+
+	quote := quoteFee(feeRule(types.TransactionTransfer, sender.Type), data.Amount, month.Count)
+
 	s := sender.Serialize()
-	if !t.HasEnoughBalance(&s, data.Amount) {
-		return fmt.Errorf("insufficient_balance")
+	if !t.HasEnoughBalance(sender.Type, &s, quote.Total) {
+		return nil, fmt.Errorf("insufficient_balance")
+	}
+
+	entry := &types.TransactionEntry{
+		Type:   types.TransactionTransfer,
+		From:   sender.ID,
+		To:     recipient.ID,
+		Amount: data.Amount,
+	}
+	if data.IdempotencyKey != "" {
+		entry.IdempotencyKey = &data.IdempotencyKey
+	}
+	if data.Reference != "" {
+		entry.Reference = &data.Reference
+	}
+	if data.CreditorReference != "" {
+		entry.CreditorReference = &data.CreditorReference
+	}
+	if data.Memo != "" {
+		entry.Memo = &data.Memo
+	}
+
+	err = bookLegs(tx, entry, sender.Currency, recipient.Currency, now)
+	if err != nil {
+		return nil, err
+	}
+
+	txn, err := tx.CreateTxn(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Debit(sender.ID, entry.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Credit(recipient.ID, entry.ToAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	if quote.Fee > 0 {
+		err = t.chargeFee(tx, txn, quote.Fee, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return txn, nil
+}
+
+/*
+chargeFee debits the sender of a transaction of the fee, in its currency, and credits the fee income account.
+*/
+func (t *transactionService) chargeFee(tx store.TransferTx, txn *types.Transaction, fee float64, now time.Time) error {
+	income, err := tx.GetSystemAccount(feeIncomeAccount)
+	if err != nil {
+		return err
+	}
+
+	entry := &types.TransactionEntry{
+		Type:     types.TransactionFee,
+		From:     txn.From,
+		To:       income.ID,
+		Amount:   fee,
+		ParentID: &txn.ID,
+	}
+
+	err = bookLegs(tx, entry, txn.Currency, income.Currency, now)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.CreateTxn(entry)
+	if err != nil {
+		return err
 	}
 
-	recipient, err := t.store.Account.GetAccount(data.To)
+	err = tx.Debit(txn.From, entry.Amount)
 	if err != nil {
 		return err
 	}
 
-	r := recipient.Serialize()
-	s.Balance -= data.Amount
-	r.Balance += data.Amount
+	return tx.Credit(income.ID, entry.ToAmount)
+}
+
+/*
+Reverse sends back all or part of a transfer from its recipient to its sender, on behalf of
+a principal with the transaction:reverse permission. The reversals of a transfer never add up
+to more than the transfer, and are in the currency of its sender.
+The recipient must be able to afford the reversal, unless it is forced: the recipient is then
+taken into overdraft, and its overdraft limit raised if needed, which also requires the
+account:overdraft permission. Accounts that can't be overdrawn can't be forced.
+*/
+func (t *transactionService) Reverse(p *types.Principal, id uint, data *dto.ReverseTransactionDTO) (*types.Reversal, error) {
+	if !p.Can(types.PermTransactionReverse) || (data.Force && !p.Can(types.PermAccountOverdraft)) {
+		return nil, fmt.Errorf("forbidden")
+	} else if id <= 0 {
+		return nil, fmt.Errorf("invalid_transaction_id")
+	} else if data.Amount != nil && *data.Amount <= 0 {
+		return nil, fmt.Errorf("invalid_amount")
+	}
+
+	var reversal *types.Reversal
+	err := t.store.Transaction.RunInTx(func(tx store.TransferTx) error {
+		var err error
+		reversal, err = t.reverse(tx, id, data)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reversal, nil
+}
+
+/*
+reverse makes a reversal within the given sql transaction.
+The original transaction is locked first, so that concurrent reversals are checked one after the other.
+*/
+func (t *transactionService) reverse(tx store.TransferTx, id uint, data *dto.ReverseTransactionDTO) (*types.Reversal, error) {
+	original, err := tx.LockTxn(id)
+	if err != nil {
+		return nil, err
+	} else if original.Type != types.TransactionTransfer {
+		return nil, fmt.Errorf("transaction_not_reversible")
+	} else if original.Status == types.TransactionReversed {
+		return nil, fmt.Errorf("transaction_already_reversed")
+	}
+
+	remaining := utils.RoundHalfEven(utils.Uint8ToFloat(original.Amount)-utils.Uint8ToFloat(original.ReversedAmount), 2)
+	amount := remaining
+	if data.Amount != nil {
+		amount = *data.Amount
+	}
+
+	if amount > remaining {
+		return nil, fmt.Errorf("reversal_exceeds_original")
+	}
+
+	accounts, err := tx.LockAccounts(original.From, original.To)
+	if err != nil {
+		return nil, err
+	}
+
+	// The money goes back from the recipient of the original transfer to its sender
+	payer, payee := accounts[original.To], accounts[original.From]
+
+	if payer.Status == types.AccountClosed {
+		return nil, fmt.Errorf("recipient_account_closed")
+	} else if payee.Status == types.AccountClosed {
+		return nil, fmt.Errorf("sender_account_closed")
+	}
+
+	// The amount is in the currency of the original sender, the recipient pays it back at the original rate
+	entry := &types.TransactionEntry{
+		Type:       types.TransactionReversal,
+		From:       payer.ID,
+		To:         payee.ID,
+		Amount:     amount,
+		Currency:   original.ToCurrency,
+		ToAmount:   amount,
+		ToCurrency: original.Currency,
+		ReversalOf: &original.ID,
+	}
+	if len(original.FxRate) > 0 {
+		rate := utils.Uint8ToFloat(original.FxRate)
+		inverse := 1 / rate
+		entry.Amount = utils.RoundHalfEven(amount*rate, 2)
+		entry.FxRate = &inverse
+	}
+
+	s := payer.Serialize()
+	if !t.HasEnoughBalance(payer.Type, &s, entry.Amount) {
+		if !data.Force {
+			return nil, fmt.Errorf("insufficient_balance")
+		} else if !rulesFor(payer.Type).allowsOverdraft() {
+			// Only an account that can be overdrawn may be forced below zero
+			return nil, fmt.Errorf("overdraft_not_allowed")
+		}
+
+		err = tx.RaiseOverdraftLimit(payer.ID, entry.Amount-s.Balance)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	txn, err := tx.CreateTxn(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Debit(payer.ID, entry.Amount)
+	if err != nil {
+		return nil, err
+	}
 
-	return t.store.Transaction.CreateTxnAndUpdateBalance(sender, recipient, s.Balance, r.Balance, data)
+	err = tx.Credit(payee.ID, entry.ToAmount)
+	if err != nil {
+		return nil, err
+	}
 
+	original, err = tx.AddReversedAmount(original.ID, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Reversal{
+		Reversal: types.SerializeTransaction(*txn),
+		Original: types.SerializeTransaction(*original),
+	}, nil
 }
 
-func (t *transactionService) HasEnoughBalance(account *types.SerializedAccount, amount float64) bool {
-	return account.Balance >= amount
+/*
+HasEnoughBalance reports whether the available balance of the account, which leaves out
+the held amount, can be debited of the amount, using its overdraft when its type allows one.
+*/
+func (t *transactionService) HasEnoughBalance(accountType types.AccountType, account *types.SerializedAccount, amount float64) bool {
+	floor := 0.0
+	if rulesFor(accountType).allowsOverdraft() {
+		floor = -account.OverdraftLimit
+	}
+
+	return account.AvailableBalance-amount >= floor
 }
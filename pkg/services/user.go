@@ -2,55 +2,387 @@ package services
 
 import (
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
+	"github.com/farischt/gobank/config"
 	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/mailer"
 	"github.com/farischt/gobank/pkg/store"
 	"github.com/farischt/gobank/pkg/types"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService interface {
 	Create(data *dto.CreateUserDTO) error
-	Get(id uint) (*types.SerializedUser, error)
+	Get(p *types.Principal, id uint) (*types.SerializedUser, error)
+	Update(p *types.Principal, id uint, data *dto.UpdateUserDTO) (*types.SerializedUser, error)
+	Delete(p *types.Principal, id uint) error
+	Export(p *types.Principal, id uint) (*types.UserExport, error)
+	VerifyEmail(token string) error
+	ResendVerification(email string) error
+	SetPassword(p *types.Principal, id uint, data *dto.SetPasswordDTO) error
 }
 
 type userService struct {
-	store store.Store
+	store  store.Store
+	mailer mailer.Mailer
 }
 
-func NewUserService(store store.Store) UserService {
+func NewUserService(store store.Store, mailer mailer.Mailer) UserService {
 	return &userService{
-		store: store,
+		store:  store,
+		mailer: mailer,
 	}
 }
 
-func (u *userService) Get(id uint) (*types.SerializedUser, error) {
+/*
+Get returns the user with the given id.
+A user the principal is not allowed to read is reported as not found,
+the read of another user is audited.
+*/
+func (u *userService) Get(p *types.Principal, id uint) (*types.SerializedUser, error) {
 	if id <= 0 {
 		return nil, fmt.Errorf("invalid_user_id")
 	}
 
+	if !p.CanReadUser(id) {
+		return nil, fmt.Errorf("user_not_found")
+	}
+
 	user, err := u.store.User.GetUserByID(id)
 	if err != nil {
 		return nil, err
 	}
 
+	if user.IsDeleted() && !p.Can(types.PermUserReadAny) {
+		return nil, fmt.Errorf("user_not_found")
+	}
+
+	if id != p.UserID {
+		err = recordAnyAccess(u.store, p, types.PermUserReadAny, http.MethodGet, fmt.Sprintf("/user/%d", id), http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	s := user.Serialize()
 	return &s, nil
 }
 
+/*
+Create creates a new user with the password it logs in with and sends it an email verification token.
+*/
 func (u *userService) Create(data *dto.CreateUserDTO) error {
 	if len(data.FirstName) == 0 {
 		return fmt.Errorf("empty_first_name")
 	} else if len(data.LastName) == 0 {
 		return fmt.Errorf("empty_last_name")
-	} else if len(data.Email) == 0 {
-		return fmt.Errorf("empty_email")
+	} else if len(data.Password) < minPasswordLength {
+		return fmt.Errorf("password_too_short")
+	}
+
+	email, err := normalizeEmail(data.Email)
+	if err != nil {
+		return err
 	}
+	data.Email = email
 
 	exist, err := u.store.User.GetUserByEmail(data.Email)
 	if err == nil && exist != nil {
 		return fmt.Errorf("user_already_exist")
 	}
 
-	err = u.store.User.CreateUser(data)
-	return err
+	data.Password, err = hashPassword([]byte(data.Password))
+	if err != nil {
+		return err
+	}
+
+	user, err := u.store.User.CreateUser(data)
+	if err != nil {
+		return err
+	}
+
+	return u.sendVerification(user)
+}
+
+/*
+SetPassword sets the password the principal's own user logs in with.
+The current password is required once the user has one, a user still logging in with the
+password of an account sets its first one from a session of the account.
+The passwords of the accounts of the user stop working and every session of the user is revoked.
+*/
+func (u *userService) SetPassword(p *types.Principal, id uint, data *dto.SetPasswordDTO) error {
+	user, err := ownUser(u.store, p, id)
+	if err != nil {
+		return err
+	} else if len(data.Password) < minPasswordLength {
+		return fmt.Errorf("password_too_short")
+	}
+
+	if user.HasPassword() && bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(data.CurrentPassword)) != nil {
+		return fmt.Errorf("invalid_current_password")
+	}
+
+	hash, err := hashPassword([]byte(data.Password))
+	if err != nil {
+		return err
+	}
+
+	return u.store.User.SetUserPassword(user.ID, hash)
+}
+
+/*
+sendVerification issues a new verification token for the current email of the user
+and mails it. Only the hash of the token is stored.
+*/
+func (u *userService) sendVerification(user *types.User) error {
+	token, err := randomHex(32)
+	if err != nil {
+		return err
+	}
+
+	ttl := config.GetConfig().GetDuration(config.EMAIL_VERIFICATION_TTL)
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+
+	err = u.store.User.CreateEmailVerification(user.ID, user.Email, hashSecret(token), time.Now().Add(ttl))
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"Hello %s,\n\nTo verify your email address, send the following token to POST /user/verify before %s:\n\n%s\n",
+		user.FirstName,
+		time.Now().Add(ttl).Format(time.RFC1123),
+		token,
+	)
+
+	return u.mailer.Send(user.Email, "Verify your email address", body)
+}
+
+/*
+VerifyEmail confirms the email address the given token was issued for.
+*/
+func (u *userService) VerifyEmail(token string) error {
+	token = strings.TrimSpace(token)
+	if len(token) == 0 {
+		return fmt.Errorf("invalid_verification_token")
+	}
+
+	return u.store.User.VerifyEmail(hashSecret(token))
+}
+
+/*
+ResendVerification sends a new verification token to an unverified user.
+It doesn't report whether the email is known, so that it can't be used to
+enumerate the users.
+*/
+func (u *userService) ResendVerification(email string) error {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return err
+	}
+
+	user, err := u.store.User.GetUserByEmail(email)
+	if err != nil || user.IsVerified() || user.IsDeleted() {
+		return nil
+	}
+
+	return u.sendVerification(user)
+}
+
+/*
+Update changes the name and email of the principal's own user.
+A new email address has to be verified again.
+*/
+func (u *userService) Update(p *types.Principal, id uint, data *dto.UpdateUserDTO) (*types.SerializedUser, error) {
+	user, err := ownUser(u.store, p, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.FirstName != nil && len(strings.TrimSpace(*data.FirstName)) == 0 {
+		return nil, fmt.Errorf("empty_first_name")
+	} else if data.LastName != nil && len(strings.TrimSpace(*data.LastName)) == 0 {
+		return nil, fmt.Errorf("empty_last_name")
+	}
+
+	if data.Email != nil {
+		email, err := normalizeEmail(*data.Email)
+		if err != nil {
+			return nil, err
+		}
+		data.Email = &email
+
+		if email != user.Email {
+			exist, err := u.store.User.GetUserByEmail(email)
+			if err == nil && exist != nil {
+				return nil, fmt.Errorf("user_already_exist")
+			}
+		}
+	}
+
+	updated, err := u.store.User.UpdateUser(id, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// A new email must be verified again
+	if updated.Email != user.Email {
+		err = u.sendVerification(updated)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s := updated.Serialize()
+	return &s, nil
+}
+
+/*
+Delete deletes the principal's own user.
+The user is anonymized rather than removed so that the transaction history of
+its accounts is kept, and its accounts are closed. It is refused while any account
+holds money, has accrued interest left to pay, an active hold, an active standing order
+or other holders.
+*/
+func (u *userService) Delete(p *types.Principal, id uint) error {
+	_, err := ownUser(u.store, p, id)
+	if err != nil {
+		return err
+	}
+
+	return u.store.User.AnonymizeUser(id)
+}
+
+/*
+Export returns everything held about the principal's own user, across every account the user holds.
+The memos of the transfers sent from the accounts the user can transact on are included.
+*/
+func (u *userService) Export(p *types.Principal, id uint) (*types.UserExport, error) {
+	user, err := ownUser(u.store, p, id)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &types.UserExport{
+		ExportedAt:      time.Now(),
+		User:            user.Serialize(),
+		Roles:           []types.SerializedUserRole{},
+		Accounts:        []types.SerializedAccount{},
+		Transactions:    []types.SerializedTransaction{},
+		StandingOrders:  []types.SerializedStandingOrder{},
+		Holds:           []types.SerializedHold{},
+		PaymentRequests: []types.SerializedPaymentRequest{},
+		Statements:      []types.SerializedStatement{},
+		ApiKeys:         []types.SerializedApiKey{},
+		Beneficiaries:   []types.SerializedBeneficiary{},
+		AuditEntries:    []types.SerializedAuditEntry{},
+	}
+
+	roles, err := u.store.Role.GetUserRoles(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range roles {
+		export.Roles = append(export.Roles, r.Serialize())
+	}
+
+	accounts, err := u.store.Account.GetAccountsByHolder(id)
+	if err != nil {
+		return nil, err
+	}
+
+	owned := make(map[uint]bool)
+	for _, a := range accounts {
+		owned[a.ID] = a.HolderRole != nil && a.HolderRole.CanTransact()
+	}
+
+	// A transfer or a payment request between two accounts of the user must appear once
+	seen := make(map[uint]bool)
+	seenRequests := make(map[uint]bool)
+	for _, a := range accounts {
+		export.Accounts = append(export.Accounts, a.Serialize())
+
+		txns, err := u.store.Transaction.GetTxnsByAccount(a.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range txns {
+			if seen[t.ID] {
+				continue
+			}
+			seen[t.ID] = true
+			if owned[t.From] {
+				export.Transactions = append(export.Transactions, types.SerializeSentTransaction(*t))
+			} else {
+				export.Transactions = append(export.Transactions, types.SerializeTransaction(*t))
+			}
+		}
+
+		orders, err := u.store.StandingOrder.GetStandingOrdersByAccount(a.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range orders {
+			export.StandingOrders = append(export.StandingOrders, o.Serialize())
+		}
+
+		holds, err := u.store.Hold.GetHoldsByAccount(a.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range holds {
+			export.Holds = append(export.Holds, h.Serialize())
+		}
+
+		requests, err := u.store.PaymentRequest.GetPaymentRequestsByAccount(a.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range requests {
+			if seenRequests[r.ID] {
+				continue
+			}
+			seenRequests[r.ID] = true
+			export.PaymentRequests = append(export.PaymentRequests, r.Serialize())
+		}
+
+		statements, err := u.store.Statement.GetStatementsByAccount(a.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, st := range statements {
+			export.Statements = append(export.Statements, st.Serialize())
+		}
+	}
+
+	keys, err := u.store.ApiKey.GetApiKeysByUser(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		export.ApiKeys = append(export.ApiKeys, k.Serialize())
+	}
+
+	beneficiaries, err := u.store.Beneficiary.GetBeneficiariesByUser(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range beneficiaries {
+		export.Beneficiaries = append(export.Beneficiaries, b.Serialize())
+	}
+
+	entries, err := u.store.Audit.GetAuditEntriesByActor(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		export.AuditEntries = append(export.AuditEntries, e.Serialize())
+	}
+
+	return export, nil
 }
@@ -42,21 +42,36 @@ func (s *AccountStore) GetAccount(id uint) (*types.Account, error) {
 	return account, nil
 }
 
+/*
+GetAccountByIBAN is a method to get an account by its IBAN.
+*/
+func (s *AccountStore) GetAccountByIBAN(iban string) (*types.Account, error) {
+	query := `SELECT * FROM account WHERE iban = $1`
+
+	account := new(types.Account)
+
+	err := s.db.QueryRowx(query, iban).StructScan(account)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("account_not_found")
+		}
+
+		return nil, err
+	}
+
+	return account, nil
+}
+
 /*
 GetAccountWithUser is a method to get an account by id with the corresponding user.
 It takes an id and returns an Account and an error.
 */
 func (s *AccountStore) GetAccountWithUser(id uint) (*types.Account, error) {
 
-	query := `SELECT a.*, a.balance, u.id AS uid , u.first_name, u.last_name, u.email, u.created_at AS ucreated_at, u.updated_at AS uupadted_at FROM account AS a LEFT JOIN "user" AS u ON a.user_id = u.id WHERE a.id = $1`
+	query := `SELECT a.*, u.id AS uid , u.first_name, u.last_name, u.email, u.created_at AS ucreated_at, u.updated_at AS uupadted_at FROM account AS a LEFT JOIN "user" AS u ON a.user_id = u.id WHERE a.id = $1`
 
 	var result struct {
-		ID        uint      `db:"id"`
-		UserId    uint      `db:"user_id"`
-		Password  string    `db:"password"`
-		Balance   []uint8   `db:"balance"`
-		CreatedAt time.Time `db:"created_at"`
-		UpdatedAt time.Time `db:"updated_at"`
+		types.Account
 		// User relation
 		UID        uint      `db:"uid"`
 		FirstName  string    `db:"first_name"`
@@ -76,21 +91,14 @@ func (s *AccountStore) GetAccountWithUser(id uint) (*types.Account, error) {
 		return nil, err
 	}
 
-	account := &types.Account{
-		ID:        result.ID,
-		UserID:    result.UserId,
-		Password:  result.Password,
-		Balance:   result.Balance,
-		CreatedAt: result.CreatedAt,
-		UpdatedAt: result.UpdatedAt,
-		User: &types.User{
-			ID:        result.UID,
-			FirstName: result.FirstName,
-			LastName:  result.LastName,
-			Email:     result.Email,
-			CreatedAt: result.UCreatedAt,
-			UpdatedAt: result.UUpdatedAt,
-		},
+	account := &result.Account
+	account.User = &types.User{
+		ID:        result.UID,
+		FirstName: result.FirstName,
+		LastName:  result.LastName,
+		Email:     result.Email,
+		CreatedAt: result.UCreatedAt,
+		UpdatedAt: result.UUpdatedAt,
 	}
 
 	return account, nil
@@ -124,16 +132,94 @@ func (s *AccountStore) GetAllAccount() ([]*types.Account, error) {
 }
 
 /*
-CreateAccount is a method to create an account.
-It takes a CreateAccountDTO and returns an error.
+GetAccountsByUser is a method to get all accounts owned by a user.
+It takes a user id and returns an array of Account and an error.
+*/
+func (s *AccountStore) GetAccountsByUser(userId uint) ([]*types.Account, error) {
+	query := `SELECT * FROM account WHERE user_id = $1 ORDER BY id`
+	accounts := []*types.Account{}
+
+	err := s.db.Select(&accounts, query, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+/*
+GetAccountsByHolder is a method to get all accounts a user holds, with the role of the user on each.
+It takes a user id and returns an array of Account and an error.
+*/
+func (s *AccountStore) GetAccountsByHolder(userId uint) ([]*types.Account, error) {
+	query := `SELECT a.*, h.role AS holder_role FROM account AS a JOIN account_holder AS h ON h.account_id = a.id WHERE h.user_id = $1 ORDER BY a.id`
+	accounts := []*types.Account{}
+
+	err := s.db.Select(&accounts, query, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+/*
+CreateAccount is a method to create an account, the user it is created for being its primary holder.
+It takes a CreateAccountDTO and returns the created Account and an error, iban_taken if the IBAN is already used.
 */
-func (s *AccountStore) CreateAccount(account *dto.CreateAccountDTO) error {
-	query := `INSERT INTO account (user_id, password) VALUES ($1, $2)`
-	_, err := s.db.Exec(
+func (s *AccountStore) CreateAccount(data *dto.CreateAccountDTO) (*types.Account, error) {
+	query := `WITH a AS (
+			INSERT INTO account (user_id, password, type, maturity_date, currency, iban) VALUES ($1, $2, $3, $4, $5, $6) RETURNING *
+		), h AS (
+			INSERT INTO account_holder (account_id, user_id, role) SELECT id, user_id, 'primary' FROM a
+		) SELECT * FROM a`
+
+	account := new(types.Account)
+	err := s.db.Get(
+		account,
 		query,
-		account.UserID,
-		account.Password,
+		data.UserID,
+		data.Password,
+		data.Type,
+		data.MaturityDate,
+		data.Currency,
+		data.IBAN,
 	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, errors.New("iban_taken")
+		}
+		return nil, err
+	}
+
+	return account, nil
+}
+
+/*
+GetAccountsWithoutIBAN is a method to get the ids of the accounts not given an IBAN yet.
+*/
+func (s *AccountStore) GetAccountsWithoutIBAN() ([]uint, error) {
+	query := `SELECT id FROM account WHERE iban IS NULL ORDER BY id`
+	ids := []uint{}
+
+	err := s.db.Select(&ids, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+/*
+SetAccountIBAN is a method to give an IBAN to an account that has none.
+It returns iban_taken if the IBAN is already used.
+*/
+func (s *AccountStore) SetAccountIBAN(id uint, iban string) error {
+	query := `UPDATE account SET iban = $2, updated_at = now() WHERE id = $1 AND iban IS NULL`
+	_, err := s.db.Exec(query, id, iban)
+	if isUniqueViolation(err) {
+		return errors.New("iban_taken")
+	}
 	return err
 }
 
@@ -146,3 +232,164 @@ func (s *AccountStore) DeleteAccount(id uint) error {
 	_, err := s.db.Query(query, id)
 	return err
 }
+
+/*
+ChangeAccountStatus is a method to move an account to a new status and record the transition.
+Within a sql transaction, it locks the account, checks that the transition is allowed and
+that a closed account holds no money, has no held amount, no accrued interest left to pay
+and no active standing order.
+*/
+func (s *AccountStore) ChangeAccountStatus(id uint, to types.AccountStatus, reason string, actorUserId *uint) (*types.AccountStatusChange, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+
+	// defer rollback if error
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			_ = tx.Commit()
+		}
+	}()
+
+	account := new(types.Account)
+	err = tx.Get(account, `SELECT * FROM account WHERE id = $1 FOR UPDATE`, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = errors.New("account_not_found")
+		}
+		return nil, err
+	}
+
+	serialized := account.Serialize()
+	if !account.Status.CanTransitionTo(to) {
+		err = errors.New("invalid_status_transition")
+		return nil, err
+	} else if to == types.AccountClosed && serialized.Balance != 0 {
+		err = errors.New("non_zero_balance")
+		return nil, err
+	} else if to == types.AccountClosed && serialized.HeldAmount != 0 {
+		err = errors.New("active_holds")
+		return nil, err
+	} else if to == types.AccountClosed && serialized.AccruedInterest != 0 {
+		err = errors.New("accrued_interest_outstanding")
+		return nil, err
+	}
+
+	if to == types.AccountClosed {
+		var orders int
+		err = tx.Get(&orders, `SELECT count(*) FROM standing_order WHERE account_id = $1 AND status = 'active'`, id)
+		if err != nil {
+			return nil, err
+		} else if orders > 0 {
+			err = errors.New("active_standing_orders")
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE account SET status = $2, updated_at = now() WHERE id = $1`, id, to)
+	if err != nil {
+		return nil, err
+	}
+
+	change := new(types.AccountStatusChange)
+	query := `INSERT INTO account_status_history (account_id, from_status, to_status, reason, actor_user_id) VALUES ($1, $2, $3, $4, $5) RETURNING *`
+	err = tx.QueryRowx(query, id, account.Status, to, reason, actorUserId).StructScan(change)
+	if err != nil {
+		return nil, err
+	}
+
+	return change, nil
+}
+
+/*
+GetAccountStatusHistory is a method to get every status transition of an account, oldest first.
+*/
+func (s *AccountStore) GetAccountStatusHistory(id uint) ([]*types.AccountStatusChange, error) {
+	query := `SELECT * FROM account_status_history WHERE account_id = $1 ORDER BY id`
+	changes := []*types.AccountStatusChange{}
+
+	err := s.db.Select(&changes, query, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+/*
+MarkDormantAccounts is a method to move every active customer account without activity since
+the given time to the dormant status, recording the transitions.
+It returns the number of accounts that became dormant.
+*/
+func (s *AccountStore) MarkDormantAccounts(inactiveSince time.Time) (int64, error) {
+	query := `WITH d AS (
+			UPDATE account SET status = 'dormant', updated_at = now()
+			WHERE status = 'active' AND system_code IS NULL AND last_activity_at < $1
+			RETURNING id
+		)
+		INSERT INTO account_status_history (account_id, from_status, to_status, reason)
+		SELECT id, 'active', 'dormant', 'inactivity' FROM d`
+
+	res, err := s.db.Exec(query, inactiveSince)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+/*
+TouchAccountActivity is a method to record an activity of the owner on an account.
+*/
+func (s *AccountStore) TouchAccountActivity(id uint) error {
+	query := `UPDATE account SET last_activity_at = now() WHERE id = $1`
+	_, err := s.db.Exec(query, id)
+	return err
+}
+
+/*
+SetOverdraftLimit is a method to set the overdraft limit of an account.
+It fails if the account is already overdrawn beyond the new limit.
+*/
+func (s *AccountStore) SetOverdraftLimit(id uint, limit float64) (*types.Account, error) {
+	query := `UPDATE account SET overdraft_limit = $2, updated_at = now() WHERE id = $1 RETURNING *`
+
+	account := new(types.Account)
+	err := s.db.QueryRowx(query, id, limit).StructScan(account)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("account_not_found")
+		} else if isCheckViolation(err) {
+			return nil, errors.New("overdraft_below_balance")
+		}
+
+		return nil, err
+	}
+
+	return account, nil
+}
+
+/*
+SetTransferLimits is a method to set the transfer limits overrides of an account.
+A nil limit removes the override, so that the limit of the account type applies.
+*/
+func (s *AccountStore) SetTransferLimits(id uint, limits *dto.SetTransferLimitsDTO) (*types.Account, error) {
+	query := `UPDATE account SET max_transfer_amount = $2, daily_transfer_amount = $3, daily_transfer_count = $4, updated_at = now() WHERE id = $1 RETURNING *`
+
+	account := new(types.Account)
+	err := s.db.QueryRowx(query, id, limits.MaxAmount, limits.DailyAmount, limits.DailyCount).StructScan(account)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("account_not_found")
+		}
+
+		return nil, err
+	}
+
+	return account, nil
+}
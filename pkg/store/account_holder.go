@@ -0,0 +1,199 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/farischt/gobank/pkg/types"
+	"github.com/jmoiron/sqlx"
+)
+
+type AccountHolderStore struct {
+	db *sqlx.DB
+}
+
+func NewAccountHolder(db *sqlx.DB) *AccountHolderStore {
+	return &AccountHolderStore{db: db}
+}
+
+/*
+GetAccountHolders is a method to get the holders of an account with their name and email,
+the primary holder first.
+*/
+func (s *AccountHolderStore) GetAccountHolders(accountId uint) ([]*types.AccountHolder, error) {
+	query := `SELECT h.*, u.first_name, u.last_name, u.email FROM account_holder AS h JOIN "user" AS u ON u.id = h.user_id
+		WHERE h.account_id = $1 ORDER BY h.role <> 'primary', h.created_at, h.user_id`
+	holders := []*types.AccountHolder{}
+
+	err := s.db.Select(&holders, query, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	return holders, nil
+}
+
+/*
+GetAccountHolder is a method to get the holding of a user on an account.
+It returns nil if the user doesn't hold the account.
+*/
+func (s *AccountHolderStore) GetAccountHolder(accountId uint, userId uint) (*types.AccountHolder, error) {
+	query := `SELECT * FROM account_holder WHERE account_id = $1 AND user_id = $2`
+
+	holder := new(types.AccountHolder)
+	err := s.db.Get(holder, query, accountId, userId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return holder, nil
+}
+
+/*
+CreateHolderChange is a method to ask for a holder to be added to or removed from an account.
+It returns account_holder_change_already_exist if a change of the same holder is already pending.
+*/
+func (s *AccountHolderStore) CreateHolderChange(accountId uint, userId uint, action types.HolderChangeAction, role *types.HolderRole, requestedBy uint) (*types.AccountHolderChange, error) {
+	query := `INSERT INTO account_holder_change (account_id, user_id, action, role, requested_by) VALUES ($1, $2, $3, $4, $5) RETURNING *`
+
+	change := new(types.AccountHolderChange)
+	err := s.db.Get(change, query, accountId, userId, action, role, requestedBy)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, errors.New("account_holder_change_already_exist")
+		}
+		return nil, err
+	}
+
+	return change, nil
+}
+
+/*
+GetHolderChange is a method to get a change of the holders of an account by id.
+*/
+func (s *AccountHolderStore) GetHolderChange(id uint) (*types.AccountHolderChange, error) {
+	query := `SELECT * FROM account_holder_change WHERE id = $1`
+
+	change := new(types.AccountHolderChange)
+	err := s.db.Get(change, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("account_holder_change_not_found")
+		}
+		return nil, err
+	}
+
+	return change, nil
+}
+
+/*
+GetHolderChangesByAccount is a method to get every change of the holders of an account, latest first.
+*/
+func (s *AccountHolderStore) GetHolderChangesByAccount(accountId uint) ([]*types.AccountHolderChange, error) {
+	query := `SELECT * FROM account_holder_change WHERE account_id = $1 ORDER BY id DESC`
+	changes := []*types.AccountHolderChange{}
+
+	err := s.db.Select(&changes, query, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+/*
+ApproveHolderChange is a method to apply a pending change of the holders of an account
+on behalf of the user consenting to it.
+Within a sql transaction, it adds or removes the holder and marks the change as approved.
+It returns account_holder_change_not_pending if the change was already decided,
+account_holder_already_exist if the user to add already holds the account and
+account_holder_not_found if the user to remove doesn't hold it anymore.
+The primary holder is never removed.
+*/
+func (s *AccountHolderStore) ApproveHolderChange(id uint, decidedBy uint) (*types.AccountHolderChange, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+
+	// defer rollback if error
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	change := new(types.AccountHolderChange)
+	err = tx.Get(change, `SELECT * FROM account_holder_change WHERE id = $1 FOR UPDATE`, id)
+	if err == sql.ErrNoRows {
+		err = errors.New("account_holder_change_not_found")
+		return nil, err
+	} else if err != nil {
+		return nil, err
+	} else if change.Status != types.HolderChangePending {
+		err = errors.New("account_holder_change_not_pending")
+		return nil, err
+	}
+
+	switch change.Action {
+	case types.HolderChangeAdd:
+		_, err = tx.Exec(`INSERT INTO account_holder (account_id, user_id, role) VALUES ($1, $2, $3)`, change.AccountID, change.UserID, change.Role)
+		if isUniqueViolation(err) {
+			err = errors.New("account_holder_already_exist")
+		}
+	case types.HolderChangeRemove:
+		var res sql.Result
+		res, err = tx.Exec(`DELETE FROM account_holder WHERE account_id = $1 AND user_id = $2 AND role <> 'primary'`, change.AccountID, change.UserID)
+		if err == nil {
+			var n int64
+			n, err = res.RowsAffected()
+			if err == nil && n == 0 {
+				err = errors.New("account_holder_not_found")
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Get(
+		change,
+		`UPDATE account_holder_change SET status = 'approved', decided_by = $2, decided_at = now(), updated_at = now() WHERE id = $1 RETURNING *`,
+		id,
+		decidedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return change, nil
+}
+
+/*
+RejectHolderChange is a method to refuse a pending change of the holders of an account
+on behalf of the user refusing it.
+It returns account_holder_change_not_pending if the change was already decided.
+*/
+func (s *AccountHolderStore) RejectHolderChange(id uint, decidedBy uint) (*types.AccountHolderChange, error) {
+	query := `UPDATE account_holder_change SET status = 'rejected', decided_by = $2, decided_at = now(), updated_at = now()
+		WHERE id = $1 AND status = 'pending' RETURNING *`
+
+	change := new(types.AccountHolderChange)
+	err := s.db.Get(change, query, id, decidedBy)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("account_holder_change_not_pending")
+		}
+		return nil, err
+	}
+
+	return change, nil
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/farischt/gobank/pkg/types"
+	"github.com/jmoiron/sqlx"
+)
+
+type ApiKeyStore struct {
+	db *sqlx.DB
+}
+
+func NewApiKey(db *sqlx.DB) *ApiKeyStore {
+	return &ApiKeyStore{db: db}
+}
+
+/*
+CreateApiKey is a method to create an API key.
+It takes an ApiKey and returns the created ApiKey and an error.
+*/
+func (s *ApiKeyStore) CreateApiKey(key *types.ApiKey) (*types.ApiKey, error) {
+	query := `INSERT INTO api_key (user_id, account_id, name, prefix, secret_hash, scopes, allowed_ips, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *`
+
+	created := new(types.ApiKey)
+	err := s.db.QueryRowx(
+		query,
+		key.UserID,
+		key.AccountID,
+		key.Name,
+		key.Prefix,
+		key.SecretHash,
+		key.Scopes,
+		key.AllowedIPs,
+		key.ExpiresAt,
+	).StructScan(created)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+/*
+GetApiKeyByPrefix is a method to get an API key by its public prefix.
+It returns an error if the key is not found.
+*/
+func (s *ApiKeyStore) GetApiKeyByPrefix(prefix string) (*types.ApiKey, error) {
+	query := `SELECT * FROM api_key WHERE prefix = $1`
+
+	key := new(types.ApiKey)
+	err := s.db.Get(key, query, prefix)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("api_key_not_found")
+		}
+
+		return nil, err
+	}
+
+	return key, nil
+}
+
+/*
+GetApiKeysByUser is a method to get all API keys of a user.
+*/
+func (s *ApiKeyStore) GetApiKeysByUser(userId uint) ([]*types.ApiKey, error) {
+	query := `SELECT * FROM api_key WHERE user_id = $1 ORDER BY id`
+	keys := []*types.ApiKey{}
+
+	err := s.db.Select(&keys, query, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+/*
+RevokeApiKey is a method to revoke an API key of a user.
+It returns false if the user has no such active key.
+*/
+func (s *ApiKeyStore) RevokeApiKey(id uint, userId uint) (bool, error) {
+	query := `UPDATE api_key SET revoked_at = now(), updated_at = now() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+	res, err := s.db.Exec(query, id, userId)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+/*
+TouchApiKey is a method to record the last use of an API key.
+*/
+func (s *ApiKeyStore) TouchApiKey(id uint, ip string) error {
+	query := `UPDATE api_key SET last_used_at = now(), last_used_ip = $2 WHERE id = $1`
+	_, err := s.db.Exec(query, id, ip)
+	return err
+}
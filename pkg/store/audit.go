@@ -0,0 +1,60 @@
+package store
+
+import (
+	"github.com/farischt/gobank/pkg/types"
+	"github.com/jmoiron/sqlx"
+)
+
+type AuditStore struct {
+	db *sqlx.DB
+}
+
+func NewAudit(db *sqlx.DB) *AuditStore {
+	return &AuditStore{db: db}
+}
+
+/*
+CreateAuditEntry is a method to append an entry to the audit log.
+*/
+func (s *AuditStore) CreateAuditEntry(entry *types.AuditEntry) error {
+	query := `INSERT INTO audit_log (actor_user_id, permission, method, path, status) VALUES ($1, $2, $3, $4, $5)`
+	_, err := s.db.Exec(
+		query,
+		entry.ActorUserID,
+		entry.Permission,
+		entry.Method,
+		entry.Path,
+		entry.Status,
+	)
+	return err
+}
+
+/*
+GetAuditEntries is a method to get the audit log, most recent first.
+*/
+func (s *AuditStore) GetAuditEntries(offset uint, limit uint) ([]*types.AuditEntry, error) {
+	query := `SELECT * FROM audit_log ORDER BY id DESC OFFSET $1 LIMIT $2`
+	entries := []*types.AuditEntry{}
+
+	err := s.db.Select(&entries, query, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+/*
+GetAuditEntriesByActor is a method to get the audit entries of the actions performed by a user.
+*/
+func (s *AuditStore) GetAuditEntriesByActor(userId uint) ([]*types.AuditEntry, error) {
+	query := `SELECT * FROM audit_log WHERE actor_user_id = $1 ORDER BY id`
+	entries := []*types.AuditEntry{}
+
+	err := s.db.Select(&entries, query, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
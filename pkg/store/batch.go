@@ -0,0 +1,226 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/farischt/gobank/pkg/types"
+	"github.com/jmoiron/sqlx"
+)
+
+type BatchStore struct {
+	db *sqlx.DB
+}
+
+func NewBatch(db *sqlx.DB) *BatchStore {
+	return &BatchStore{db: db}
+}
+
+/*
+CreateBatch is a method to record a batch with its lines.
+It returns batch_already_exist if a message with the same id was already uploaded for the account.
+*/
+func (s *BatchStore) CreateBatch(b *types.BatchEntry) (*types.Batch, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+
+	// defer rollback if error
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			_ = tx.Commit()
+		}
+	}()
+
+	batch := new(types.Batch)
+	query := `INSERT INTO batch (account_id, format, mode, status, message_id, line_count, control_sum)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *`
+	err = tx.QueryRowx(query, b.AccountID, b.Format, b.Mode, b.Status, b.MessageID, len(b.Lines), b.ControlSum).StructScan(batch)
+	if err != nil {
+		if isUniqueViolation(err) {
+			err = errors.New("batch_already_exist")
+		}
+		return nil, err
+	}
+
+	query = `INSERT INTO batch_line (batch_id, line_number, to_iban, to_id, amount, reference, creditor_reference, end_to_end_id, status, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	for _, l := range b.Lines {
+		_, err = tx.Exec(query, batch.ID, l.LineNumber, l.ToIBAN, l.To, l.Amount, l.Reference, l.CreditorReference, l.EndToEndID, l.Status, l.Error)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return batch, nil
+}
+
+/*
+GetBatch is a method to get a batch by id.
+*/
+func (s *BatchStore) GetBatch(id uint) (*types.Batch, error) {
+	query := `SELECT * FROM batch WHERE id = $1`
+
+	batch := new(types.Batch)
+	err := s.db.Get(batch, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("batch_not_found")
+		}
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+/*
+GetBatchesByAccount is a method to get every batch of an account, latest first.
+*/
+func (s *BatchStore) GetBatchesByAccount(accountId uint) ([]*types.Batch, error) {
+	query := `SELECT * FROM batch WHERE account_id = $1 ORDER BY id DESC`
+	batches := []*types.Batch{}
+
+	err := s.db.Select(&batches, query, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	return batches, nil
+}
+
+/*
+GetBatchLines is a method to get the lines of a batch, in the order of the file.
+*/
+func (s *BatchStore) GetBatchLines(batchId uint) ([]*types.BatchLine, error) {
+	query := `SELECT * FROM batch_line WHERE batch_id = $1 ORDER BY line_number`
+	lines := []*types.BatchLine{}
+
+	err := s.db.Select(&lines, query, batchId)
+	if err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+/*
+SetBatchStatus is a method to move a batch from a status to another.
+It returns batch_not_pending if the batch isn't in the expected status anymore.
+*/
+func (s *BatchStore) SetBatchStatus(id uint, from types.BatchStatus, to types.BatchStatus) (*types.Batch, error) {
+	return setBatchStatus(s.db, id, from, to)
+}
+
+func setBatchStatus(q sqlx.Queryer, id uint, from types.BatchStatus, to types.BatchStatus) (*types.Batch, error) {
+	query := `UPDATE batch SET status = $3, updated_at = now(),
+			executed_at = CASE WHEN $3 IN ('completed', 'partially_completed', 'failed') THEN now() ELSE executed_at END
+		WHERE id = $1 AND status = $2 RETURNING *`
+
+	batch := new(types.Batch)
+	err := q.QueryRowx(query, id, from, to).StructScan(batch)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("batch_not_pending")
+		}
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+/*
+SetBatchLineResult is a method to record the outcome of a pending line of a batch, the transaction
+it was executed into or the reason it failed. The outcome of a line is recorded once.
+*/
+func (s *BatchStore) SetBatchLineResult(id uint, status types.BatchLineStatus, transactionId *uint, reason *string) error {
+	return setBatchLineResult(s.db, id, status, transactionId, reason)
+}
+
+func setBatchLineResult(e sqlx.Execer, id uint, status types.BatchLineStatus, transactionId *uint, reason *string) error {
+	query := `UPDATE batch_line SET status = $2, transaction_id = $3, error = $4, updated_at = now() WHERE id = $1 AND status = 'pending'`
+	_, err := e.Exec(query, id, status, transactionId, reason)
+	return err
+}
+
+/*
+ClaimStaleBatches is a method to claim the batches left executing, neither the batch nor any
+of its lines having been updated since the given time.
+Claiming a batch touches it, so that it is claimed once until it stalls again.
+*/
+func (s *BatchStore) ClaimStaleBatches(before time.Time) ([]*types.Batch, error) {
+	query := `UPDATE batch AS b SET updated_at = now()
+		WHERE b.status = 'executing' AND b.updated_at < $1
+			AND NOT EXISTS (SELECT 1 FROM batch_line AS l WHERE l.batch_id = b.id AND l.updated_at >= $1)
+		RETURNING b.*`
+	batches := []*types.Batch{}
+
+	err := s.db.Select(&batches, query, before)
+	if err != nil {
+		return nil, err
+	}
+
+	return batches, nil
+}
+
+/*
+RunInTx runs the given function within a sql transaction, giving it access to the
+operations needed to execute a batch.
+*/
+func (s *BatchStore) RunInTx(fn func(tx BatchTx) error) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	// defer rollback if error
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = fn(&batchTx{transferTx{tx: tx}})
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	return err
+}
+
+/*
+batchTx implements BatchTx on top of a sql transaction.
+*/
+type batchTx struct {
+	transferTx
+}
+
+/*
+LockBatch locks the given batch until the end of the sql transaction and returns it.
+*/
+func (t *batchTx) LockBatch(id uint) (*types.Batch, error) {
+	query := `SELECT * FROM batch WHERE id = $1 FOR UPDATE`
+
+	batch := new(types.Batch)
+	err := t.tx.Get(batch, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("batch_not_found")
+		}
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+func (t *batchTx) SetBatchStatus(id uint, from types.BatchStatus, to types.BatchStatus) (*types.Batch, error) {
+	return setBatchStatus(t.tx, id, from, to)
+}
+
+func (t *batchTx) SetBatchLineResult(id uint, status types.BatchLineStatus, transactionId *uint, reason *string) error {
+	return setBatchLineResult(t.tx, id, status, transactionId, reason)
+}
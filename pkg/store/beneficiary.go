@@ -0,0 +1,131 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/types"
+	"github.com/jmoiron/sqlx"
+)
+
+type BeneficiaryStore struct {
+	db *sqlx.DB
+}
+
+func NewBeneficiary(db *sqlx.DB) *BeneficiaryStore {
+	return &BeneficiaryStore{db: db}
+}
+
+/*
+CreateBeneficiary is a method to save an account as a beneficiary of a user.
+It returns beneficiary_already_exist if the user already saved the account.
+*/
+func (s *BeneficiaryStore) CreateBeneficiary(b *types.Beneficiary) (*types.Beneficiary, error) {
+	query := `INSERT INTO beneficiary (user_id, account_id, iban, nickname, default_reference, masked_name, name_match)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *`
+
+	beneficiary := new(types.Beneficiary)
+	err := s.db.QueryRowx(
+		query,
+		b.UserID,
+		b.AccountID,
+		b.IBAN,
+		b.Nickname,
+		b.DefaultReference,
+		b.MaskedName,
+		b.NameMatch,
+	).StructScan(beneficiary)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, errors.New("beneficiary_already_exist")
+		}
+		return nil, err
+	}
+
+	return beneficiary, nil
+}
+
+/*
+GetBeneficiary is a method to get a beneficiary by id.
+*/
+func (s *BeneficiaryStore) GetBeneficiary(id uint) (*types.Beneficiary, error) {
+	query := `SELECT * FROM beneficiary WHERE id = $1`
+
+	beneficiary := new(types.Beneficiary)
+	err := s.db.Get(beneficiary, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("beneficiary_not_found")
+		}
+		return nil, err
+	}
+
+	return beneficiary, nil
+}
+
+/*
+GetBeneficiariesByUser is a method to get every beneficiary of a user, by nickname.
+*/
+func (s *BeneficiaryStore) GetBeneficiariesByUser(userId uint) ([]*types.Beneficiary, error) {
+	query := `SELECT * FROM beneficiary WHERE user_id = $1 ORDER BY nickname, id`
+	beneficiaries := []*types.Beneficiary{}
+
+	err := s.db.Select(&beneficiaries, query, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return beneficiaries, nil
+}
+
+/*
+UpdateBeneficiary is a method to change the nickname or the default reference of a beneficiary.
+An empty default reference removes it.
+*/
+func (s *BeneficiaryStore) UpdateBeneficiary(id uint, data *dto.UpdateBeneficiaryDTO) (*types.Beneficiary, error) {
+	query := `UPDATE beneficiary SET
+			nickname = COALESCE($2, nickname),
+			default_reference = CASE WHEN $3::VARCHAR IS NULL THEN default_reference ELSE NULLIF($3::VARCHAR, '') END,
+			updated_at = now()
+		WHERE id = $1 RETURNING *`
+
+	beneficiary := new(types.Beneficiary)
+	err := s.db.QueryRowx(query, id, data.Nickname, data.DefaultReference).StructScan(beneficiary)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("beneficiary_not_found")
+		}
+		return nil, err
+	}
+
+	return beneficiary, nil
+}
+
+/*
+ConfirmBeneficiary is a method to record that the user confirmed a beneficiary.
+Confirming it again keeps the first confirmation time.
+*/
+func (s *BeneficiaryStore) ConfirmBeneficiary(id uint) (*types.Beneficiary, error) {
+	query := `UPDATE beneficiary SET confirmed_at = COALESCE(confirmed_at, now()), updated_at = now() WHERE id = $1 RETURNING *`
+
+	beneficiary := new(types.Beneficiary)
+	err := s.db.QueryRowx(query, id).StructScan(beneficiary)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("beneficiary_not_found")
+		}
+		return nil, err
+	}
+
+	return beneficiary, nil
+}
+
+/*
+DeleteBeneficiary is a method to delete a beneficiary by id.
+*/
+func (s *BeneficiaryStore) DeleteBeneficiary(id uint) error {
+	query := `DELETE FROM beneficiary WHERE id = $1`
+	_, err := s.db.Exec(query, id)
+	return err
+}
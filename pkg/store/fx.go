@@ -0,0 +1,70 @@
+package store
+
+import (
+	"database/sql"
+
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/types"
+	"github.com/jmoiron/sqlx"
+)
+
+type FxStore struct {
+	db *sqlx.DB
+}
+
+func NewFx(db *sqlx.DB) *FxStore {
+	return &FxStore{db: db}
+}
+
+/*
+CreateFxRate is a method to record the rate of a currency pair.
+Rates are never overwritten, the latest as of time wins.
+*/
+func (s *FxStore) CreateFxRate(data *dto.SetFxRateDTO, source string) (*types.FxRate, error) {
+	query := `INSERT INTO fx_rate (base, quote, rate, as_of, source) VALUES ($1, $2, $3, COALESCE($4, now()), $5) RETURNING *`
+
+	rate := new(types.FxRate)
+	err := s.db.QueryRowx(query, data.Base, data.Quote, data.Rate, data.AsOf, source).StructScan(rate)
+	if err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+/*
+GetFxRate is a method to get the latest rate of a currency pair, nil if none.
+*/
+func (s *FxStore) GetFxRate(base string, quote string) (*types.FxRate, error) {
+	return getFxRate(s.db, base, quote)
+}
+
+/*
+GetLatestFxRates is a method to get the latest rate of every currency pair.
+*/
+func (s *FxStore) GetLatestFxRates() ([]*types.FxRate, error) {
+	query := `SELECT DISTINCT ON (base, quote) * FROM fx_rate ORDER BY base, quote, as_of DESC, id DESC`
+	rates := []*types.FxRate{}
+
+	err := s.db.Select(&rates, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+func getFxRate(q sqlx.Queryer, base string, quote string) (*types.FxRate, error) {
+	query := `SELECT * FROM fx_rate WHERE base = $1 AND quote = $2 ORDER BY as_of DESC, id DESC LIMIT 1`
+
+	rate := new(types.FxRate)
+	err := sqlx.Get(q, rate, query, base, quote)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return rate, nil
+}
@@ -0,0 +1,209 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/farischt/gobank/pkg/types"
+	"github.com/jmoiron/sqlx"
+)
+
+type HoldStore struct {
+	db *sqlx.DB
+}
+
+func NewHold(db *sqlx.DB) *HoldStore {
+	return &HoldStore{db: db}
+}
+
+/*
+GetHold is a method to get a hold by id.
+*/
+func (s *HoldStore) GetHold(id uint) (*types.Hold, error) {
+	query := `SELECT * FROM hold WHERE id = $1`
+
+	hold := new(types.Hold)
+	err := s.db.Get(hold, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("hold_not_found")
+		}
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+/*
+GetHoldsByAccount is a method to get every hold placed on an account, latest first.
+*/
+func (s *HoldStore) GetHoldsByAccount(accountId uint) ([]*types.Hold, error) {
+	query := `SELECT * FROM hold WHERE account_id = $1 ORDER BY id DESC`
+	holds := []*types.Hold{}
+
+	err := s.db.Select(&holds, query, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	return holds, nil
+}
+
+/*
+ExpireHolds is a method to expire every active hold past its expiry time, giving the
+held amounts back to the accounts. It returns the number of holds expired.
+*/
+func (s *HoldStore) ExpireHolds(now time.Time) (int64, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+
+	// defer rollback if error
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			_ = tx.Commit()
+		}
+	}()
+
+	// Lock the accounts before the holds, in the same order as captures do
+	_, err = tx.Exec(`SELECT id FROM account WHERE id IN (SELECT account_id FROM hold WHERE status = 'active' AND expires_at <= $1) ORDER BY id FOR UPDATE`, now)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `WITH e AS (
+			UPDATE hold SET status = 'expired', updated_at = now()
+			WHERE status = 'active' AND expires_at <= $1
+			RETURNING account_id, amount
+		), t AS (
+			SELECT account_id, SUM(amount) AS amount, count(*) AS holds FROM e GROUP BY account_id
+		)
+		UPDATE account SET held_amount = held_amount - t.amount FROM t WHERE account.id = t.account_id
+		RETURNING t.holds`
+
+	counts := []int64{}
+	err = tx.Select(&counts, query, now)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+	for _, c := range counts {
+		n += c
+	}
+
+	return n, nil
+}
+
+/*
+RunInTx runs the given function within a sql transaction, giving it access to the
+operations needed to place, capture and release holds.
+*/
+func (s *HoldStore) RunInTx(fn func(tx HoldTx) error) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	// defer rollback if error
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = fn(&holdTx{transferTx{tx: tx}})
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	return err
+}
+
+/*
+holdTx implements HoldTx on top of a sql transaction.
+*/
+type holdTx struct {
+	transferTx
+}
+
+/*
+CreateHold places a hold on an account and adds its amount to the held amount of the account.
+*/
+func (t *holdTx) CreateHold(accountId uint, to uint, amount float64, expiresAt time.Time) (*types.Hold, error) {
+	query := `INSERT INTO hold (account_id, to_id, amount, expires_at) VALUES ($1, $2, $3, $4) RETURNING *`
+
+	hold := new(types.Hold)
+	err := t.tx.QueryRowx(query, accountId, to, amount, expiresAt).StructScan(hold)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = t.tx.Exec(`UPDATE account SET held_amount = held_amount + $2, updated_at = now() WHERE id = $1`, accountId, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+/*
+LockHold locks the given hold until the end of the sql transaction and returns it.
+*/
+func (t *holdTx) LockHold(id uint) (*types.Hold, error) {
+	query := `SELECT * FROM hold WHERE id = $1 FOR UPDATE`
+
+	hold := new(types.Hold)
+	err := t.tx.Get(hold, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("hold_not_found")
+		}
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+/*
+CloseHold moves an active hold to the given status and removes its amount from the held amount of the account.
+*/
+func (t *holdTx) CloseHold(id uint, status types.HoldStatus, capturedAmount *float64) (*types.Hold, error) {
+	query := `UPDATE hold SET status = $2, captured_amount = $3, updated_at = now() WHERE id = $1 AND status = 'active' RETURNING *`
+
+	hold := new(types.Hold)
+	err := t.tx.QueryRowx(query, id, status, capturedAmount).StructScan(hold)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("hold_not_active")
+		}
+		return nil, err
+	}
+
+	_, err = t.tx.Exec(`UPDATE account SET held_amount = held_amount - $2, updated_at = now() WHERE id = $1`, hold.AccountID, hold.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+/*
+SetHoldTransaction links a captured hold to the transfer it was captured into.
+*/
+func (t *holdTx) SetHoldTransaction(id uint, transactionId uint) (*types.Hold, error) {
+	query := `UPDATE hold SET transaction_id = $2, updated_at = now() WHERE id = $1 RETURNING *`
+
+	hold := new(types.Hold)
+	err := t.tx.QueryRowx(query, id, transactionId).StructScan(hold)
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
@@ -0,0 +1,151 @@
+package store
+
+import (
+	"time"
+
+	"github.com/farischt/gobank/pkg/types"
+	"github.com/jmoiron/sqlx"
+)
+
+type InterestStore struct {
+	db *sqlx.DB
+}
+
+func NewInterest(db *sqlx.DB) *InterestStore {
+	return &InterestStore{db: db}
+}
+
+/*
+GetInterestBearingAccounts returns the ids of the customer accounts of the given type
+that were open on the business date.
+*/
+func (s *InterestStore) GetInterestBearingAccounts(accountType types.AccountType, businessDate time.Time) ([]uint, error) {
+	query := `SELECT id FROM account WHERE type = $1 AND system_code IS NULL AND status <> 'closed' AND created_at::date <= $2 ORDER BY id`
+	ids := []uint{}
+
+	err := s.db.Select(&ids, query, accountType, businessDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+/*
+GetLastBusinessDate returns the last business date interest was accrued for, nil if none.
+*/
+func (s *InterestStore) GetLastBusinessDate() (*time.Time, error) {
+	query := `SELECT max(business_date) FROM interest_accrual`
+
+	var last *time.Time
+	err := s.db.Get(&last, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return last, nil
+}
+
+/*
+RunInTx runs the given function within a sql transaction, giving it access to the
+operations needed to accrue and capitalize interest.
+*/
+func (s *InterestStore) RunInTx(fn func(tx InterestTx) error) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	// defer rollback if error
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = fn(&interestTx{transferTx{tx: tx}})
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	return err
+}
+
+/*
+interestTx implements InterestTx on top of a sql transaction.
+*/
+type interestTx struct {
+	transferTx
+}
+
+/*
+GetBalanceAt returns the balance of an account at the given time,
+the current balance without what moved since.
+*/
+func (t *interestTx) GetBalanceAt(accountId uint, at time.Time) (float64, error) {
+	query := `SELECT a.balance
+			- COALESCE((SELECT SUM(to_amount) FROM transaction WHERE to_id = a.id AND created_at >= $2), 0)
+			+ COALESCE((SELECT SUM(amount) FROM transaction WHERE from_id = a.id AND created_at >= $2), 0)
+		FROM account AS a WHERE a.id = $1`
+
+	var balance float64
+	err := t.tx.Get(&balance, query, accountId, at)
+	if err != nil {
+		return 0, err
+	}
+
+	return balance, nil
+}
+
+/*
+CreateAccrual records the interest accrued by an account for a business date and adds it
+to the accrued interest of the account.
+It returns false without changing anything if the business date was already accrued.
+*/
+func (t *interestTx) CreateAccrual(a *types.InterestAccrual) (bool, error) {
+	query := `INSERT INTO interest_accrual (account_id, business_date, balance, rate, day_count, amount) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT DO NOTHING`
+
+	res, err := t.tx.Exec(query, a.AccountID, a.BusinessDate, a.Balance, a.Rate, a.DayCount, a.Amount)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil || n == 0 {
+		return false, err
+	}
+
+	_, err = t.tx.Exec(`UPDATE account SET accrued_interest = accrued_interest + $2 WHERE id = $1`, a.AccountID, a.Amount)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+/*
+CreateCapitalization records the capitalization of the accrued interest of an account
+for a business date and removes the amount paid from its accrued interest.
+It returns false without changing anything if the business date was already capitalized.
+*/
+func (t *interestTx) CreateCapitalization(c *types.InterestCapitalization) (bool, error) {
+	query := `INSERT INTO interest_capitalization (account_id, business_date, amount, transaction_id) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING`
+
+	res, err := t.tx.Exec(query, c.AccountID, c.BusinessDate, c.Amount, c.TransactionID)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil || n == 0 {
+		return false, err
+	}
+
+	_, err = t.tx.Exec(`UPDATE account SET accrued_interest = accrued_interest - $2 WHERE id = $1`, c.AccountID, c.Amount)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,245 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/types"
+	"github.com/jmoiron/sqlx"
+)
+
+// Selects the payment requests of the r relation with the IBANs of their accounts and the currency of the requester
+const selectPaymentRequests = `SELECT r.*, ra.iban AS requester_iban, pa.iban AS payer_iban, ra.currency
+	FROM r JOIN account AS ra ON ra.id = r.requester_id JOIN account AS pa ON pa.id = r.payer_id`
+
+type PaymentRequestStore struct {
+	db *sqlx.DB
+}
+
+func NewPaymentRequest(db *sqlx.DB) *PaymentRequestStore {
+	return &PaymentRequestStore{db: db}
+}
+
+/*
+CreatePaymentRequest is a method to create a request from an account to be paid by another one.
+It returns payment_request_already_exist if the requester already used the idempotency key.
+*/
+func (s *PaymentRequestStore) CreatePaymentRequest(requesterId uint, payerId uint, data *dto.CreatePaymentRequestDTO, expiresAt time.Time) (*types.PaymentRequest, error) {
+	query := `WITH r AS (
+			INSERT INTO payment_request (requester_id, payer_id, amount, reason, idempotency_key, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6) RETURNING *
+		) ` + selectPaymentRequests
+
+	var reason, key *string
+	if data.Reason != "" {
+		reason = &data.Reason
+	}
+	if data.IdempotencyKey != "" {
+		key = &data.IdempotencyKey
+	}
+
+	request := new(types.PaymentRequest)
+	err := s.db.Get(request, query, requesterId, payerId, data.Amount, reason, key, expiresAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, errors.New("payment_request_already_exist")
+		}
+		return nil, err
+	}
+
+	return request, nil
+}
+
+/*
+GetPaymentRequest is a method to get a payment request by id.
+*/
+func (s *PaymentRequestStore) GetPaymentRequest(id uint) (*types.PaymentRequest, error) {
+	query := `WITH r AS (SELECT * FROM payment_request WHERE id = $1) ` + selectPaymentRequests
+
+	request := new(types.PaymentRequest)
+	err := s.db.Get(request, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("payment_request_not_found")
+		}
+		return nil, err
+	}
+
+	return request, nil
+}
+
+/*
+GetPaymentRequestByIdempotencyKey is a method to get the payment request an account made with
+an idempotency key. It returns nil if there is none.
+*/
+func (s *PaymentRequestStore) GetPaymentRequestByIdempotencyKey(requesterId uint, key string) (*types.PaymentRequest, error) {
+	query := `WITH r AS (SELECT * FROM payment_request WHERE requester_id = $1 AND idempotency_key = $2) ` + selectPaymentRequests
+
+	request := new(types.PaymentRequest)
+	err := s.db.Get(request, query, requesterId, key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return request, nil
+}
+
+/*
+GetPaymentRequestsByRequester is a method to get every payment request made by an account, latest first.
+*/
+func (s *PaymentRequestStore) GetPaymentRequestsByRequester(accountId uint) ([]*types.PaymentRequest, error) {
+	query := `WITH r AS (SELECT * FROM payment_request WHERE requester_id = $1) ` + selectPaymentRequests + ` ORDER BY r.id DESC`
+	requests := []*types.PaymentRequest{}
+
+	err := s.db.Select(&requests, query, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+/*
+GetPaymentRequestsByAccount is a method to get every payment request made by or to an account, latest first.
+*/
+func (s *PaymentRequestStore) GetPaymentRequestsByAccount(accountId uint) ([]*types.PaymentRequest, error) {
+	query := `WITH r AS (SELECT * FROM payment_request WHERE requester_id = $1 OR payer_id = $1) ` + selectPaymentRequests + ` ORDER BY r.id DESC`
+	requests := []*types.PaymentRequest{}
+
+	err := s.db.Select(&requests, query, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+/*
+GetPendingPaymentRequestsByPayer is a method to get the payment requests an account is asked
+to pay and can still accept, the ones expiring first first.
+*/
+func (s *PaymentRequestStore) GetPendingPaymentRequestsByPayer(accountId uint, now time.Time) ([]*types.PaymentRequest, error) {
+	query := `WITH r AS (SELECT * FROM payment_request WHERE payer_id = $1 AND status = 'pending' AND expires_at > $2) ` +
+		selectPaymentRequests + ` ORDER BY r.expires_at, r.id`
+	requests := []*types.PaymentRequest{}
+
+	err := s.db.Select(&requests, query, accountId, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+/*
+ClosePaymentRequest is a method to close a pending payment request without paying it.
+It returns payment_request_not_pending if the request was already closed or has expired.
+*/
+func (s *PaymentRequestStore) ClosePaymentRequest(id uint, status types.PaymentRequestStatus) (*types.PaymentRequest, error) {
+	query := `WITH r AS (
+			UPDATE payment_request SET status = $2, updated_at = now() WHERE id = $1 AND status = 'pending' AND expires_at > now() RETURNING *
+		) ` + selectPaymentRequests
+
+	request := new(types.PaymentRequest)
+	err := s.db.Get(request, query, id, status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("payment_request_not_pending")
+		}
+		return nil, err
+	}
+
+	return request, nil
+}
+
+/*
+ExpirePaymentRequests is a method to expire every pending payment request past its expiry time.
+It returns the number of requests expired.
+*/
+func (s *PaymentRequestStore) ExpirePaymentRequests(now time.Time) (int64, error) {
+	query := `UPDATE payment_request SET status = 'expired', updated_at = now() WHERE status = 'pending' AND expires_at <= $1`
+
+	res, err := s.db.Exec(query, now)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+/*
+RunInTx runs the given function within a sql transaction, giving it access to the
+operations needed to pay a payment request.
+*/
+func (s *PaymentRequestStore) RunInTx(fn func(tx PaymentRequestTx) error) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	// defer rollback if error
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = fn(&paymentRequestTx{transferTx{tx: tx}})
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	return err
+}
+
+/*
+paymentRequestTx implements PaymentRequestTx on top of a sql transaction.
+*/
+type paymentRequestTx struct {
+	transferTx
+}
+
+/*
+LockPaymentRequest locks the given payment request until the end of the sql transaction and returns it.
+*/
+func (t *paymentRequestTx) LockPaymentRequest(id uint) (*types.PaymentRequest, error) {
+	query := `SELECT * FROM payment_request WHERE id = $1 FOR UPDATE`
+
+	request := new(types.PaymentRequest)
+	err := t.tx.Get(request, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("payment_request_not_found")
+		}
+		return nil, err
+	}
+
+	return request, nil
+}
+
+/*
+AcceptPaymentRequest marks a pending payment request as paid by the given transaction.
+*/
+func (t *paymentRequestTx) AcceptPaymentRequest(id uint, transactionId uint) error {
+	query := `UPDATE payment_request SET status = 'accepted', transaction_id = $2, updated_at = now() WHERE id = $1 AND status = 'pending'`
+
+	res, err := t.tx.Exec(query, id, transactionId)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	} else if n == 0 {
+		return errors.New("payment_request_not_pending")
+	}
+
+	return nil
+}
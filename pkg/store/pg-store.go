@@ -8,10 +8,22 @@ import (
 )
 
 type Store struct {
-	User         UserStorer
-	Account      AccountStorer
-	Transaction  TransactionStorer
-	SessionToken SessionTokenStorer
+	User           UserStorer
+	Account        AccountStorer
+	AccountHolder  AccountHolderStorer
+	Transaction    TransactionStorer
+	SessionToken   SessionTokenStorer
+	Role           RoleStorer
+	Audit          AuditStorer
+	ApiKey         ApiKeyStorer
+	Interest       InterestStorer
+	StandingOrder  StandingOrderStorer
+	Hold           HoldStorer
+	Fx             FxStorer
+	Beneficiary    BeneficiaryStorer
+	Statement      StatementStorer
+	Batch          BatchStorer
+	PaymentRequest PaymentRequestStorer
 }
 
 func NewPostgres() (*Store, error) {
@@ -27,9 +39,21 @@ func NewPostgres() (*Store, error) {
 	log.Println("Succesfully connected to postgres database")
 
 	return &Store{
-		User:         NewUser(db),
-		Account:      NewAccount(db),
-		Transaction:  NewTransaction(db),
-		SessionToken: NewSessionToken(db),
+		User:           NewUser(db),
+		Account:        NewAccount(db),
+		AccountHolder:  NewAccountHolder(db),
+		Transaction:    NewTransaction(db),
+		SessionToken:   NewSessionToken(db),
+		Role:           NewRole(db),
+		Audit:          NewAudit(db),
+		ApiKey:         NewApiKey(db),
+		Interest:       NewInterest(db),
+		StandingOrder:  NewStandingOrder(db),
+		Hold:           NewHold(db),
+		Fx:             NewFx(db),
+		Beneficiary:    NewBeneficiary(db),
+		Statement:      NewStatement(db),
+		Batch:          NewBatch(db),
+		PaymentRequest: NewPaymentRequest(db),
 	}, nil
 }
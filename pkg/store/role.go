@@ -0,0 +1,55 @@
+package store
+
+import (
+	"github.com/farischt/gobank/pkg/types"
+	"github.com/jmoiron/sqlx"
+)
+
+type RoleStore struct {
+	db *sqlx.DB
+}
+
+func NewRole(db *sqlx.DB) *RoleStore {
+	return &RoleStore{db: db}
+}
+
+/*
+GetUserRoles is a method to get the roles granted to a user.
+It takes a user id and returns an array of UserRole and an error.
+*/
+func (s *RoleStore) GetUserRoles(userId uint) ([]*types.UserRole, error) {
+	query := `SELECT * FROM user_role WHERE user_id = $1 ORDER BY role`
+	roles := []*types.UserRole{}
+
+	err := s.db.Select(&roles, query, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+/*
+GrantRole is a method to grant a role to a user.
+Granting a role the user already holds is a no-op.
+*/
+func (s *RoleStore) GrantRole(userId uint, role types.Role, grantedBy uint) error {
+	query := `INSERT INTO user_role (user_id, role, granted_by) VALUES ($1, $2, $3) ON CONFLICT (user_id, role) DO NOTHING`
+	_, err := s.db.Exec(query, userId, role, grantedBy)
+	return err
+}
+
+/*
+RevokeRole is a method to revoke a role from a user.
+It returns false if the user did not hold the role.
+*/
+func (s *RoleStore) RevokeRole(userId uint, role types.Role) (bool, error) {
+	query := `DELETE FROM user_role WHERE user_id = $1 AND role = $2`
+	res, err := s.db.Exec(query, userId, role)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -20,15 +20,15 @@ func NewSessionToken(db *sqlx.DB) *SessionTokenStore {
 }
 
 /*
-CreateSessionToken creates a new session token for the given account id.
+CreateSessionToken creates a new session token for the given user, acting on the given account
+unless told otherwise, if any.
 It returns the token id and an error if any.
 */
-func (s *SessionTokenStore) CreateSessionToken(accountId uint) (*types.SessionToken, error) {
+func (s *SessionTokenStore) CreateSessionToken(userId uint, accountId *uint) (*types.SessionToken, error) {
 	token := new(types.SessionToken)
-	query := `INSERT INTO session_token (account_id) VALUES ($1) RETURNING *`
-	// _, _ = s.db.NamedQuery(query, accountId)
+	query := `INSERT INTO session_token (user_id, account_id) VALUES ($1, $2) RETURNING *`
 
-	err := s.db.QueryRowx(query, accountId).StructScan(token)
+	err := s.db.QueryRowx(query, userId, accountId).StructScan(token)
 
 	if err != nil {
 		return nil, err
@@ -77,5 +77,5 @@ func (s *SessionTokenStore) IsValidSessionToken(token string) (uint, bool) {
 	}
 
 	elapsed := time.Since(st.CreatedAt)
-	return st.AccountId, elapsed <= time.Second*1000
+	return st.UserID, elapsed <= time.Second*1000
 }
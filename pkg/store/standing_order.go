@@ -0,0 +1,185 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/farischt/gobank/pkg/dto"
+	"github.com/farischt/gobank/pkg/types"
+	"github.com/jmoiron/sqlx"
+)
+
+type StandingOrderStore struct {
+	db *sqlx.DB
+}
+
+func NewStandingOrder(db *sqlx.DB) *StandingOrderStore {
+	return &StandingOrderStore{db: db}
+}
+
+/*
+CreateStandingOrder is a method to create a standing order sending money from an account to another.
+*/
+func (s *StandingOrderStore) CreateStandingOrder(accountId uint, toId uint, data *dto.CreateStandingOrderDTO, nextRunDate time.Time) (*types.StandingOrder, error) {
+	query := `INSERT INTO standing_order (account_id, to_id, amount, frequency, day_of_month, start_date, end_date, next_run_date)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *`
+
+	order := new(types.StandingOrder)
+	err := s.db.QueryRowx(
+		query,
+		accountId,
+		toId,
+		data.Amount,
+		data.Frequency,
+		data.DayOfMonth,
+		data.StartDate,
+		data.EndDate,
+		nextRunDate,
+	).StructScan(order)
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+/*
+GetStandingOrder is a method to get a standing order by id.
+*/
+func (s *StandingOrderStore) GetStandingOrder(id uint) (*types.StandingOrder, error) {
+	query := `SELECT * FROM standing_order WHERE id = $1`
+
+	order := new(types.StandingOrder)
+	err := s.db.Get(order, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("standing_order_not_found")
+		}
+		return nil, err
+	}
+
+	return order, nil
+}
+
+/*
+GetStandingOrdersByAccount is a method to get every standing order sending money from an account.
+*/
+func (s *StandingOrderStore) GetStandingOrdersByAccount(accountId uint) ([]*types.StandingOrder, error) {
+	query := `SELECT * FROM standing_order WHERE account_id = $1 ORDER BY id`
+	orders := []*types.StandingOrder{}
+
+	err := s.db.Select(&orders, query, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+/*
+UpdateStandingOrder is a method to change the amount or the end date of an active standing order.
+The order is completed if its next run falls after the new end date.
+*/
+func (s *StandingOrderStore) UpdateStandingOrder(id uint, data *dto.UpdateStandingOrderDTO) (*types.StandingOrder, error) {
+	query := `UPDATE standing_order SET
+			amount = COALESCE($2, amount),
+			end_date = COALESCE($3, end_date),
+			status = CASE WHEN COALESCE($3, end_date) < next_run_date THEN 'completed' ELSE status END,
+			next_run_date = CASE WHEN COALESCE($3, end_date) < next_run_date THEN NULL ELSE next_run_date END,
+			updated_at = now()
+		WHERE id = $1 AND status = 'active' RETURNING *`
+
+	order := new(types.StandingOrder)
+	err := s.db.QueryRowx(query, id, data.Amount, data.EndDate).StructScan(order)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("standing_order_not_active")
+		}
+		return nil, err
+	}
+
+	return order, nil
+}
+
+/*
+CancelStandingOrder is a method to cancel an active standing order, it won't run anymore.
+*/
+func (s *StandingOrderStore) CancelStandingOrder(id uint) (*types.StandingOrder, error) {
+	query := `UPDATE standing_order SET status = 'cancelled', next_run_date = NULL, next_attempt_at = NULL, updated_at = now()
+		WHERE id = $1 AND status = 'active' RETURNING *`
+
+	order := new(types.StandingOrder)
+	err := s.db.QueryRowx(query, id).StructScan(order)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("standing_order_not_active")
+		}
+		return nil, err
+	}
+
+	return order, nil
+}
+
+/*
+GetDueStandingOrders is a method to get the active standing orders with a run due today or before,
+leaving out the failed runs waiting for their next attempt.
+*/
+func (s *StandingOrderStore) GetDueStandingOrders(today time.Time, now time.Time) ([]*types.StandingOrder, error) {
+	query := `SELECT * FROM standing_order
+		WHERE status = 'active' AND next_run_date <= $1 AND (next_attempt_at IS NULL OR next_attempt_at <= $2)
+		ORDER BY next_run_date, id`
+	orders := []*types.StandingOrder{}
+
+	err := s.db.Select(&orders, query, today, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+/*
+GetStandingOrderRuns is a method to get every run of a standing order, latest first.
+*/
+func (s *StandingOrderStore) GetStandingOrderRuns(id uint) ([]*types.StandingOrderRun, error) {
+	query := `SELECT * FROM standing_order_run WHERE standing_order_id = $1 ORDER BY id DESC`
+	runs := []*types.StandingOrderRun{}
+
+	err := s.db.Select(&runs, query, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+/*
+RecordStandingOrderRun is a method to log a run of a standing order and save the
+schedule of the order that follows from it, within a sql transaction.
+*/
+func (s *StandingOrderStore) RecordStandingOrderRun(run *types.StandingOrderRun, order *types.StandingOrder) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	// defer rollback if error
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			_ = tx.Commit()
+		}
+	}()
+
+	query := `INSERT INTO standing_order_run (standing_order_id, run_date, attempt, status, error, transaction_id) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err = tx.Exec(query, run.StandingOrderID, run.RunDate, run.Attempt, run.Status, run.Error, run.TransactionID)
+	if err != nil {
+		return err
+	}
+
+	query = `UPDATE standing_order SET next_run_date = $2, attempts = $3, next_attempt_at = $4, status = $5, updated_at = now() WHERE id = $1`
+	_, err = tx.Exec(query, order.ID, order.NextRunDate, order.Attempts, order.NextAttemptAt, order.Status)
+	return err
+}
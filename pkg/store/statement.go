@@ -0,0 +1,163 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/farischt/gobank/pkg/types"
+	"github.com/jmoiron/sqlx"
+)
+
+type StatementStore struct {
+	db *sqlx.DB
+}
+
+func NewStatement(db *sqlx.DB) *StatementStore {
+	return &StatementStore{db: db}
+}
+
+/*
+GetLedger is a method to get the balance of an account at the start of a period and the
+transactions of the account within the period, oldest first.
+Both are read from the same snapshot, so that a transaction made meanwhile can't make them disagree.
+*/
+func (s *StatementStore) GetLedger(accountId uint, since time.Time, until time.Time) (float64, []*types.LedgerEntry, error) {
+	tx, err := s.db.BeginTxx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// The current balance without what moved since the start of the period
+	query := `SELECT a.balance
+			- COALESCE((SELECT SUM(to_amount) FROM transaction WHERE to_id = a.id AND created_at >= $2), 0)
+			+ COALESCE((SELECT SUM(amount) FROM transaction WHERE from_id = a.id AND created_at >= $2), 0)
+		FROM account AS a WHERE a.id = $1`
+
+	var opening float64
+	err = tx.Get(&opening, query, accountId, since)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil, errors.New("account_not_found")
+		}
+		return 0, nil, err
+	}
+
+	query = `SELECT t.*, CASE WHEN t.from_id = $1 THEN r.iban ELSE s.iban END AS counterparty_iban
+		FROM transaction AS t
+		JOIN account AS s ON s.id = t.from_id
+		JOIN account AS r ON r.id = t.to_id
+		WHERE (t.from_id = $1 OR t.to_id = $1) AND t.created_at >= $2 AND t.created_at < $3
+		ORDER BY t.created_at, t.id`
+
+	entries := []*types.LedgerEntry{}
+	err = tx.Select(&entries, query, accountId, since, until)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return opening, entries, nil
+}
+
+/*
+CreateStatement is a method to store a statement.
+It returns statement_already_exist if the account already has a statement for the period.
+*/
+func (s *StatementStore) CreateStatement(statement *types.Statement) (*types.Statement, error) {
+	query := `INSERT INTO statement (account_id, period_start, period_end, document, hash, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING *`
+
+	created := new(types.Statement)
+	err := s.db.QueryRowx(
+		query,
+		statement.AccountID,
+		statement.PeriodStart,
+		statement.PeriodEnd,
+		statement.Document,
+		statement.Hash,
+		statement.CreatedAt,
+	).StructScan(created)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, errors.New("statement_already_exist")
+		}
+		return nil, err
+	}
+
+	return created, nil
+}
+
+/*
+GetStatement is a method to get a statement by id.
+*/
+func (s *StatementStore) GetStatement(id uint) (*types.Statement, error) {
+	query := `SELECT * FROM statement WHERE id = $1`
+
+	statement := new(types.Statement)
+	err := s.db.Get(statement, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("statement_not_found")
+		}
+		return nil, err
+	}
+
+	return statement, nil
+}
+
+/*
+GetStatementByPeriod is a method to get the statement of an account for a period.
+It returns nil if there is none.
+*/
+func (s *StatementStore) GetStatementByPeriod(accountId uint, start time.Time, end time.Time) (*types.Statement, error) {
+	query := `SELECT * FROM statement WHERE account_id = $1 AND period_start = $2 AND period_end = $3`
+
+	statement := new(types.Statement)
+	err := s.db.Get(statement, query, accountId, start, end)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return statement, nil
+}
+
+/*
+GetStatementsByAccount is a method to get the statements of an account, most recent period first.
+*/
+func (s *StatementStore) GetStatementsByAccount(accountId uint) ([]*types.Statement, error) {
+	query := `SELECT * FROM statement WHERE account_id = $1 ORDER BY period_end DESC, period_start DESC`
+	statements := []*types.Statement{}
+
+	err := s.db.Select(&statements, query, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	return statements, nil
+}
+
+/*
+GetAccountsWithoutStatement is a method to get the ids of the customer accounts opened before the
+end of a period that have no statement for it.
+*/
+func (s *StatementStore) GetAccountsWithoutStatement(start time.Time, end time.Time) ([]uint, error) {
+	query := `SELECT a.id FROM account AS a
+		WHERE a.system_code IS NULL AND a.created_at < $2::DATE + 1
+			AND NOT EXISTS (SELECT 1 FROM statement AS s WHERE s.account_id = a.id AND s.period_start = $1 AND s.period_end = $2)
+		ORDER BY a.id`
+	ids := []uint{}
+
+	err := s.db.Select(&ids, query, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
@@ -1,33 +1,213 @@
 package store
 
 import (
+	"time"
+
 	"github.com/farischt/gobank/pkg/types"
 
 	"github.com/farischt/gobank/pkg/dto"
 )
 
 type UserStorer interface {
-	CreateUser(input *dto.CreateUserDTO) error
+	CreateUser(input *dto.CreateUserDTO) (*types.User, error)
 	GetUserByEmail(email string) (*types.User, error)
 	GetUserByID(id uint) (*types.User, error)
+	UpdateUser(id uint, input *dto.UpdateUserDTO) (*types.User, error)
+	SetUserPassword(id uint, password string) error
+	AdoptAccountPassword(id uint, password string) (bool, error)
+	AnonymizeUser(id uint) error
+	CreateEmailVerification(userId uint, email string, tokenHash string, expiresAt time.Time) error
+	VerifyEmail(tokenHash string) error
 }
 
 type AccountStorer interface {
 	GetAccount(id uint) (*types.Account, error)
+	GetAccountByIBAN(iban string) (*types.Account, error)
 	GetAllAccount() ([]*types.Account, error)
+	GetAccountsByUser(userId uint) ([]*types.Account, error)
+	GetAccountsByHolder(userId uint) ([]*types.Account, error)
 	GetAccountWithUser(id uint) (*types.Account, error)
-	CreateAccount(account *dto.CreateAccountDTO) error
+	CreateAccount(data *dto.CreateAccountDTO) (*types.Account, error)
+	GetAccountsWithoutIBAN() ([]uint, error)
+	SetAccountIBAN(id uint, iban string) error
 	DeleteAccount(id uint) error
+	ChangeAccountStatus(id uint, to types.AccountStatus, reason string, actorUserId *uint) (*types.AccountStatusChange, error)
+	GetAccountStatusHistory(id uint) ([]*types.AccountStatusChange, error)
+	MarkDormantAccounts(inactiveSince time.Time) (int64, error)
+	TouchAccountActivity(id uint) error
+	SetOverdraftLimit(id uint, limit float64) (*types.Account, error)
+	SetTransferLimits(id uint, limits *dto.SetTransferLimitsDTO) (*types.Account, error)
 }
 
 type TransactionStorer interface {
-	CreateTxn(from uint, data *dto.CreateTransactionDTO) error
-	CreateTxnAndUpdateBalance(from *types.Account, to *types.Account, fromFinalBalance float64, toFinalBalance float64, data *dto.CreateTransactionDTO) error
+	GetTxnsByAccount(accountId uint) ([]*types.Transaction, error)
+	SearchTxnsByAccount(accountId uint, filter *dto.SearchTransactionsDTO, searchMemo bool) ([]*types.Transaction, error)
+	GetOutgoingTotalsSince(accountId uint, since time.Time) (*types.OutgoingTotals, error)
+	GetSentByUserToAccountSince(userId uint, accountId uint, since time.Time) (float64, error)
+	RunInTx(fn func(tx TransferTx) error) error
+}
+
+/*
+TransferTx is the set of operations available to make transfers within a sql transaction.
+*/
+type TransferTx interface {
+	LockAccounts(ids ...uint) (map[uint]*types.Account, error)
+	GetOutgoingTotalsSince(accountId uint, since time.Time) (*types.OutgoingTotals, error)
+	GetSystemAccount(code string) (*types.Account, error)
+	GetTxnByIdempotencyKey(from uint, key string) (*types.Transaction, error)
+	GetFxRate(base string, quote string) (*types.FxRate, error)
+	LockTxn(id uint) (*types.Transaction, error)
+	AddReversedAmount(id uint, amount float64) (*types.Transaction, error)
+	RaiseOverdraftLimit(accountId uint, limit float64) error
+	CreateTxn(entry *types.TransactionEntry) (*types.Transaction, error)
+	Debit(accountId uint, amount float64) error
+	Credit(accountId uint, amount float64) error
+}
+
+type InterestStorer interface {
+	GetInterestBearingAccounts(accountType types.AccountType, businessDate time.Time) ([]uint, error)
+	GetLastBusinessDate() (*time.Time, error)
+	RunInTx(fn func(tx InterestTx) error) error
+}
+
+/*
+InterestTx is the set of operations available to accrue and capitalize interest within a sql transaction.
+*/
+type InterestTx interface {
+	TransferTx
+	GetBalanceAt(accountId uint, at time.Time) (float64, error)
+	CreateAccrual(a *types.InterestAccrual) (bool, error)
+	CreateCapitalization(c *types.InterestCapitalization) (bool, error)
+}
+
+type FxStorer interface {
+	CreateFxRate(data *dto.SetFxRateDTO, source string) (*types.FxRate, error)
+	GetFxRate(base string, quote string) (*types.FxRate, error)
+	GetLatestFxRates() ([]*types.FxRate, error)
+}
+
+type HoldStorer interface {
+	GetHold(id uint) (*types.Hold, error)
+	GetHoldsByAccount(accountId uint) ([]*types.Hold, error)
+	ExpireHolds(now time.Time) (int64, error)
+	RunInTx(fn func(tx HoldTx) error) error
+}
+
+/*
+HoldTx is the set of operations available to place, capture and release holds within a sql transaction.
+*/
+type HoldTx interface {
+	TransferTx
+	CreateHold(accountId uint, to uint, amount float64, expiresAt time.Time) (*types.Hold, error)
+	LockHold(id uint) (*types.Hold, error)
+	CloseHold(id uint, status types.HoldStatus, capturedAmount *float64) (*types.Hold, error)
+	SetHoldTransaction(id uint, transactionId uint) (*types.Hold, error)
+}
+
+type StandingOrderStorer interface {
+	CreateStandingOrder(accountId uint, toId uint, data *dto.CreateStandingOrderDTO, nextRunDate time.Time) (*types.StandingOrder, error)
+	GetStandingOrder(id uint) (*types.StandingOrder, error)
+	GetStandingOrdersByAccount(accountId uint) ([]*types.StandingOrder, error)
+	UpdateStandingOrder(id uint, data *dto.UpdateStandingOrderDTO) (*types.StandingOrder, error)
+	CancelStandingOrder(id uint) (*types.StandingOrder, error)
+	GetDueStandingOrders(today time.Time, now time.Time) ([]*types.StandingOrder, error)
+	GetStandingOrderRuns(id uint) ([]*types.StandingOrderRun, error)
+	RecordStandingOrderRun(run *types.StandingOrderRun, order *types.StandingOrder) error
 }
 
 type SessionTokenStorer interface {
-	CreateSessionToken(accountId uint) (*types.SessionToken, error)
+	CreateSessionToken(userId uint, accountId *uint) (*types.SessionToken, error)
 	GetSessionToken(token string) (*types.SessionToken, error)
 	DeleteSessionToken(token string) error
 	IsValidSessionToken(token string) (uint, bool)
 }
+
+type RoleStorer interface {
+	GetUserRoles(userId uint) ([]*types.UserRole, error)
+	GrantRole(userId uint, role types.Role, grantedBy uint) error
+	RevokeRole(userId uint, role types.Role) (bool, error)
+}
+
+type AuditStorer interface {
+	CreateAuditEntry(entry *types.AuditEntry) error
+	GetAuditEntries(offset uint, limit uint) ([]*types.AuditEntry, error)
+	GetAuditEntriesByActor(userId uint) ([]*types.AuditEntry, error)
+}
+
+type ApiKeyStorer interface {
+	CreateApiKey(key *types.ApiKey) (*types.ApiKey, error)
+	GetApiKeyByPrefix(prefix string) (*types.ApiKey, error)
+	GetApiKeysByUser(userId uint) ([]*types.ApiKey, error)
+	RevokeApiKey(id uint, userId uint) (bool, error)
+	TouchApiKey(id uint, ip string) error
+}
+
+type BeneficiaryStorer interface {
+	CreateBeneficiary(b *types.Beneficiary) (*types.Beneficiary, error)
+	GetBeneficiary(id uint) (*types.Beneficiary, error)
+	GetBeneficiariesByUser(userId uint) ([]*types.Beneficiary, error)
+	UpdateBeneficiary(id uint, data *dto.UpdateBeneficiaryDTO) (*types.Beneficiary, error)
+	ConfirmBeneficiary(id uint) (*types.Beneficiary, error)
+	DeleteBeneficiary(id uint) error
+}
+
+type StatementStorer interface {
+	GetLedger(accountId uint, since time.Time, until time.Time) (float64, []*types.LedgerEntry, error)
+	CreateStatement(statement *types.Statement) (*types.Statement, error)
+	GetStatement(id uint) (*types.Statement, error)
+	GetStatementByPeriod(accountId uint, start time.Time, end time.Time) (*types.Statement, error)
+	GetStatementsByAccount(accountId uint) ([]*types.Statement, error)
+	GetAccountsWithoutStatement(start time.Time, end time.Time) ([]uint, error)
+}
+
+type BatchStorer interface {
+	CreateBatch(b *types.BatchEntry) (*types.Batch, error)
+	GetBatch(id uint) (*types.Batch, error)
+	GetBatchesByAccount(accountId uint) ([]*types.Batch, error)
+	GetBatchLines(batchId uint) ([]*types.BatchLine, error)
+	SetBatchStatus(id uint, from types.BatchStatus, to types.BatchStatus) (*types.Batch, error)
+	SetBatchLineResult(id uint, status types.BatchLineStatus, transactionId *uint, reason *string) error
+	ClaimStaleBatches(before time.Time) ([]*types.Batch, error)
+	RunInTx(fn func(tx BatchTx) error) error
+}
+
+/*
+BatchTx is the set of operations available to execute the lines of a batch within a sql transaction.
+*/
+type BatchTx interface {
+	TransferTx
+	LockBatch(id uint) (*types.Batch, error)
+	SetBatchStatus(id uint, from types.BatchStatus, to types.BatchStatus) (*types.Batch, error)
+	SetBatchLineResult(id uint, status types.BatchLineStatus, transactionId *uint, reason *string) error
+}
+
+type PaymentRequestStorer interface {
+	CreatePaymentRequest(requesterId uint, payerId uint, data *dto.CreatePaymentRequestDTO, expiresAt time.Time) (*types.PaymentRequest, error)
+	GetPaymentRequest(id uint) (*types.PaymentRequest, error)
+	GetPaymentRequestByIdempotencyKey(requesterId uint, key string) (*types.PaymentRequest, error)
+	GetPaymentRequestsByRequester(accountId uint) ([]*types.PaymentRequest, error)
+	GetPaymentRequestsByAccount(accountId uint) ([]*types.PaymentRequest, error)
+	GetPendingPaymentRequestsByPayer(accountId uint, now time.Time) ([]*types.PaymentRequest, error)
+	ClosePaymentRequest(id uint, status types.PaymentRequestStatus) (*types.PaymentRequest, error)
+	ExpirePaymentRequests(now time.Time) (int64, error)
+	RunInTx(fn func(tx PaymentRequestTx) error) error
+}
+
+/*
+PaymentRequestTx is the set of operations available to pay a payment request within a sql transaction.
+*/
+type PaymentRequestTx interface {
+	TransferTx
+	LockPaymentRequest(id uint) (*types.PaymentRequest, error)
+	AcceptPaymentRequest(id uint, transactionId uint) error
+}
+
+type AccountHolderStorer interface {
+	GetAccountHolders(accountId uint) ([]*types.AccountHolder, error)
+	GetAccountHolder(accountId uint, userId uint) (*types.AccountHolder, error)
+	CreateHolderChange(accountId uint, userId uint, action types.HolderChangeAction, role *types.HolderRole, requestedBy uint) (*types.AccountHolderChange, error)
+	GetHolderChange(id uint) (*types.AccountHolderChange, error)
+	GetHolderChangesByAccount(accountId uint) ([]*types.AccountHolderChange, error)
+	ApproveHolderChange(id uint, decidedBy uint) (*types.AccountHolderChange, error)
+	RejectHolderChange(id uint, decidedBy uint) (*types.AccountHolderChange, error)
+}
@@ -1,11 +1,15 @@
 package store
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/farischt/gobank/pkg/dto"
 	"github.com/farischt/gobank/pkg/types"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type TransactionStore struct {
@@ -17,27 +21,100 @@ func NewTransaction(db *sqlx.DB) *TransactionStore {
 }
 
 /*
-CreateTxn creates a new transaction.
-It returns an error if any.
+GetTxnsByAccount returns every transaction sent or received by the given account, oldest first.
 */
-func (s *TransactionStore) CreateTxn(from uint, data *dto.CreateTransactionDTO) error {
-	query := `INSERT INTO transaction (from_id, to_id, amount) VALUES ($1, $2, $3)`
-	_, err := s.db.Exec(
+func (s *TransactionStore) GetTxnsByAccount(accountId uint) ([]*types.Transaction, error) {
+	query := `SELECT * FROM transaction WHERE from_id = $1 OR to_id = $1 ORDER BY created_at, id`
+	txns := []*types.Transaction{}
+
+	err := s.db.Select(&txns, query, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	return txns, nil
+}
+
+/*
+SearchTxnsByAccount returns the transactions sent or received by the given account matching the filter, newest first.
+The query matches the references of the transactions, and if searchMemo is set, the memo of the ones sent by the account.
+*/
+func (s *TransactionStore) SearchTxnsByAccount(accountId uint, filter *dto.SearchTransactionsDTO, searchMemo bool) ([]*types.Transaction, error) {
+	query := `SELECT * FROM transaction
+		WHERE (from_id = $1 OR to_id = $1)
+			AND ($2 = '' OR reference ILIKE $3 OR creditor_reference ILIKE $3 OR ($9 AND from_id = $1 AND memo ILIKE $3))
+			AND ($4 = '' OR creditor_reference = $4)
+			AND ($5::TIMESTAMP IS NULL OR created_at >= $5)
+			AND ($6::TIMESTAMP IS NULL OR created_at < $6)
+		ORDER BY created_at DESC, id DESC
+		OFFSET $7 LIMIT $8`
+	txns := []*types.Transaction{}
+
+	err := s.db.Select(
+		&txns,
 		query,
-		from,
-		data.To,
-		data.Amount,
+		accountId,
+		filter.Query,
+		"%"+likeEscaper.Replace(filter.Query)+"%",
+		filter.CreditorReference,
+		filter.Since,
+		filter.Until,
+		filter.Offset,
+		filter.Limit,
+		searchMemo,
 	)
-	return err
+	if err != nil {
+		return nil, err
+	}
+
+	return txns, nil
+}
+
+/*
+GetOutgoingTotalsSince returns the total amount and number of transfers sent by the given account since the given time.
+*/
+func (s *TransactionStore) GetOutgoingTotalsSince(accountId uint, since time.Time) (*types.OutgoingTotals, error) {
+	return getOutgoingTotalsSince(s.db, accountId, since)
+}
+
+/*
+GetSentByUserToAccountSince returns the total amount of the transfers sent to the given account,
+since the given time, from the accounts the given user holds.
+*/
+func (s *TransactionStore) GetSentByUserToAccountSince(userId uint, accountId uint, since time.Time) (float64, error) {
+	query := `SELECT COALESCE(SUM(amount), 0) FROM transaction
+		WHERE to_id = $2 AND type = 'transfer' AND created_at >= $3
+			AND from_id IN (SELECT account_id FROM account_holder WHERE user_id = $1)`
+
+	var total float64
+	err := s.db.Get(&total, query, userId, accountId, since)
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func getOutgoingTotalsSince(q sqlx.Queryer, accountId uint, since time.Time) (*types.OutgoingTotals, error) {
+	query := `SELECT COALESCE(SUM(amount), 0) AS amount, count(*) AS count FROM transaction WHERE from_id = $1 AND type = 'transfer' AND created_at >= $2`
+
+	totals := new(types.OutgoingTotals)
+	err := sqlx.Get(q, totals, query, accountId, since)
+	if err != nil {
+		return nil, err
+	}
+
+	return totals, nil
 }
 
 /*
-CreateTxnAndUpdateBalance creates a new transaction and updates the balance of the from and to account within a sql transaction.
-It returns an error if any
+RunInTx runs the given function within a sql transaction, giving it access to the
+operations needed to make transfers. The sql transaction is committed if the function
+succeeds and rolled back otherwise.
 */
-func (s *TransactionStore) CreateTxnAndUpdateBalance(from *types.Account, to *types.Account, fromFinalBalance float64, toFinalBalance float64, data *dto.CreateTransactionDTO) error {
+func (s *TransactionStore) RunInTx(fn func(tx TransferTx) error) error {
 	// Start transaction
-	tx, err := s.db.Begin()
+	tx, err := s.db.Beginx()
 	if err != nil {
 		return err
 	}
@@ -46,41 +123,213 @@ func (s *TransactionStore) CreateTxnAndUpdateBalance(from *types.Account, to *ty
 	defer func() {
 		if err != nil {
 			_ = tx.Rollback()
-		} else {
-			_ = tx.Commit()
 		}
 	}()
 
-	createTxnQuery := `INSERT INTO transaction (from_id, to_id, amount) VALUES ($1, $2, $3)`
-	_, err = tx.Exec(
-		createTxnQuery,
-		from.ID,
-		to.ID,
-		data.Amount,
-	)
+	err = fn(&transferTx{tx: tx})
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	return err
+}
+
+/*
+transferTx implements TransferTx on top of a sql transaction.
+*/
+type transferTx struct {
+	tx *sqlx.Tx
+}
+
+/*
+LockAccounts locks the given accounts until the end of the sql transaction and returns them by id.
+The rows are locked in id order so that concurrent transfers can't deadlock.
+*/
+func (t *transferTx) LockAccounts(ids ...uint) (map[uint]*types.Account, error) {
+	query := `SELECT * FROM account WHERE id = ANY($1) ORDER BY id FOR UPDATE`
+
+	keys := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, int64(id))
+	}
 
+	accounts := []*types.Account{}
+	err := t.tx.Select(&accounts, query, pq.Array(keys))
 	if err != nil {
-		return fmt.Errorf("error creating transaction")
+		return nil, err
 	}
 
-	// Update balance
-	updateFromBalanceQuery := `UPDATE account SET balance = $1 WHERE id = $2`
-	_, err = tx.Exec(
-		updateFromBalanceQuery,
-		fromFinalBalance,
-		from.ID,
-	)
+	byId := make(map[uint]*types.Account, len(accounts))
+	for _, a := range accounts {
+		byId[a.ID] = a
+	}
+
+	for _, id := range ids {
+		if _, ok := byId[id]; !ok {
+			return nil, errors.New("account_not_found")
+		}
+	}
+
+	return byId, nil
+}
+
+/*
+GetOutgoingTotalsSince returns the total amount and number of transfers sent by the given account since the given time.
+*/
+func (t *transferTx) GetOutgoingTotalsSince(accountId uint, since time.Time) (*types.OutgoingTotals, error) {
+	return getOutgoingTotalsSince(t.tx, accountId, since)
+}
+
+/*
+GetSystemAccount returns the system account with the given code.
+*/
+func (t *transferTx) GetSystemAccount(code string) (*types.Account, error) {
+	query := `SELECT * FROM account WHERE system_code = $1`
 
+	account := new(types.Account)
+	err := t.tx.Get(account, query, code)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("system_account_not_found")
+		}
+		return nil, err
+	}
+
+	return account, nil
+}
+
+/*
+GetFxRate returns the latest rate of a currency pair, nil if none.
+*/
+func (t *transferTx) GetFxRate(base string, quote string) (*types.FxRate, error) {
+	return getFxRate(t.tx, base, quote)
+}
+
+/*
+LockTxn locks the given transaction until the end of the sql transaction and returns it.
+*/
+func (t *transferTx) LockTxn(id uint) (*types.Transaction, error) {
+	query := `SELECT * FROM transaction WHERE id = $1 FOR UPDATE`
+
+	txn := new(types.Transaction)
+	err := t.tx.Get(txn, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("transaction_not_found")
+		}
+		return nil, err
+	}
+
+	return txn, nil
+}
+
+/*
+AddReversedAmount adds the amount to what was reversed of a transaction and updates its status.
+*/
+func (t *transferTx) AddReversedAmount(id uint, amount float64) (*types.Transaction, error) {
+	query := `UPDATE transaction SET
+			reversed_amount = reversed_amount + $2,
+			status = CASE WHEN reversed_amount + $2 >= amount THEN 'reversed' ELSE 'partially_reversed' END,
+			updated_at = now()
+		WHERE id = $1 RETURNING *`
+
+	txn := new(types.Transaction)
+	err := t.tx.QueryRowx(query, id, amount).StructScan(txn)
+	if err != nil {
+		if isCheckViolation(err) {
+			return nil, errors.New("reversal_exceeds_original")
+		}
+		return nil, err
+	}
+
+	return txn, nil
+}
+
+/*
+RaiseOverdraftLimit raises the overdraft limit of an account to at least the given limit.
+*/
+func (t *transferTx) RaiseOverdraftLimit(accountId uint, limit float64) error {
+	query := `UPDATE account SET overdraft_limit = GREATEST(overdraft_limit, $2), updated_at = now() WHERE id = $1`
+	_, err := t.tx.Exec(query, accountId, limit)
+	return err
+}
+
+/*
+GetTxnByIdempotencyKey returns the transaction sent by the account with the given idempotency key, nil if none.
+*/
+func (t *transferTx) GetTxnByIdempotencyKey(from uint, key string) (*types.Transaction, error) {
+	query := `SELECT * FROM transaction WHERE from_id = $1 AND idempotency_key = $2`
+
+	txn := new(types.Transaction)
+	err := t.tx.Get(txn, query, from, key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return txn, nil
+}
+
+/*
+CreateTxn records a transaction between two accounts.
+*/
+func (t *transferTx) CreateTxn(entry *types.TransactionEntry) (*types.Transaction, error) {
+	query := `INSERT INTO transaction (type, from_id, to_id, amount, currency, to_amount, to_currency, fx_rate, parent_id, reversal_of, idempotency_key,
+			reference, creditor_reference, memo)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING *`
+
+	txn := new(types.Transaction)
+	err := t.tx.QueryRowx(
+		query,
+		entry.Type,
+		entry.From,
+		entry.To,
+		entry.Amount,
+		entry.Currency,
+		entry.ToAmount,
+		entry.ToCurrency,
+		entry.FxRate,
+		entry.ParentID,
+		entry.ReversalOf,
+		entry.IdempotencyKey,
+		entry.Reference,
+		entry.CreditorReference,
+		entry.Memo,
+	).StructScan(txn)
+	if err != nil {
+		return nil, fmt.Errorf("error creating transaction")
+	}
+
+	return txn, nil
+}
+
+/*
+Debit removes the amount from the balance of an account and records the activity of its owner.
+It fails with insufficient_balance if the balance would go below the account floor.
+*/
+func (t *transferTx) Debit(accountId uint, amount float64) error {
+	query := `UPDATE account SET balance = balance - $2, last_activity_at = now(), updated_at = now() WHERE id = $1`
+	_, err := t.tx.Exec(query, accountId, amount)
+
+	if err != nil {
+		if isCheckViolation(err) {
+			return errors.New("insufficient_balance")
+		}
 		return fmt.Errorf("error updating from account balance")
 	}
 
-	updateToBalanceQuery := `UPDATE account SET balance = $1 WHERE id = $2`
-	_, err = tx.Exec(
-		updateToBalanceQuery,
-		toFinalBalance,
-		to.ID,
-	)
+	return nil
+}
+
+/*
+Credit adds the amount to the balance of an account.
+*/
+func (t *transferTx) Credit(accountId uint, amount float64) error {
+	query := `UPDATE account SET balance = balance + $2, updated_at = now() WHERE id = $1`
+	_, err := t.tx.Exec(query, accountId, amount)
 
 	if err != nil {
 		return fmt.Errorf("error updating to account balance")
@@ -3,6 +3,8 @@ package store
 import (
 	"database/sql"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/farischt/gobank/pkg/dto"
 	"github.com/farischt/gobank/pkg/types"
@@ -18,19 +20,34 @@ func NewUser(db *sqlx.DB) *UserStore {
 }
 
 /*
-CreateUser is a method to create a user.
-It takes a CreateUserDTO and returns an error.
+CreateUser is a method to create a user with the customer role.
+It takes a CreateUserDTO and returns the created User and an error.
 */
-func (s *UserStore) CreateUser(input *dto.CreateUserDTO) error {
-	query := `INSERT INTO "user" (first_name, last_name, email) VALUES ($1, $2, $3)`
-	_, err := s.db.Exec(
+func (s *UserStore) CreateUser(input *dto.CreateUserDTO) (*types.User, error) {
+	query := `WITH u AS (INSERT INTO "user" (first_name, last_name, email, password) VALUES ($1, $2, $3, $4) RETURNING *),
+		r AS (INSERT INTO user_role (user_id, role) SELECT id, 'customer' FROM u)
+		SELECT * FROM u`
+
+	// Users created without a password log in with the password of their accounts
+	var password *string
+	if input.Password != "" {
+		password = &input.Password
+	}
+
+	user := new(types.User)
+	err := s.db.QueryRowx(
 		query,
 		input.FirstName,
 		input.LastName,
 		input.Email,
-	)
+		password,
+	).StructScan(user)
 
-	return err
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
 }
 
 /*
@@ -38,7 +55,7 @@ GetUserByEmail is a method to get a user by email.
 It takes an email and returns a User and an error.
 */
 func (s *UserStore) GetUserByEmail(email string) (*types.User, error) {
-	query := `SELECT * FROM "user" WHERE email = $1`
+	query := `SELECT * FROM "user" WHERE lower(email) = lower($1)`
 
 	user := new(types.User)
 	err := s.db.QueryRowx(query, email).StructScan(user)
@@ -75,3 +92,260 @@ func (s *UserStore) GetUserByID(id uint) (*types.User, error) {
 
 	return user, nil
 }
+
+/*
+UpdateUser is a method to update the profile of a user.
+Only the fields set in the UpdateUserDTO are updated. Changing the email
+resets the verification of the user.
+*/
+func (s *UserStore) UpdateUser(id uint, input *dto.UpdateUserDTO) (*types.User, error) {
+	query := `UPDATE "user" SET
+		first_name = COALESCE($2, first_name),
+		last_name = COALESCE($3, last_name),
+		email = COALESCE($4, email),
+		verified_at = CASE WHEN $4::VARCHAR IS NULL OR $4 = email THEN verified_at ELSE NULL END,
+		updated_at = now()
+		WHERE id = $1 RETURNING *`
+
+	user := new(types.User)
+	err := s.db.QueryRowx(query, id, input.FirstName, input.LastName, input.Email).StructScan(user)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("user_not_found")
+		}
+
+		return nil, err
+	}
+
+	return user, nil
+}
+
+/*
+SetUserPassword is a method to set the hashed password a user logs in with.
+Within a sql transaction, it also disables the passwords of the accounts of the user, so that
+an older password can't be used through the legacy login, and revokes every session of the user.
+*/
+func (s *UserStore) SetUserPassword(id uint, password string) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	// defer rollback if error
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			_ = tx.Commit()
+		}
+	}()
+
+	res, err := tx.Exec(`UPDATE "user" SET password = $2, updated_at = now() WHERE id = $1 AND deleted_at IS NULL`, id, password)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	} else if n == 0 {
+		err = errors.New("user_not_found")
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE account SET password = '', updated_at = now() WHERE user_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM session_token WHERE user_id = $1`, id)
+	return err
+}
+
+/*
+AdoptAccountPassword is a method to give a user without a password the hashed password of one of its accounts.
+It reports whether the user was given it, a user already having a password keeps it.
+*/
+func (s *UserStore) AdoptAccountPassword(id uint, password string) (bool, error) {
+	query := `UPDATE "user" SET password = $2, updated_at = now() WHERE id = $1 AND password IS NULL AND deleted_at IS NULL`
+
+	res, err := s.db.Exec(query, id, password)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+/*
+AnonymizeUser is a method to delete a user without losing the history of its accounts.
+Within a sql transaction, it checks that every account of the user has a zero balance, no accrued
+interest left to pay, no active hold, no active standing order and no other holder,
+replaces the personal data of the user, disables its credentials and the ones of its accounts,
+closes its accounts, revokes every session and API key, deletes the beneficiaries of the user
+and the ones paying its accounts, rejects the holder changes awaiting consent that involve it, and
+removes the user from the accounts it holds without being their primary holder.
+*/
+func (s *UserStore) AnonymizeUser(id uint) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	// defer rollback if error
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			_ = tx.Commit()
+		}
+	}()
+
+	// Lock the accounts so that no transfer can credit them meanwhile
+	var accounts []struct {
+		Balance         float64 `db:"balance"`
+		AccruedInterest float64 `db:"accrued_interest"`
+	}
+	err = tx.Select(&accounts, `SELECT balance, accrued_interest FROM account WHERE user_id = $1 FOR UPDATE`, id)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range accounts {
+		if a.Balance != 0 {
+			err = errors.New("non_zero_balance")
+			return err
+		} else if a.AccruedInterest != 0 {
+			err = errors.New("accrued_interest_outstanding")
+			return err
+		}
+	}
+
+	var outstanding struct {
+		Holds          int `db:"holds"`
+		StandingOrders int `db:"standing_orders"`
+		OtherHolders   int `db:"other_holders"`
+	}
+	err = tx.Get(&outstanding, `SELECT
+		(SELECT count(*) FROM hold WHERE status = 'active' AND account_id IN (SELECT id FROM account WHERE user_id = $1)) AS holds,
+		(SELECT count(*) FROM standing_order WHERE status = 'active' AND account_id IN (SELECT id FROM account WHERE user_id = $1)) AS standing_orders,
+		(SELECT count(*) FROM account_holder WHERE user_id <> $1 AND account_id IN (SELECT id FROM account WHERE user_id = $1)) AS other_holders`, id)
+	if err != nil {
+		return err
+	} else if outstanding.Holds > 0 {
+		err = errors.New("active_holds")
+		return err
+	} else if outstanding.StandingOrders > 0 {
+		err = errors.New("active_standing_orders")
+		return err
+	} else if outstanding.OtherHolders > 0 {
+		// Closing the account would take it away from the other holders
+		err = errors.New("primary_holder_of_joint_account")
+		return err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE "user" SET first_name = 'Deleted', last_name = 'User', email = $2, password = NULL, deleted_at = now(), updated_at = now() WHERE id = $1`,
+		id,
+		fmt.Sprintf("deleted-%d@deleted.invalid", id),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE account SET password = '', updated_at = now() WHERE user_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	// Close the accounts so that nothing can be credited to them anymore, recording the transitions
+	_, err = tx.Exec(`WITH c AS (
+			UPDATE account AS a SET status = 'closed', updated_at = now()
+			FROM (SELECT id, status FROM account WHERE user_id = $1 AND status <> 'closed') AS o
+			WHERE a.id = o.id RETURNING a.id, o.status
+		) INSERT INTO account_status_history (account_id, from_status, to_status, reason, actor_user_id)
+		SELECT id, status, 'closed', 'user_deleted', $1 FROM c`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM session_token WHERE user_id = $1 OR account_id IN (SELECT id FROM account WHERE user_id = $1)`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE api_key SET revoked_at = now(), updated_at = now() WHERE user_id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+
+	// The beneficiaries of others paying the closed accounts hold the name of the user
+	_, err = tx.Exec(`DELETE FROM beneficiary WHERE user_id = $1 OR account_id IN (SELECT id FROM account WHERE user_id = $1)`, id)
+	if err != nil {
+		return err
+	}
+
+	// The changes awaiting consent can no longer be decided by or applied to the user
+	_, err = tx.Exec(`UPDATE account_holder_change SET status = 'rejected', decided_at = now(), updated_at = now()
+		WHERE status = 'pending' AND (user_id = $1 OR requested_by = $1 OR account_id IN (SELECT id FROM account WHERE user_id = $1))`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM account_holder WHERE user_id = $1 AND role <> 'primary'`, id)
+	return err
+}
+
+/*
+CreateEmailVerification is a method to store the hashed token sent to verify the email of a user.
+*/
+func (s *UserStore) CreateEmailVerification(userId uint, email string, tokenHash string, expiresAt time.Time) error {
+	query := `INSERT INTO email_verification (user_id, email, token_hash, expires_at) VALUES ($1, $2, $3, $4)`
+	_, err := s.db.Exec(query, userId, email, tokenHash, expiresAt)
+	return err
+}
+
+/*
+VerifyEmail is a method to consume a verification token and mark the user as verified.
+The token must not be expired nor consumed, and must have been issued for the
+current email of the user.
+*/
+func (s *UserStore) VerifyEmail(tokenHash string) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	// defer rollback if error
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			_ = tx.Commit()
+		}
+	}()
+
+	var userId uint
+	query := `UPDATE email_verification AS v SET consumed_at = now()
+		FROM "user" AS u
+		WHERE v.token_hash = $1 AND v.consumed_at IS NULL AND v.expires_at > now()
+		AND u.id = v.user_id AND u.email = v.email AND u.deleted_at IS NULL
+		RETURNING v.user_id`
+
+	err = tx.Get(&userId, query, tokenHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = errors.New("invalid_verification_token")
+		}
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE "user" SET verified_at = now(), updated_at = now() WHERE id = $1`, userId)
+	return err
+}
@@ -1,9 +1,12 @@
 package store
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/farischt/gobank/config"
+	"github.com/lib/pq"
 )
 
 /*
@@ -20,3 +23,22 @@ func getPgConnectionStr() string {
 
 	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=disable", host, user, password, name, port)
 }
+
+/*
+isCheckViolation reports whether the error is a postgres check constraint violation.
+*/
+func isCheckViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23514"
+}
+
+/*
+isUniqueViolation reports whether the error is a postgres unique constraint violation.
+*/
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// Escapes the wildcards of a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
@@ -1,28 +1,143 @@
 package types
 
 import (
+	"math"
 	"time"
 
 	"github.com/farischt/gobank/utils"
 )
 
+type AccountStatus string
+
+const (
+	AccountActive  AccountStatus = "active"
+	AccountFrozen  AccountStatus = "frozen"
+	AccountDormant AccountStatus = "dormant"
+	AccountClosed  AccountStatus = "closed"
+)
+
+/*
+accountTransitions lists the statuses an account can move to from each status.
+A closed account is final.
+*/
+var accountTransitions = map[AccountStatus][]AccountStatus{
+	AccountActive:  {AccountFrozen, AccountDormant, AccountClosed},
+	AccountFrozen:  {AccountActive, AccountClosed},
+	AccountDormant: {AccountActive, AccountFrozen, AccountClosed},
+	AccountClosed:  {},
+}
+
+/*
+IsValid reports whether the status is a known account status.
+*/
+func (s AccountStatus) IsValid() bool {
+	_, ok := accountTransitions[s]
+	return ok
+}
+
+/*
+CanTransitionTo reports whether an account can move from this status to the given one.
+*/
+func (s AccountStatus) CanTransitionTo(to AccountStatus) bool {
+	for _, t := range accountTransitions[s] {
+		if t == to {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+CanSend reports whether an account in this status can send money.
+*/
+func (s AccountStatus) CanSend() bool {
+	return s == AccountActive
+}
+
+/*
+CanReceive reports whether an account in this status can receive money.
+Frozen and dormant accounts can still be credited.
+*/
+func (s AccountStatus) CanReceive() bool {
+	return s != AccountClosed
+}
+
+/*
+CanLogin reports whether the owner of an account in this status can log in.
+*/
+func (s AccountStatus) CanLogin() bool {
+	return s != AccountClosed
+}
+
+type AccountType string
+
+const (
+	AccountChecking    AccountType = "checking"
+	AccountSavings     AccountType = "savings"
+	AccountTermDeposit AccountType = "term_deposit"
+)
+
+/*
+IsValid reports whether the type is a known account type.
+*/
+func (t AccountType) IsValid() bool {
+	switch t {
+	case AccountChecking, AccountSavings, AccountTermDeposit:
+		return true
+	default:
+		return false
+	}
+}
+
 type Account struct {
-	ID        uint      `db:"id"`
-	UserID    uint      `db:"user_id"`
-	Password  string    `db:"password"`
-	Balance   []uint8   `db:"balance"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
-	User      *User
+	ID     uint `db:"id"`
+	UserID uint `db:"user_id"`
+	// Account number given out to customers, unset until assigned
+	IBAN           *string `db:"iban"`
+	Password       string  `db:"password"`
+	Balance        []uint8 `db:"balance"`
+	OverdraftLimit []uint8 `db:"overdraft_limit"`
+	// Reserved by the active holds, not available to spend
+	HeldAmount []uint8 `db:"held_amount"`
+	// Interest accrued but not capitalized yet
+	AccruedInterest []uint8 `db:"accrued_interest"`
+	// Set on the bank's own accounts only
+	SystemCode *string `db:"system_code"`
+	// Transfer limits overrides, unset when empty
+	MaxTransferAmount   []uint8       `db:"max_transfer_amount"`
+	DailyTransferAmount []uint8       `db:"daily_transfer_amount"`
+	DailyTransferCount  *int          `db:"daily_transfer_count"`
+	Type                AccountType   `db:"type"`
+	Currency            string        `db:"currency"`
+	MaturityDate        *time.Time    `db:"maturity_date"`
+	Status              AccountStatus `db:"status"`
+	LastActivityAt      time.Time     `db:"last_activity_at"`
+	CreatedAt           time.Time     `db:"created_at"`
+	UpdatedAt           time.Time     `db:"updated_at"`
+	User                *User
+	// Role of the user the account is listed for, only set when listing the accounts a user holds
+	HolderRole *HolderRole `db:"holder_role"`
 }
 
 type SerializedAccount struct {
-	ID        uint            `json:"id"`
-	UserID    uint            `json:"user_id"`
-	Balance   float64         `json:"balance,omitempty"`
-	CreatedAt time.Time       `json:"created_at,omitempty"`
-	UpdatedAt time.Time       `json:"updated_at,omitempty"`
-	User      *SerializedUser `json:"user,omitempty"`
+	ID                uint            `json:"id"`
+	UserID            uint            `json:"user_id"`
+	IBAN              string          `json:"iban,omitempty"`
+	Balance           float64         `json:"balance"`
+	AvailableBalance  float64         `json:"available_balance"`
+	HeldAmount        float64         `json:"held_amount"`
+	OverdraftLimit    float64         `json:"overdraft_limit"`
+	OverdraftHeadroom float64         `json:"overdraft_headroom"`
+	AccruedInterest   float64         `json:"accrued_interest"`
+	Type              AccountType     `json:"type"`
+	Currency          string          `json:"currency"`
+	MaturityDate      *time.Time      `json:"maturity_date,omitempty"`
+	Status            AccountStatus   `json:"status"`
+	LastActivityAt    time.Time       `json:"last_activity_at"`
+	CreatedAt         time.Time       `json:"created_at,omitempty"`
+	UpdatedAt         time.Time       `json:"updated_at,omitempty"`
+	User              *SerializedUser `json:"user,omitempty"`
+	HolderRole        *HolderRole     `json:"holder_role,omitempty"`
 }
 
 func (a *Account) Serialize() SerializedAccount {
@@ -36,16 +151,52 @@ func (a *Account) Serialize() SerializedAccount {
 		serializedUser = nil
 	}
 
+	balance := utils.Uint8ToFloat(a.Balance)
+	held := utils.Uint8ToFloat(a.HeldAmount)
+	overdraftLimit := utils.Uint8ToFloat(a.OverdraftLimit)
+
+	available := utils.RoundHalfEven(balance-held, 2)
+
+	// The part of the overdraft not used yet, held amounts using it as well
+	headroom := overdraftLimit
+	if available < 0 {
+		headroom = math.Max(0, overdraftLimit+available)
+	}
+
+	iban := ""
+	if a.IBAN != nil {
+		iban = *a.IBAN
+	}
+
 	return SerializedAccount{
-		ID:        a.ID,
-		UserID:    a.UserID,
-		Balance:   utils.Uint8ToFloat(a.Balance),
-		CreatedAt: a.CreatedAt,
-		UpdatedAt: a.UpdatedAt,
-		User:      serializedUser,
+		ID:                a.ID,
+		UserID:            a.UserID,
+		IBAN:              iban,
+		Balance:           balance,
+		AvailableBalance:  available,
+		HeldAmount:        held,
+		OverdraftLimit:    overdraftLimit,
+		OverdraftHeadroom: headroom,
+		AccruedInterest:   utils.Uint8ToFloat(a.AccruedInterest),
+		Type:              a.Type,
+		Currency:          a.Currency,
+		MaturityDate:      a.MaturityDate,
+		Status:            a.Status,
+		LastActivityAt:    a.LastActivityAt,
+		CreatedAt:         a.CreatedAt,
+		UpdatedAt:         a.UpdatedAt,
+		User:              serializedUser,
+		HolderRole:        a.HolderRole,
 	}
 }
 
+/*
+IsSystem reports whether the account is one of the bank's own accounts.
+*/
+func (a *Account) IsSystem() bool {
+	return a.SystemCode != nil
+}
+
 func SerializeAccounts(accounts []*Account) []SerializedAccount {
 	var serializedAccounts []SerializedAccount
 	for _, account := range accounts {
@@ -53,3 +204,35 @@ func SerializeAccounts(accounts []*Account) []SerializedAccount {
 	}
 	return serializedAccounts
 }
+
+type AccountStatusChange struct {
+	ID          uint          `db:"id"`
+	AccountID   uint          `db:"account_id"`
+	FromStatus  AccountStatus `db:"from_status"`
+	ToStatus    AccountStatus `db:"to_status"`
+	Reason      string        `db:"reason"`
+	ActorUserID *uint         `db:"actor_user_id"`
+	CreatedAt   time.Time     `db:"created_at"`
+}
+
+type SerializedAccountStatusChange struct {
+	ID          uint          `json:"id"`
+	AccountID   uint          `json:"account_id"`
+	FromStatus  AccountStatus `json:"from_status"`
+	ToStatus    AccountStatus `json:"to_status"`
+	Reason      string        `json:"reason"`
+	ActorUserID *uint         `json:"actor_user_id"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+func (c *AccountStatusChange) Serialize() SerializedAccountStatusChange {
+	return SerializedAccountStatusChange{
+		ID:          c.ID,
+		AccountID:   c.AccountID,
+		FromStatus:  c.FromStatus,
+		ToStatus:    c.ToStatus,
+		Reason:      c.Reason,
+		ActorUserID: c.ActorUserID,
+		CreatedAt:   c.CreatedAt,
+	}
+}
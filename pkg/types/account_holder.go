@@ -0,0 +1,138 @@
+package types
+
+import "time"
+
+type HolderRole string
+
+const (
+	HolderPrimary   HolderRole = "primary"
+	HolderJoint     HolderRole = "joint"
+	HolderSignatory HolderRole = "authorised_signatory"
+	HolderViewOnly  HolderRole = "view_only"
+)
+
+/*
+IsValid reports whether the role is a known holder role.
+*/
+func (r HolderRole) IsValid() bool {
+	switch r {
+	case HolderPrimary, HolderJoint, HolderSignatory, HolderViewOnly:
+		return true
+	default:
+		return false
+	}
+}
+
+/*
+CanTransact reports whether a holder with this role can send money from the account.
+View-only holders can only read it.
+*/
+func (r HolderRole) CanTransact() bool {
+	return r == HolderPrimary || r == HolderJoint || r == HolderSignatory
+}
+
+/*
+CanRequestHolderChange reports whether a holder with this role can ask to add or remove other holders.
+Every holder can still ask to be removed.
+*/
+func (r HolderRole) CanRequestHolderChange() bool {
+	return r == HolderPrimary || r == HolderJoint
+}
+
+/*
+AccountHolder is a user holding an account with a role.
+The name and email of the user are only set when the holders of an account are read.
+*/
+type AccountHolder struct {
+	AccountID uint       `db:"account_id"`
+	UserID    uint       `db:"user_id"`
+	Role      HolderRole `db:"role"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at"`
+	FirstName string     `db:"first_name"`
+	LastName  string     `db:"last_name"`
+	Email     string     `db:"email"`
+}
+
+type SerializedAccountHolder struct {
+	UserID    uint       `json:"user_id"`
+	FirstName string     `json:"first_name,omitempty"`
+	LastName  string     `json:"last_name,omitempty"`
+	Email     string     `json:"email,omitempty"`
+	Role      HolderRole `json:"role"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (h *AccountHolder) Serialize() SerializedAccountHolder {
+	return SerializedAccountHolder{
+		UserID:    h.UserID,
+		FirstName: h.FirstName,
+		LastName:  h.LastName,
+		Email:     h.Email,
+		Role:      h.Role,
+		CreatedAt: h.CreatedAt,
+	}
+}
+
+type HolderChangeAction string
+
+const (
+	HolderChangeAdd    HolderChangeAction = "add"
+	HolderChangeRemove HolderChangeAction = "remove"
+)
+
+type HolderChangeStatus string
+
+const (
+	HolderChangePending  HolderChangeStatus = "pending"
+	HolderChangeApproved HolderChangeStatus = "approved"
+	HolderChangeRejected HolderChangeStatus = "rejected"
+)
+
+/*
+AccountHolderChange is a holder added to or removed from an account by one of its holders.
+It is only applied once approved by the primary holder, right away when asked by the primary holder.
+*/
+type AccountHolderChange struct {
+	ID          uint               `db:"id"`
+	AccountID   uint               `db:"account_id"`
+	UserID      uint               `db:"user_id"`
+	Action      HolderChangeAction `db:"action"`
+	Role        *HolderRole        `db:"role"`
+	Status      HolderChangeStatus `db:"status"`
+	RequestedBy uint               `db:"requested_by"`
+	DecidedBy   *uint              `db:"decided_by"`
+	DecidedAt   *time.Time         `db:"decided_at"`
+	CreatedAt   time.Time          `db:"created_at"`
+	UpdatedAt   time.Time          `db:"updated_at"`
+}
+
+type SerializedAccountHolderChange struct {
+	ID          uint               `json:"id"`
+	AccountID   uint               `json:"account_id"`
+	UserID      uint               `json:"user_id"`
+	Action      HolderChangeAction `json:"action"`
+	Role        *HolderRole        `json:"role,omitempty"`
+	Status      HolderChangeStatus `json:"status"`
+	RequestedBy uint               `json:"requested_by"`
+	DecidedBy   *uint              `json:"decided_by,omitempty"`
+	DecidedAt   *time.Time         `json:"decided_at,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+func (c *AccountHolderChange) Serialize() SerializedAccountHolderChange {
+	return SerializedAccountHolderChange{
+		ID:          c.ID,
+		AccountID:   c.AccountID,
+		UserID:      c.UserID,
+		Action:      c.Action,
+		Role:        c.Role,
+		Status:      c.Status,
+		RequestedBy: c.RequestedBy,
+		DecidedBy:   c.DecidedBy,
+		DecidedAt:   c.DecidedAt,
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+	}
+}
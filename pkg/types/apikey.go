@@ -0,0 +1,88 @@
+package types
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	ScopeUserRead      = "user:read"
+	ScopeAccountRead   = "account:read"
+	ScopeTransferWrite = "transfer:write"
+	ScopeAdmin         = "admin"
+)
+
+var scopes = map[string]bool{
+	ScopeUserRead:      true,
+	ScopeAccountRead:   true,
+	ScopeTransferWrite: true,
+	ScopeAdmin:         true,
+}
+
+/*
+IsValidScope reports whether the scope can be granted to an API key.
+*/
+func IsValidScope(scope string) bool {
+	return scopes[scope]
+}
+
+type ApiKey struct {
+	ID         uint           `db:"id"`
+	UserID     uint           `db:"user_id"`
+	AccountID  uint           `db:"account_id"`
+	Name       string         `db:"name"`
+	Prefix     string         `db:"prefix"`
+	SecretHash string         `db:"secret_hash"`
+	Scopes     pq.StringArray `db:"scopes"`
+	AllowedIPs pq.StringArray `db:"allowed_ips"`
+	ExpiresAt  *time.Time     `db:"expires_at"`
+	LastUsedAt *time.Time     `db:"last_used_at"`
+	LastUsedIP *string        `db:"last_used_ip"`
+	RevokedAt  *time.Time     `db:"revoked_at"`
+	CreatedAt  time.Time      `db:"created_at"`
+	UpdatedAt  time.Time      `db:"updated_at"`
+}
+
+type SerializedApiKey struct {
+	ID         uint       `json:"id"`
+	AccountID  uint       `json:"account_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Key        string     `json:"key,omitempty"`
+	Scopes     []string   `json:"scopes"`
+	AllowedIPs []string   `json:"allowed_ips"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP *string    `json:"last_used_ip"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func (k *ApiKey) Serialize() SerializedApiKey {
+	return SerializedApiKey{
+		ID:         k.ID,
+		AccountID:  k.AccountID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     k.Scopes,
+		AllowedIPs: k.AllowedIPs,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		LastUsedIP: k.LastUsedIP,
+		RevokedAt:  k.RevokedAt,
+		CreatedAt:  k.CreatedAt,
+		UpdatedAt:  k.UpdatedAt,
+	}
+}
+
+/*
+IsActive reports whether the key is neither revoked nor expired at the given time.
+*/
+func (k *ApiKey) IsActive(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
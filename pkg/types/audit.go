@@ -0,0 +1,35 @@
+package types
+
+import "time"
+
+type AuditEntry struct {
+	ID          uint      `db:"id"`
+	ActorUserID *uint     `db:"actor_user_id"`
+	Permission  string    `db:"permission"`
+	Method      string    `db:"method"`
+	Path        string    `db:"path"`
+	Status      int       `db:"status"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+type SerializedAuditEntry struct {
+	ID          uint      `json:"id"`
+	ActorUserID *uint     `json:"actor_user_id"`
+	Permission  string    `json:"permission"`
+	Method      string    `json:"method"`
+	Path        string    `json:"path"`
+	Status      int       `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (e *AuditEntry) Serialize() SerializedAuditEntry {
+	return SerializedAuditEntry{
+		ID:          e.ID,
+		ActorUserID: e.ActorUserID,
+		Permission:  e.Permission,
+		Method:      e.Method,
+		Path:        e.Path,
+		Status:      e.Status,
+		CreatedAt:   e.CreatedAt,
+	}
+}
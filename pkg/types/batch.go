@@ -0,0 +1,210 @@
+package types
+
+import (
+	"time"
+
+	"github.com/farischt/gobank/utils"
+)
+
+type BatchFormat string
+
+const (
+	BatchPain001 BatchFormat = "pain001"
+	BatchCSV     BatchFormat = "csv"
+)
+
+type BatchMode string
+
+const (
+	// Every line is executed within a single sql transaction, the first failure cancelling them all
+	BatchAllOrNothing BatchMode = "all_or_nothing"
+	// Every line is executed on its own, the failures being recorded line by line
+	BatchBestEffort BatchMode = "best_effort"
+)
+
+/*
+IsValid reports whether the mode is a known batch mode.
+*/
+func (m BatchMode) IsValid() bool {
+	return m == BatchAllOrNothing || m == BatchBestEffort
+}
+
+type BatchStatus string
+
+const (
+	BatchPending            BatchStatus = "pending"
+	BatchInvalid            BatchStatus = "invalid"
+	BatchExecuting          BatchStatus = "executing"
+	BatchCompleted          BatchStatus = "completed"
+	BatchPartiallyCompleted BatchStatus = "partially_completed"
+	BatchFailed             BatchStatus = "failed"
+	BatchCancelled          BatchStatus = "cancelled"
+)
+
+type BatchLineStatus string
+
+const (
+	BatchLinePending  BatchLineStatus = "pending"
+	BatchLineInvalid  BatchLineStatus = "invalid"
+	BatchLineExecuted BatchLineStatus = "executed"
+	BatchLineFailed   BatchLineStatus = "failed"
+)
+
+/*
+Batch is a file of transfers from an account, uploaded as a preview and executed once confirmed.
+A batch with an invalid line is invalid as a whole and can't be executed.
+*/
+type Batch struct {
+	ID         uint        `db:"id"`
+	AccountID  uint        `db:"account_id"`
+	Format     BatchFormat `db:"format"`
+	Mode       BatchMode   `db:"mode"`
+	Status     BatchStatus `db:"status"`
+	MessageID  *string     `db:"message_id"`
+	LineCount  int         `db:"line_count"`
+	ControlSum []uint8     `db:"control_sum"`
+	ExecutedAt *time.Time  `db:"executed_at"`
+	CreatedAt  time.Time   `db:"created_at"`
+	UpdatedAt  time.Time   `db:"updated_at"`
+}
+
+/*
+BatchLine is a transfer of a batch, with the reason it is invalid or failed if so.
+*/
+type BatchLine struct {
+	ID                uint            `db:"id"`
+	BatchID           uint            `db:"batch_id"`
+	LineNumber        int             `db:"line_number"`
+	ToIBAN            string          `db:"to_iban"`
+	To                *uint           `db:"to_id"`
+	Amount            []uint8         `db:"amount"`
+	Reference         *string         `db:"reference"`
+	CreditorReference *string         `db:"creditor_reference"`
+	EndToEndID        *string         `db:"end_to_end_id"`
+	Status            BatchLineStatus `db:"status"`
+	Error             *string         `db:"error"`
+	TransactionID     *uint           `db:"transaction_id"`
+	UpdatedAt         time.Time       `db:"updated_at"`
+}
+
+/*
+BatchEntry is a batch to record, with its lines.
+*/
+type BatchEntry struct {
+	AccountID  uint
+	Format     BatchFormat
+	Mode       BatchMode
+	Status     BatchStatus
+	MessageID  *string
+	ControlSum float64
+	Lines      []BatchLineEntry
+}
+
+type BatchLineEntry struct {
+	LineNumber        int
+	ToIBAN            string
+	To                *uint
+	Amount            float64
+	Reference         *string
+	CreditorReference *string
+	EndToEndID        *string
+	Status            BatchLineStatus
+	Error             *string
+}
+
+type SerializedBatchLine struct {
+	LineNumber        int             `json:"line_number"`
+	To                string          `json:"to"`
+	Amount            float64         `json:"amount"`
+	Reference         *string         `json:"reference,omitempty"`
+	CreditorReference *string         `json:"creditor_reference,omitempty"`
+	EndToEndID        *string         `json:"end_to_end_id,omitempty"`
+	Status            BatchLineStatus `json:"status"`
+	Error             *string         `json:"error,omitempty"`
+	TransactionID     *uint           `json:"transaction_id,omitempty"`
+}
+
+func (l *BatchLine) Serialize() SerializedBatchLine {
+	return SerializedBatchLine{
+		LineNumber:        l.LineNumber,
+		To:                l.ToIBAN,
+		Amount:            utils.Uint8ToFloat(l.Amount),
+		Reference:         l.Reference,
+		CreditorReference: l.CreditorReference,
+		EndToEndID:        l.EndToEndID,
+		Status:            l.Status,
+		Error:             l.Error,
+		TransactionID:     l.TransactionID,
+	}
+}
+
+/*
+BatchReport sums up the lines of a batch by status.
+*/
+type BatchReport struct {
+	Pending        int     `json:"pending"`
+	Invalid        int     `json:"invalid"`
+	Executed       int     `json:"executed"`
+	Failed         int     `json:"failed"`
+	ExecutedAmount float64 `json:"executed_amount"`
+}
+
+type SerializedBatch struct {
+	ID         uint                  `json:"id"`
+	AccountID  uint                  `json:"account_id"`
+	Format     BatchFormat           `json:"format"`
+	Mode       BatchMode             `json:"mode"`
+	Status     BatchStatus           `json:"status"`
+	MessageID  *string               `json:"message_id,omitempty"`
+	LineCount  int                   `json:"line_count"`
+	ControlSum float64               `json:"control_sum"`
+	ExecutedAt *time.Time            `json:"executed_at,omitempty"`
+	CreatedAt  time.Time             `json:"created_at"`
+	UpdatedAt  time.Time             `json:"updated_at"`
+	Report     *BatchReport          `json:"report,omitempty"`
+	Lines      []SerializedBatchLine `json:"lines,omitempty"`
+}
+
+func (b *Batch) Serialize() SerializedBatch {
+	return SerializedBatch{
+		ID:         b.ID,
+		AccountID:  b.AccountID,
+		Format:     b.Format,
+		Mode:       b.Mode,
+		Status:     b.Status,
+		MessageID:  b.MessageID,
+		LineCount:  b.LineCount,
+		ControlSum: utils.Uint8ToFloat(b.ControlSum),
+		ExecutedAt: b.ExecutedAt,
+		CreatedAt:  b.CreatedAt,
+		UpdatedAt:  b.UpdatedAt,
+	}
+}
+
+/*
+SerializeWithLines serializes a batch with its lines and the report of them.
+*/
+func (b *Batch) SerializeWithLines(lines []*BatchLine) SerializedBatch {
+	serialized := b.Serialize()
+	serialized.Report = &BatchReport{}
+	serialized.Lines = []SerializedBatchLine{}
+
+	for _, l := range lines {
+		line := l.Serialize()
+		switch l.Status {
+		case BatchLinePending:
+			serialized.Report.Pending++
+		case BatchLineInvalid:
+			serialized.Report.Invalid++
+		case BatchLineExecuted:
+			serialized.Report.Executed++
+			serialized.Report.ExecutedAmount += line.Amount
+		case BatchLineFailed:
+			serialized.Report.Failed++
+		}
+		serialized.Lines = append(serialized.Lines, line)
+	}
+	serialized.Report.ExecutedAmount = utils.RoundHalfEven(serialized.Report.ExecutedAmount, 2)
+
+	return serialized
+}
@@ -0,0 +1,97 @@
+package types
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type NameMatch string
+
+const (
+	NameMatched    NameMatch = "match"
+	NameCloseMatch NameMatch = "close_match"
+	NameNoMatch    NameMatch = "no_match"
+	NameNotChecked NameMatch = "not_checked"
+)
+
+/*
+MatchName compares the name of a recipient given by a user with the real one.
+The comparison ignores case and extra spaces, a name with the right last name only is a close match.
+*/
+func MatchName(given string, firstName string, lastName string) NameMatch {
+	given = strings.ToLower(strings.Join(strings.Fields(given), " "))
+	if given == "" {
+		return NameNotChecked
+	}
+
+	firstName = strings.ToLower(strings.TrimSpace(firstName))
+	lastName = strings.ToLower(strings.TrimSpace(lastName))
+
+	switch {
+	case given == firstName+" "+lastName || given == lastName+" "+firstName:
+		return NameMatched
+	case strings.HasSuffix(given, " "+lastName) || strings.HasPrefix(given, lastName+" ") || given == lastName:
+		return NameCloseMatch
+	default:
+		return NameNoMatch
+	}
+}
+
+/*
+MaskName masks a name but the first letter of each of its words, e.g. J*** D**.
+*/
+func MaskName(name string) string {
+	words := strings.Fields(name)
+	for i, w := range words {
+		first, size := utf8.DecodeRuneInString(w)
+		words[i] = string(first) + strings.Repeat("*", utf8.RuneCountInString(w[size:]))
+	}
+	return strings.Join(words, " ")
+}
+
+/*
+Beneficiary is an account a user saved to send money to.
+It has to be confirmed by the user, after seeing the masked name of the recipient, before its first use.
+*/
+type Beneficiary struct {
+	ID               uint       `db:"id"`
+	UserID           uint       `db:"user_id"`
+	AccountID        uint       `db:"account_id"`
+	IBAN             string     `db:"iban"`
+	Nickname         string     `db:"nickname"`
+	DefaultReference *string    `db:"default_reference"`
+	MaskedName       string     `db:"masked_name"`
+	NameMatch        NameMatch  `db:"name_match"`
+	ConfirmedAt      *time.Time `db:"confirmed_at"`
+	CreatedAt        time.Time  `db:"created_at"`
+	UpdatedAt        time.Time  `db:"updated_at"`
+}
+
+type SerializedBeneficiary struct {
+	ID               uint       `json:"id"`
+	UserID           uint       `json:"user_id"`
+	IBAN             string     `json:"iban"`
+	Nickname         string     `json:"nickname"`
+	DefaultReference *string    `json:"default_reference,omitempty"`
+	MaskedName       string     `json:"masked_name"`
+	NameMatch        NameMatch  `json:"name_match"`
+	ConfirmedAt      *time.Time `json:"confirmed_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+func (b *Beneficiary) Serialize() SerializedBeneficiary {
+	return SerializedBeneficiary{
+		ID:               b.ID,
+		UserID:           b.UserID,
+		IBAN:             b.IBAN,
+		Nickname:         b.Nickname,
+		DefaultReference: b.DefaultReference,
+		MaskedName:       b.MaskedName,
+		NameMatch:        b.NameMatch,
+		ConfirmedAt:      b.ConfirmedAt,
+		CreatedAt:        b.CreatedAt,
+		UpdatedAt:        b.UpdatedAt,
+	}
+}
@@ -0,0 +1,45 @@
+package types
+
+type FeeKind string
+
+const (
+	FeeFlat       FeeKind = "flat"
+	FeePercentage FeeKind = "percentage"
+)
+
+/*
+IsValid reports whether the kind is a known fee kind.
+*/
+func (k FeeKind) IsValid() bool {
+	return k == FeeFlat || k == FeePercentage
+}
+
+/*
+FeeRule is the fee charged for a transaction type on an account type.
+Amount is the flat fee, or the rate applied to the transaction amount for a percentage fee,
+which is then kept between Min and Max when they are set. The first FreePerMonth
+transactions of each month are free.
+*/
+type FeeRule struct {
+	Kind         FeeKind `json:"kind"`
+	Amount       float64 `json:"amount"`
+	Min          float64 `json:"min,omitempty"`
+	Max          float64 `json:"max,omitempty"`
+	FreePerMonth int     `json:"free_per_month,omitempty"`
+}
+
+/*
+TransferQuote previews what a transfer would cost the sender.
+*/
+type TransferQuote struct {
+	Amount float64 `json:"amount"`
+	Fee    float64 `json:"fee"`
+	Total  float64 `json:"total"`
+	// What the recipient is credited, in its currency
+	ToAmount   float64  `json:"to_amount"`
+	ToCurrency string   `json:"to_currency"`
+	FxRate     *float64 `json:"fx_rate,omitempty"`
+	Rule       *FeeRule `json:"rule,omitempty"`
+	// Number of free transfers left this month, unset without allowance
+	FreeRemaining *int `json:"free_remaining,omitempty"`
+}
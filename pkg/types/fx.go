@@ -0,0 +1,52 @@
+package types
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/farischt/gobank/utils"
+)
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+/*
+IsValidCurrency reports whether the code is shaped as an ISO 4217 alphabetic currency code.
+*/
+func IsValidCurrency(code string) bool {
+	return currencyCode.MatchString(code)
+}
+
+/*
+FxRate is the number of units of the quote currency one unit of the base currency buys, as of a time.
+*/
+type FxRate struct {
+	ID        uint      `db:"id"`
+	Base      string    `db:"base"`
+	Quote     string    `db:"quote"`
+	Rate      []uint8   `db:"rate"`
+	AsOf      time.Time `db:"as_of"`
+	Source    string    `db:"source"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type SerializedFxRate struct {
+	ID        uint      `json:"id"`
+	Base      string    `json:"base"`
+	Quote     string    `json:"quote"`
+	Rate      float64   `json:"rate"`
+	AsOf      time.Time `json:"as_of"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (r *FxRate) Serialize() SerializedFxRate {
+	return SerializedFxRate{
+		ID:        r.ID,
+		Base:      r.Base,
+		Quote:     r.Quote,
+		Rate:      utils.Uint8ToFloat(r.Rate),
+		AsOf:      r.AsOf,
+		Source:    r.Source,
+		CreatedAt: r.CreatedAt,
+	}
+}
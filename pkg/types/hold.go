@@ -0,0 +1,61 @@
+package types
+
+import (
+	"time"
+
+	"github.com/farischt/gobank/utils"
+)
+
+type HoldStatus string
+
+const (
+	HoldActive   HoldStatus = "active"
+	HoldCaptured HoldStatus = "captured"
+	HoldReleased HoldStatus = "released"
+	HoldExpired  HoldStatus = "expired"
+)
+
+/*
+Hold is an amount reserved on an account for a later transfer to the recipient.
+It reduces the available balance of the account until it is captured, released or expired.
+*/
+type Hold struct {
+	ID             uint       `db:"id"`
+	AccountID      uint       `db:"account_id"`
+	To             uint       `db:"to_id"`
+	Amount         []uint8    `db:"amount"`
+	CapturedAmount []uint8    `db:"captured_amount"`
+	Status         HoldStatus `db:"status"`
+	TransactionID  *uint      `db:"transaction_id"`
+	ExpiresAt      time.Time  `db:"expires_at"`
+	CreatedAt      time.Time  `db:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+}
+
+type SerializedHold struct {
+	ID             uint       `json:"id"`
+	AccountID      uint       `json:"account_id"`
+	To             uint       `json:"to"`
+	Amount         float64    `json:"amount"`
+	CapturedAmount float64    `json:"captured_amount,omitempty"`
+	Status         HoldStatus `json:"status"`
+	TransactionID  *uint      `json:"transaction_id,omitempty"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (h *Hold) Serialize() SerializedHold {
+	return SerializedHold{
+		ID:             h.ID,
+		AccountID:      h.AccountID,
+		To:             h.To,
+		Amount:         utils.Uint8ToFloat(h.Amount),
+		CapturedAmount: utils.Uint8ToFloat(h.CapturedAmount),
+		Status:         h.Status,
+		TransactionID:  h.TransactionID,
+		ExpiresAt:      h.ExpiresAt,
+		CreatedAt:      h.CreatedAt,
+		UpdatedAt:      h.UpdatedAt,
+	}
+}
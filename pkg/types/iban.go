@@ -0,0 +1,50 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var ibanFormat = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+
+/*
+NormalizeIBAN removes the spaces of an IBAN, as printed on paper, and upper-cases it.
+*/
+func NormalizeIBAN(iban string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(iban), ""))
+}
+
+/*
+IsValidIBAN reports whether a normalized IBAN follows the ISO 13616 structure and its check digits are right.
+The length of the BBAN is not checked against the country.
+*/
+func IsValidIBAN(iban string) bool {
+	return ibanFormat.MatchString(iban) && mod97(iban[4:]+iban[:4]) == 1
+}
+
+/*
+NewIBAN builds the IBAN of a BBAN in a country, computing its check digits.
+*/
+func NewIBAN(country string, bban string) string {
+	checkDigits := 98 - mod97(bban+country+"00")
+	return fmt.Sprintf("%s%02d%s", country, checkDigits, bban)
+}
+
+/*
+mod97 computes the ISO 7064 MOD 97-10 remainder of a string of digits and letters,
+each letter standing for two digits (A = 10, ..., Z = 35).
+The remainder is computed one digit at a time, as the number doesn't fit in an integer.
+*/
+func mod97(s string) int {
+	remainder := 0
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			remainder = (remainder*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			remainder = (remainder*100 + int(c-'A'+10)) % 97
+		}
+	}
+	return remainder
+}
@@ -0,0 +1,83 @@
+package types
+
+import "testing"
+
+func TestIsValidIBAN(t *testing.T) {
+	tests := []struct {
+		name string
+		iban string
+		want bool
+	}{
+		{"french", "FR7630006000011234567890189", true},
+		{"german", "DE89370400440532013000", true},
+		{"british with letters in the BBAN", "GB29NWBK60161331926819", true},
+		{"belgian, the shortest structure", "BE68539007547034", true},
+		{"wrong check digits", "FR7730006000011234567890189", false},
+		{"swapped digits in the BBAN", "FR7630006000011234567890198", false},
+		{"check digits 00", "FR0030006000011234567890189", false},
+		{"lower case", "fr7630006000011234567890189", false},
+		{"with spaces", "FR76 3000 6000 0112 3456 7890 189", false},
+		{"too short", "FR76300060", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsValidIBAN(tt.iban); got != tt.want {
+				t.Errorf("IsValidIBAN(%q) = %v, want %v", tt.iban, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewIBAN(t *testing.T) {
+	tests := []struct {
+		country string
+		bban    string
+		want    string
+	}{
+		{"FR", "30006000011234567890189", "FR7630006000011234567890189"},
+		{"DE", "370400440532013000", "DE89370400440532013000"},
+		{"GB", "NWBK60161331926819", "GB29NWBK60161331926819"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := NewIBAN(tt.country, tt.bban)
+			if got != tt.want {
+				t.Errorf("NewIBAN(%s, %s) = %s, want %s", tt.country, tt.bban, got, tt.want)
+			} else if !IsValidIBAN(got) {
+				t.Errorf("NewIBAN(%s, %s) = %s, not a valid IBAN", tt.country, tt.bban, got)
+			}
+		})
+	}
+}
+
+func TestMod97(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"0", 0},
+		{"97", 0},
+		{"98", 1},
+		{"A", 10},
+		{"Z", 35},
+		{"3214282912345698765432161182", 1},
+		{"30006000011234567890189FR76", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			if got := mod97(tt.s); got != tt.want {
+				t.Errorf("mod97(%s) = %d, want %d", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeIBAN(t *testing.T) {
+	if got := NormalizeIBAN(" fr76 3000 6000\t0112 3456 7890 189 "); got != "FR7630006000011234567890189" {
+		t.Errorf("NormalizeIBAN = %s, want FR7630006000011234567890189", got)
+	}
+}
@@ -0,0 +1,59 @@
+package types
+
+import "time"
+
+/*
+DayCount is the day-count convention used to turn an annual rate into a daily one.
+*/
+type DayCount string
+
+const (
+	DayCountAct365 DayCount = "ACT/365"
+	DayCountAct360 DayCount = "ACT/360"
+)
+
+/*
+IsValid reports whether the convention is a known day-count convention.
+*/
+func (d DayCount) IsValid() bool {
+	switch d {
+	case DayCountAct365, DayCountAct360:
+		return true
+	default:
+		return false
+	}
+}
+
+/*
+YearDays returns the number of days in a year according to the convention.
+Every actual day accrues 1/YearDays of the annual rate, so a leap year accrues
+366/365 of the rate under ACT/365.
+*/
+func (d DayCount) YearDays() float64 {
+	if d == DayCountAct360 {
+		return 360
+	}
+	return 365
+}
+
+/*
+InterestAccrual is the interest accrued by an account for one business date.
+*/
+type InterestAccrual struct {
+	AccountID    uint
+	BusinessDate time.Time
+	Balance      float64
+	Rate         float64
+	DayCount     DayCount
+	Amount       float64
+}
+
+/*
+InterestCapitalization is the accrued interest paid into an account at the end of a month.
+*/
+type InterestCapitalization struct {
+	AccountID     uint
+	BusinessDate  time.Time
+	Amount        float64
+	TransactionID uint
+}
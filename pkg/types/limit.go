@@ -0,0 +1,21 @@
+package types
+
+/*
+TransferLimits are the limits on the outgoing transfers of an account.
+A zero value means unlimited.
+*/
+type TransferLimits struct {
+	MaxAmount   float64 `json:"max_amount"`
+	DailyAmount float64 `json:"daily_amount"`
+	DailyCount  int     `json:"daily_count"`
+}
+
+/*
+TransferLimitsUsage are the effective limits of an account and how much of them is used today.
+*/
+type TransferLimitsUsage struct {
+	AccountID   uint           `json:"account_id"`
+	Limits      TransferLimits `json:"limits"`
+	AmountToday float64        `json:"amount_today"`
+	CountToday  int            `json:"count_today"`
+}
@@ -0,0 +1,73 @@
+package types
+
+import (
+	"time"
+
+	"github.com/farischt/gobank/utils"
+)
+
+type PaymentRequestStatus string
+
+const (
+	PaymentRequestPending   PaymentRequestStatus = "pending"
+	PaymentRequestAccepted  PaymentRequestStatus = "accepted"
+	PaymentRequestDeclined  PaymentRequestStatus = "declined"
+	PaymentRequestCancelled PaymentRequestStatus = "cancelled"
+	PaymentRequestExpired   PaymentRequestStatus = "expired"
+)
+
+/*
+PaymentRequest is a request from an account, the requester, to be paid an amount by another one, the payer.
+An accepted request is linked to the transfer that paid it.
+The IBANs of the accounts are only set when the request is read.
+*/
+type PaymentRequest struct {
+	ID             uint                 `db:"id"`
+	RequesterID    uint                 `db:"requester_id"`
+	PayerID        uint                 `db:"payer_id"`
+	Amount         []uint8              `db:"amount"`
+	Reason         *string              `db:"reason"`
+	Status         PaymentRequestStatus `db:"status"`
+	IdempotencyKey *string              `db:"idempotency_key"`
+	TransactionID  *uint                `db:"transaction_id"`
+	ExpiresAt      time.Time            `db:"expires_at"`
+	CreatedAt      time.Time            `db:"created_at"`
+	UpdatedAt      time.Time            `db:"updated_at"`
+	RequesterIBAN  *string              `db:"requester_iban"`
+	PayerIBAN      *string              `db:"payer_iban"`
+	Currency       string               `db:"currency"`
+}
+
+type SerializedPaymentRequest struct {
+	ID            uint                 `json:"id"`
+	Requester     uint                 `json:"requester"`
+	RequesterIBAN *string              `json:"requester_iban,omitempty"`
+	Payer         uint                 `json:"payer"`
+	PayerIBAN     *string              `json:"payer_iban,omitempty"`
+	Amount        float64              `json:"amount"`
+	Currency      string               `json:"currency,omitempty"`
+	Reason        *string              `json:"reason,omitempty"`
+	Status        PaymentRequestStatus `json:"status"`
+	TransactionID *uint                `json:"transaction_id,omitempty"`
+	ExpiresAt     time.Time            `json:"expires_at"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+}
+
+func (r *PaymentRequest) Serialize() SerializedPaymentRequest {
+	return SerializedPaymentRequest{
+		ID:            r.ID,
+		Requester:     r.RequesterID,
+		RequesterIBAN: r.RequesterIBAN,
+		Payer:         r.PayerID,
+		PayerIBAN:     r.PayerIBAN,
+		Amount:        utils.Uint8ToFloat(r.Amount),
+		Currency:      r.Currency,
+		Reason:        r.Reason,
+		Status:        r.Status,
+		TransactionID: r.TransactionID,
+		ExpiresAt:     r.ExpiresAt,
+		CreatedAt:     r.CreatedAt,
+		UpdatedAt:     r.UpdatedAt,
+	}
+}
@@ -0,0 +1,90 @@
+package types
+
+/*
+Principal is the authenticated caller of a request.
+It is resolved from the session token or the API key and carried in the request context.
+*/
+type Principal struct {
+	// Account the session or the API key acts on, unset for a session of a user holding no account
+	AccountID uint
+	UserID    uint
+	Roles     []Role
+	// Only set when authenticated with an API key
+	ApiKeyID     uint
+	ApiKeyPrefix string
+	Scopes       []string
+}
+
+/*
+IsApiKey reports whether the principal authenticated with an API key.
+*/
+func (p *Principal) IsApiKey() bool {
+	return p != nil && p.ApiKeyID != 0
+}
+
+/*
+HasScope reports whether the principal may call a route requiring the given scope.
+Session principals are not restricted by scopes.
+*/
+func (p *Principal) HasScope(scope string) bool {
+	if p == nil {
+		return false
+	} else if !p.IsApiKey() {
+		return true
+	}
+
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Can reports whether one of the principal's roles grants the given permission.
+*/
+func (p *Principal) Can(perm Permission) bool {
+	if p == nil {
+		return false
+	}
+
+	for _, r := range p.Roles {
+		if r.HasPermission(perm) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+HasRole reports whether the principal holds the given role.
+*/
+func (p *Principal) HasRole(role Role) bool {
+	if p == nil {
+		return false
+	}
+
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+CanReadUser reports whether the principal is allowed to read the given user.
+Reading another user is only allowed by the user:read_any permission, its use must be audited.
+*/
+func (p *Principal) CanReadUser(userId uint) bool {
+	return p != nil && (p.UserID == userId || p.Can(PermUserReadAny))
+}
+
+/*
+CanReadAccount reports whether the principal is allowed to read the given account.
+Reading the account of another user is only allowed by the account:read_any permission, its use must be audited.
+*/
+func (p *Principal) CanReadAccount(a *Account) bool {
+	return p != nil && a != nil && (p.UserID == a.UserID || p.Can(PermAccountReadAny))
+}
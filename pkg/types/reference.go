@@ -0,0 +1,20 @@
+package types
+
+import "regexp"
+
+var creditorReferenceFormat = regexp.MustCompile(`^RF[0-9]{2}[A-Z0-9]{1,21}$`)
+
+/*
+NormalizeCreditorReference removes the spaces of a creditor reference, as printed on an invoice, and upper-cases it.
+*/
+func NormalizeCreditorReference(ref string) string {
+	return NormalizeIBAN(ref)
+}
+
+/*
+IsValidCreditorReference reports whether a normalized reference is an ISO 11649 structured
+creditor reference (RF) and its check digits are right.
+*/
+func IsValidCreditorReference(ref string) bool {
+	return creditorReferenceFormat.MatchString(ref) && mod97(ref[4:]+ref[:4]) == 1
+}
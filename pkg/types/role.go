@@ -0,0 +1,107 @@
+package types
+
+import "time"
+
+type Role string
+
+const (
+	RoleCustomer Role = "customer"
+	RoleSupport  Role = "support"
+	RoleOperator Role = "operator"
+	RoleAdmin    Role = "admin"
+)
+
+type Permission string
+
+const (
+	PermUserReadAny        Permission = "user:read_any"
+	PermAccountReadAny     Permission = "account:read_any"
+	PermAccountOpenAny     Permission = "account:open_any"
+	PermAccountFreeze      Permission = "account:freeze"
+	PermAccountClose       Permission = "account:close"
+	PermAccountOverdraft   Permission = "account:overdraft"
+	PermAccountLimits      Permission = "account:limits"
+	PermTransactionReverse Permission = "transaction:reverse"
+	PermRoleManage         Permission = "role:manage"
+	PermAuditRead          Permission = "audit:read"
+	PermFxManage           Permission = "fx:manage"
+)
+
+/*
+rolePermissions is the permission matrix.
+A customer has no privileged permission, it can only act on what it owns.
+*/
+var rolePermissions = map[Role][]Permission{
+	RoleCustomer: {},
+	RoleSupport: {
+		PermUserReadAny,
+		PermAccountReadAny,
+	},
+	RoleOperator: {
+		PermUserReadAny,
+		PermAccountReadAny,
+		PermAccountOpenAny,
+		PermAccountFreeze,
+		PermAccountClose,
+		PermAccountOverdraft,
+		PermAccountLimits,
+		PermTransactionReverse,
+		PermFxManage,
+	},
+	RoleAdmin: {
+		PermUserReadAny,
+		PermAccountReadAny,
+		PermAccountOpenAny,
+		PermAccountFreeze,
+		PermAccountClose,
+		PermAccountOverdraft,
+		PermAccountLimits,
+		PermTransactionReverse,
+		PermRoleManage,
+		PermAuditRead,
+		PermFxManage,
+	},
+}
+
+/*
+IsValid reports whether the role is part of the permission matrix.
+*/
+func (r Role) IsValid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+/*
+HasPermission reports whether the role grants the given permission.
+*/
+func (r Role) HasPermission(perm Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+type UserRole struct {
+	UserID    uint      `db:"user_id"`
+	Role      Role      `db:"role"`
+	GrantedBy *uint     `db:"granted_by"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type SerializedUserRole struct {
+	UserID    uint      `json:"user_id"`
+	Role      Role      `json:"role"`
+	GrantedBy *uint     `json:"granted_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (r *UserRole) Serialize() SerializedUserRole {
+	return SerializedUserRole{
+		UserID:    r.UserID,
+		Role:      r.Role,
+		GrantedBy: r.GrantedBy,
+		CreatedAt: r.CreatedAt,
+	}
+}
@@ -2,16 +2,22 @@ package types
 
 import "time"
 
+/*
+SessionToken is a session of a user. The account of the session is the one it acts on
+when a request doesn't name one, unset if the user holds no account it can log in to.
+*/
 type SessionToken struct {
 	ID        string    `db:"id"`
-	AccountId uint      `db:"account_id"`
+	UserID    uint      `db:"user_id"`
+	AccountId *uint     `db:"account_id"`
 	CreatedAt time.Time `db:"created_at"`
 	UpdatedAt time.Time `db:"updated_at"`
 }
 
 type SerializedSessionToken struct {
 	ID        string    `json:"id"`
-	AccountId uint      `json:"account_id"`
+	UserID    uint      `json:"user_id"`
+	AccountId *uint     `json:"account_id,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -19,6 +25,7 @@ type SerializedSessionToken struct {
 func (s *SessionToken) Serialize() *SerializedSessionToken {
 	return &SerializedSessionToken{
 		ID:        s.ID,
+		UserID:    s.UserID,
 		AccountId: s.AccountId,
 		CreatedAt: s.CreatedAt,
 		UpdatedAt: s.UpdatedAt,
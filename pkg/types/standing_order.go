@@ -0,0 +1,192 @@
+package types
+
+import (
+	"time"
+
+	"github.com/farischt/gobank/utils"
+)
+
+type StandingOrderFrequency string
+
+const (
+	FrequencyOnce       StandingOrderFrequency = "once"
+	FrequencyWeekly     StandingOrderFrequency = "weekly"
+	FrequencyMonthly    StandingOrderFrequency = "monthly"
+	FrequencyEndOfMonth StandingOrderFrequency = "end_of_month"
+)
+
+/*
+IsValid reports whether the frequency is a known standing order frequency.
+*/
+func (f StandingOrderFrequency) IsValid() bool {
+	switch f {
+	case FrequencyOnce, FrequencyWeekly, FrequencyMonthly, FrequencyEndOfMonth:
+		return true
+	default:
+		return false
+	}
+}
+
+/*
+lastDayOfMonth returns the last day of the month of the given date.
+*/
+func lastDayOfMonth(year int, month time.Month) time.Time {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+}
+
+/*
+dayOfMonth returns the given day of a month, or its last day for shorter months.
+*/
+func dayOfMonth(year int, month time.Month, day int) time.Time {
+	last := lastDayOfMonth(year, month)
+	if day > last.Day() {
+		return last
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+/*
+FirstRun returns the first date on or after the start date the order runs at.
+*/
+func (f StandingOrderFrequency) FirstRun(start time.Time, day int) time.Time {
+	switch f {
+	case FrequencyMonthly:
+		d := dayOfMonth(start.Year(), start.Month(), day)
+		if d.Before(start) {
+			d = dayOfMonth(start.Year(), start.Month()+1, day)
+		}
+		return d
+	case FrequencyEndOfMonth:
+		return lastDayOfMonth(start.Year(), start.Month())
+	default:
+		return start
+	}
+}
+
+/*
+NextRun returns the date the order runs at after the given run date, nil for a one-off order.
+Monthly orders run on the given day, or on the last day of shorter months.
+*/
+func (f StandingOrderFrequency) NextRun(after time.Time, day int) *time.Time {
+	var next time.Time
+
+	switch f {
+	case FrequencyWeekly:
+		next = after.AddDate(0, 0, 7)
+	case FrequencyMonthly:
+		next = dayOfMonth(after.Year(), after.Month()+1, day)
+	case FrequencyEndOfMonth:
+		next = lastDayOfMonth(after.Year(), after.Month()+1)
+	default:
+		return nil
+	}
+
+	return &next
+}
+
+type StandingOrderStatus string
+
+const (
+	StandingOrderActive    StandingOrderStatus = "active"
+	StandingOrderCompleted StandingOrderStatus = "completed"
+	StandingOrderCancelled StandingOrderStatus = "cancelled"
+)
+
+type StandingOrder struct {
+	ID         uint                   `db:"id"`
+	AccountID  uint                   `db:"account_id"`
+	To         uint                   `db:"to_id"`
+	Amount     []uint8                `db:"amount"`
+	Frequency  StandingOrderFrequency `db:"frequency"`
+	DayOfMonth *int                   `db:"day_of_month"`
+	StartDate  time.Time              `db:"start_date"`
+	EndDate    *time.Time             `db:"end_date"`
+	// Unset once the order is completed or cancelled
+	NextRunDate *time.Time `db:"next_run_date"`
+	// Failed attempts of the next run and when to retry it
+	Attempts      int                 `db:"attempts"`
+	NextAttemptAt *time.Time          `db:"next_attempt_at"`
+	Status        StandingOrderStatus `db:"status"`
+	CreatedAt     time.Time           `db:"created_at"`
+	UpdatedAt     time.Time           `db:"updated_at"`
+}
+
+type SerializedStandingOrder struct {
+	ID            uint                         `json:"id"`
+	AccountID     uint                         `json:"account_id"`
+	To            uint                         `json:"to"`
+	Amount        float64                      `json:"amount"`
+	Frequency     StandingOrderFrequency       `json:"frequency"`
+	DayOfMonth    *int                         `json:"day_of_month,omitempty"`
+	StartDate     time.Time                    `json:"start_date"`
+	EndDate       *time.Time                   `json:"end_date,omitempty"`
+	NextRunDate   *time.Time                   `json:"next_run_date,omitempty"`
+	Attempts      int                          `json:"attempts"`
+	NextAttemptAt *time.Time                   `json:"next_attempt_at,omitempty"`
+	Status        StandingOrderStatus          `json:"status"`
+	Runs          []SerializedStandingOrderRun `json:"runs,omitempty"`
+	CreatedAt     time.Time                    `json:"created_at"`
+	UpdatedAt     time.Time                    `json:"updated_at"`
+}
+
+func (o *StandingOrder) Serialize() SerializedStandingOrder {
+	return SerializedStandingOrder{
+		ID:            o.ID,
+		AccountID:     o.AccountID,
+		To:            o.To,
+		Amount:        utils.Uint8ToFloat(o.Amount),
+		Frequency:     o.Frequency,
+		DayOfMonth:    o.DayOfMonth,
+		StartDate:     o.StartDate,
+		EndDate:       o.EndDate,
+		NextRunDate:   o.NextRunDate,
+		Attempts:      o.Attempts,
+		NextAttemptAt: o.NextAttemptAt,
+		Status:        o.Status,
+		CreatedAt:     o.CreatedAt,
+		UpdatedAt:     o.UpdatedAt,
+	}
+}
+
+type StandingOrderRunStatus string
+
+const (
+	RunSucceeded StandingOrderRunStatus = "succeeded"
+	RunFailed    StandingOrderRunStatus = "failed"
+)
+
+/*
+StandingOrderRun is an attempt to execute a standing order for one of its run dates.
+*/
+type StandingOrderRun struct {
+	ID              uint                   `db:"id"`
+	StandingOrderID uint                   `db:"standing_order_id"`
+	RunDate         time.Time              `db:"run_date"`
+	Attempt         int                    `db:"attempt"`
+	Status          StandingOrderRunStatus `db:"status"`
+	Error           *string                `db:"error"`
+	TransactionID   *uint                  `db:"transaction_id"`
+	CreatedAt       time.Time              `db:"created_at"`
+}
+
+type SerializedStandingOrderRun struct {
+	ID            uint                   `json:"id"`
+	RunDate       time.Time              `json:"run_date"`
+	Attempt       int                    `json:"attempt"`
+	Status        StandingOrderRunStatus `json:"status"`
+	Error         *string                `json:"error,omitempty"`
+	TransactionID *uint                  `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+}
+
+func (r *StandingOrderRun) Serialize() SerializedStandingOrderRun {
+	return SerializedStandingOrderRun{
+		ID:            r.ID,
+		RunDate:       r.RunDate,
+		Attempt:       r.Attempt,
+		Status:        r.Status,
+		Error:         r.Error,
+		TransactionID: r.TransactionID,
+		CreatedAt:     r.CreatedAt,
+	}
+}
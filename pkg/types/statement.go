@@ -0,0 +1,120 @@
+package types
+
+import "time"
+
+type StatementFormat string
+
+const (
+	StatementJSON StatementFormat = "json"
+	StatementCSV  StatementFormat = "csv"
+	StatementHTML StatementFormat = "html"
+	// Bank formats imported by accounting software
+	StatementCamt053 StatementFormat = "camt053"
+	StatementMT940   StatementFormat = "mt940"
+)
+
+/*
+IsValid reports whether the format is a known statement format.
+*/
+func (f StatementFormat) IsValid() bool {
+	switch f {
+	case StatementJSON, StatementCSV, StatementHTML, StatementCamt053, StatementMT940:
+		return true
+	default:
+		return false
+	}
+}
+
+/*
+IsBankFormat reports whether the format is one of the bank formats, camt.053 or MT940.
+*/
+func (f StatementFormat) IsBankFormat() bool {
+	return f == StatementCamt053 || f == StatementMT940
+}
+
+/*
+LedgerEntry is a transaction as seen from one of its accounts, with the IBAN of the other one.
+*/
+type LedgerEntry struct {
+	Transaction
+	CounterpartyIBAN *string `db:"counterparty_iban"`
+}
+
+/*
+StatementLine is a transaction of a statement, signed from the point of view of the account,
+with the balance of the account after it.
+The memo of the sender is left out, a statement being shared as is.
+*/
+type StatementLine struct {
+	TransactionID     uint            `json:"transaction_id"`
+	Date              time.Time       `json:"date"`
+	Type              TransactionType `json:"type"`
+	Counterparty      string          `json:"counterparty,omitempty"`
+	Reference         string          `json:"reference,omitempty"`
+	CreditorReference string          `json:"creditor_reference,omitempty"`
+	Amount            float64         `json:"amount"`
+	Balance           float64         `json:"balance"`
+}
+
+/*
+StatementDocument is the content of a statement, from which every format is rendered.
+*/
+type StatementDocument struct {
+	AccountID      uint            `json:"account_id"`
+	IBAN           string          `json:"iban"`
+	Currency       string          `json:"currency"`
+	PeriodStart    time.Time       `json:"period_start"`
+	PeriodEnd      time.Time       `json:"period_end"`
+	OpeningBalance float64         `json:"opening_balance"`
+	TotalIn        float64         `json:"total_in"`
+	TotalOut       float64         `json:"total_out"`
+	Fees           float64         `json:"fees"`
+	Interest       float64         `json:"interest"`
+	ClosingBalance float64         `json:"closing_balance"`
+	Lines          []StatementLine `json:"lines"`
+	GeneratedAt    time.Time       `json:"generated_at"`
+}
+
+/*
+Statement is a statement of an account for a period, immutable once generated.
+Document is the JSON of its StatementDocument and Hash the hex SHA-256 of it.
+*/
+type Statement struct {
+	ID          uint      `db:"id"`
+	AccountID   uint      `db:"account_id"`
+	PeriodStart time.Time `db:"period_start"`
+	PeriodEnd   time.Time `db:"period_end"`
+	Document    string    `db:"document"`
+	Hash        string    `db:"hash"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+type SerializedStatement struct {
+	ID          uint      `json:"id"`
+	AccountID   uint      `json:"account_id"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Hash        string    `json:"hash"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (s *Statement) Serialize() SerializedStatement {
+	return SerializedStatement{
+		ID:          s.ID,
+		AccountID:   s.AccountID,
+		PeriodStart: s.PeriodStart,
+		PeriodEnd:   s.PeriodEnd,
+		Hash:        s.Hash,
+		CreatedAt:   s.CreatedAt,
+	}
+}
+
+/*
+StatementFile is a statement rendered in one of the formats.
+*/
+type StatementFile struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+	Hash        string
+}
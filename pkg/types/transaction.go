@@ -6,31 +6,142 @@ import (
 	"github.com/farischt/gobank/utils"
 )
 
+type TransactionType string
+
+const (
+	TransactionTransfer TransactionType = "transfer"
+	TransactionInterest TransactionType = "interest"
+	TransactionFee      TransactionType = "fee"
+	TransactionReversal TransactionType = "reversal"
+)
+
+type TransactionStatus string
+
+const (
+	TransactionCompleted         TransactionStatus = "completed"
+	TransactionPartiallyReversed TransactionStatus = "partially_reversed"
+	TransactionReversed          TransactionStatus = "reversed"
+)
+
 type Transaction struct {
-	ID        uint      `db:"id"`
-	From      uint      `db:"from"`
-	To        uint      `db:"to"`
-	Amount    []uint8   `db:"amount"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
+	ID       uint    `db:"id"`
+	From     uint    `db:"from_id"`
+	To       uint    `db:"to_id"`
+	Amount   []uint8 `db:"amount"`
+	Currency string  `db:"currency"`
+	// Credited to the recipient, in its currency
+	ToAmount   []uint8         `db:"to_amount"`
+	ToCurrency string          `db:"to_currency"`
+	FxRate     []uint8         `db:"fx_rate"`
+	Type       TransactionType `db:"type"`
+	ParentID   *uint           `db:"parent_id"`
+	// Set by the sender so that a retried transfer is only made once
+	IdempotencyKey *string           `db:"idempotency_key"`
+	Status         TransactionStatus `db:"status"`
+	ReversalOf     *uint             `db:"reversal_of"`
+	ReversedAmount []uint8           `db:"reversed_amount"`
+	// Set by the sender, the memo is only seen by the sender
+	Reference         *string   `db:"reference"`
+	CreditorReference *string   `db:"creditor_reference"`
+	Memo              *string   `db:"memo"`
+	CreatedAt         time.Time `db:"created_at"`
+	UpdatedAt         time.Time `db:"updated_at"`
 }
 
 type SerializedTransaction struct {
-	ID        uint      `json:"id"`
-	From      uint      `json:"from"`
-	To        uint      `json:"to"`
-	Amount    float64   `json:"amount"`
-	CreatedAt time.Time `json:"created_at" omitempty:"true"`
-	UpdatedAt time.Time `json:"updated_at" omitempty:"true"`
+	ID                uint              `json:"id"`
+	From              uint              `json:"from"`
+	To                uint              `json:"to"`
+	Amount            float64           `json:"amount"`
+	Currency          string            `json:"currency"`
+	ToAmount          float64           `json:"to_amount"`
+	ToCurrency        string            `json:"to_currency"`
+	FxRate            *float64          `json:"fx_rate,omitempty"`
+	Type              TransactionType   `json:"type"`
+	ParentID          *uint             `json:"parent_id,omitempty"`
+	Status            TransactionStatus `json:"status"`
+	ReversalOf        *uint             `json:"reversal_of,omitempty"`
+	ReversedAmount    float64           `json:"reversed_amount,omitempty"`
+	Reference         *string           `json:"reference,omitempty"`
+	CreditorReference *string           `json:"creditor_reference,omitempty"`
+	Memo              *string           `json:"memo,omitempty"`
+	CreatedAt         time.Time         `json:"created_at" omitempty:"true"`
+	UpdatedAt         time.Time         `json:"updated_at" omitempty:"true"`
 }
 
 func SerializeTransaction(t Transaction) SerializedTransaction {
+	var fxRate *float64
+	if t.FxRate != nil {
+		rate := utils.Uint8ToFloat(t.FxRate)
+		fxRate = &rate
+	}
+
 	return SerializedTransaction{
-		ID:        t.ID,
-		From:      t.From,
-		To:        t.To,
-		Amount:    utils.Uint8ToFloat(t.Amount),
-		CreatedAt: t.CreatedAt,
-		UpdatedAt: t.UpdatedAt,
+		ID:                t.ID,
+		From:              t.From,
+		To:                t.To,
+		Amount:            utils.Uint8ToFloat(t.Amount),
+		Currency:          t.Currency,
+		ToAmount:          utils.Uint8ToFloat(t.ToAmount),
+		ToCurrency:        t.ToCurrency,
+		FxRate:            fxRate,
+		Type:              t.Type,
+		ParentID:          t.ParentID,
+		Status:            t.Status,
+		ReversalOf:        t.ReversalOf,
+		ReversedAmount:    utils.Uint8ToFloat(t.ReversedAmount),
+		Reference:         t.Reference,
+		CreditorReference: t.CreditorReference,
+		CreatedAt:         t.CreatedAt,
+		UpdatedAt:         t.UpdatedAt,
 	}
 }
+
+/*
+SerializeSentTransaction serializes a transaction for its sender, with the memo only the sender can see.
+*/
+func SerializeSentTransaction(t Transaction) SerializedTransaction {
+	s := SerializeTransaction(t)
+	s.Memo = t.Memo
+	return s
+}
+
+/*
+TransactionEntry is a transaction to record.
+Amount is debited in Currency and ToAmount credited in ToCurrency, converted at FxRate
+when the currencies differ.
+ParentID links a fee to the transaction it was charged for, ReversalOf links a reversal
+to the transaction it reverses.
+*/
+type TransactionEntry struct {
+	Type              TransactionType
+	From              uint
+	To                uint
+	Amount            float64
+	Currency          string
+	ToAmount          float64
+	ToCurrency        string
+	FxRate            *float64
+	ParentID          *uint
+	ReversalOf        *uint
+	IdempotencyKey    *string
+	Reference         *string
+	CreditorReference *string
+	Memo              *string
+}
+
+/*
+Reversal is a reversal and the transaction it reverses, as it is after the reversal.
+*/
+type Reversal struct {
+	Reversal SerializedTransaction `json:"reversal"`
+	Original SerializedTransaction `json:"original"`
+}
+
+/*
+OutgoingTotals are the total amount and number of transfers sent by an account over a period.
+*/
+type OutgoingTotals struct {
+	Amount float64 `db:"amount"`
+	Count  int     `db:"count"`
+}
@@ -3,30 +3,78 @@ package types
 import "time"
 
 type User struct {
-	ID        uint      `db:"id"`
-	FirstName string    `db:"first_name"`
-	LastName  string    `db:"last_name"`
-	Email     string    `db:"email"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
+	ID        uint   `db:"id"`
+	FirstName string `db:"first_name"`
+	LastName  string `db:"last_name"`
+	Email     string `db:"email"`
+	// Unset for the users still logging in with the password of an account
+	Password   *string    `db:"password"`
+	VerifiedAt *time.Time `db:"verified_at"`
+	DeletedAt  *time.Time `db:"deleted_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at"`
 }
 
 type SerializedUser struct {
-	ID        uint      `json:"id"`
-	FirstName string    `json:"first_name"`
-	LastName  string    `json:"last_name"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at" omitempty:"true"`
-	UpdatedAt time.Time `json:"updated_at" omitempty:"true"`
+	ID         uint       `json:"id"`
+	FirstName  string     `json:"first_name"`
+	LastName   string     `json:"last_name"`
+	Email      string     `json:"email"`
+	VerifiedAt *time.Time `json:"verified_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" omitempty:"true"`
+	UpdatedAt  time.Time  `json:"updated_at" omitempty:"true"`
 }
 
 func (u *User) Serialize() SerializedUser {
 	return SerializedUser{
-		ID:        u.ID,
-		FirstName: u.FirstName,
-		LastName:  u.LastName,
-		Email:     u.Email,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:         u.ID,
+		FirstName:  u.FirstName,
+		LastName:   u.LastName,
+		Email:      u.Email,
+		VerifiedAt: u.VerifiedAt,
+		DeletedAt:  u.DeletedAt,
+		CreatedAt:  u.CreatedAt,
+		UpdatedAt:  u.UpdatedAt,
 	}
 }
+
+/*
+IsVerified reports whether the user has confirmed its current email address.
+*/
+func (u *User) IsVerified() bool {
+	return u.VerifiedAt != nil
+}
+
+/*
+HasPassword reports whether the user can log in with its email and password.
+*/
+func (u *User) HasPassword() bool {
+	return u.Password != nil && *u.Password != ""
+}
+
+/*
+IsDeleted reports whether the user has been deleted, i.e. anonymized.
+*/
+func (u *User) IsDeleted() bool {
+	return u.DeletedAt != nil
+}
+
+/*
+UserExport is the bundle of everything held about a user, on every account the user holds.
+The role of the user on each account is given by its holder_role.
+*/
+type UserExport struct {
+	ExportedAt      time.Time                  `json:"exported_at"`
+	User            SerializedUser             `json:"user"`
+	Roles           []SerializedUserRole       `json:"roles"`
+	Accounts        []SerializedAccount        `json:"accounts"`
+	Transactions    []SerializedTransaction    `json:"transactions"`
+	StandingOrders  []SerializedStandingOrder  `json:"standing_orders"`
+	Holds           []SerializedHold           `json:"holds"`
+	PaymentRequests []SerializedPaymentRequest `json:"payment_requests"`
+	Statements      []SerializedStatement      `json:"statements"`
+	ApiKeys         []SerializedApiKey         `json:"api_keys"`
+	Beneficiaries   []SerializedBeneficiary    `json:"beneficiaries"`
+	AuditEntries    []SerializedAuditEntry     `json:"audit_entries"`
+}
@@ -1,8 +1,22 @@
 package utils
 
-import "strconv"
+import (
+	"math"
+	"strconv"
+)
 
 func Uint8ToFloat(u []uint8) float64 {
 	b, _ := strconv.ParseFloat(string(u), 64)
 	return b
 }
+
+/*
+RoundHalfEven rounds the value to the given number of decimal places,
+rounding halves to the nearest even digit (banker's rounding).
+*/
+func RoundHalfEven(v float64, places int) float64 {
+	p := math.Pow10(places)
+	// Round the scaled value first so that binary representation errors don't decide the tie
+	scaled, _ := strconv.ParseFloat(strconv.FormatFloat(v*p, 'f', 6, 64), 64)
+	return math.RoundToEven(scaled) / p
+}
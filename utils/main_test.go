@@ -0,0 +1,33 @@
+package utils
+
+import "testing"
+
+func TestRoundHalfEven(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  float64
+		places int
+		want   float64
+	}{
+		{"half rounds down to even", 0.125, 2, 0.12},
+		{"half rounds up to even", 0.135, 2, 0.14},
+		{"above half rounds up", 0.1251, 2, 0.13},
+		{"below half rounds down", 0.1249, 2, 0.12},
+		{"binary representation of a half", 1.005, 2, 1.0},
+		{"binary representation of a half rounding up", 2.675, 2, 2.68},
+		{"negative half rounds to even", -0.125, 2, -0.12},
+		{"integer half rounds to even", 2.5, 0, 2},
+		{"integer half rounds up to even", 3.5, 0, 4},
+		{"eight decimals", 0.123456785, 8, 0.12345678},
+		{"already rounded", 10.5, 2, 10.5},
+		{"zero", 0, 2, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RoundHalfEven(tt.value, tt.places); got != tt.want {
+				t.Errorf("RoundHalfEven(%v, %d) = %v, want %v", tt.value, tt.places, got, tt.want)
+			}
+		})
+	}
+}